@@ -0,0 +1,425 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/sdexmon/sdexmon/internal/alerts"
+	"github.com/sdexmon/sdexmon/internal/notify"
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+)
+
+const (
+	alertInterval = 30 * time.Second
+	notifyTimeout = 30 * time.Second // per event and sink, retries included
+	alertRows     = 30
+)
+
+// alertSinks receive every alert event: the log, then the configured sinks
+var alertSinks = []alerts.Sink{logSink{}}
+
+// configureAlertSinks adds the sinks listed under alerts.sinks. Sinks that
+// are missing settings are logged and returned as problems.
+func configureAlertSinks() []string {
+	alertSinks = []alerts.Sink{logSink{}}
+	if appConfig == nil {
+		return nil
+	}
+	var problems []string
+	for _, sc := range appConfig.Alerts.Sinks {
+		s, err := notify.New(notify.Spec{
+			Name: sc.Name, Type: sc.Type, URL: sc.URL, Headers: sc.Headers,
+			SMTP: sc.SMTP, From: sc.From, To: sc.To, User: sc.User, Pass: os.Getenv(sc.PasswordEnv),
+			Command: sc.Command,
+			Options: notify.Options{Limit: sc.Limit, Per: sc.Per, Retries: sc.Retries, Backoff: sc.Backoff,
+				DryRun: sc.DryRun || appConfig.Alerts.DryRun, Logf: log.Printf},
+		})
+		if err != nil {
+			log.Printf("Alerts: %v", err)
+			problems = append(problems, err.Error())
+			continue
+		}
+		alertSinks = append(alertSinks, s)
+	}
+	return problems
+}
+
+// logSink writes alert events to the log
+type logSink struct{}
+
+func (logSink) Name() string { return "log" }
+
+func (logSink) Notify(_ context.Context, e alerts.Event) error {
+	a := e.Alert
+	if e.Resolved {
+		log.Printf("ALERT resolved: %s %s (%s)", a.Rule, a.Pair, a.Condition)
+	} else {
+		log.Printf("ALERT %s: %s %s (%s) value %s", firstNonEmpty(a.Severity, "alert"), a.Rule, a.Pair, a.Condition, alertValue(a.Value))
+	}
+	return nil
+}
+
+// alertPair is a pair some rule watches, with the data its rules need
+type alertPair struct {
+	key          string
+	base, quote  txnbuild.Asset
+	book, trades bool
+	lp           bool
+}
+
+type (
+	alertTickMsg    struct{}
+	alertSamplesMsg struct{ samples []alerts.Sample }
+)
+
+func alertTick() tea.Cmd {
+	return tea.Tick(alertInterval, func(time.Time) tea.Msg { return alertTickMsg{} })
+}
+
+// alertPairKey tags samples with the pair they describe
+func alertPairKey(base, quote txnbuild.Asset) string {
+	return getAssetName(base) + "/" + getAssetName(quote)
+}
+
+// newAlertEngine compiles the configured rules. Rules that do not parse or
+// name unknown assets are logged and returned as problems.
+func newAlertEngine() (*alerts.Engine, []alertPair, []string) {
+	var rules []alerts.Rule
+	var problems []string
+	pairs := map[string]*alertPair{}
+	var order []string
+	if appConfig != nil {
+		for _, ar := range appConfig.Alerts.Rules {
+			key, label := "", ""
+			var base, quote txnbuild.Asset
+			if ar.Pair != "" {
+				codes := strings.SplitN(ar.Pair, "/", 2)
+				var err1, err2 error
+				if len(codes) == 2 {
+					base, err1 = resolveAssetArg(codes[0])
+					quote, err2 = resolveAssetArg(codes[1])
+				}
+				if len(codes) != 2 || err1 != nil || err2 != nil {
+					problems = append(problems, fmt.Sprintf("alert %q: unknown pair %q", ar.Name, ar.Pair))
+					continue
+				}
+				key, label = alertPairKey(base, quote), ar.Pair
+			}
+			r, err := alerts.Parse(ar.Name, key, label, ar.When, ar.For)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			r.Severity = ar.Severity
+			rules = append(rules, r)
+			if key == "" {
+				continue
+			}
+			p := pairs[key]
+			if p == nil {
+				p = &alertPair{key: key, base: base, quote: quote}
+				pairs[key] = p
+				order = append(order, key)
+			}
+			switch r.Metric {
+			case alerts.MetricSpread, alerts.MetricMid:
+				p.book = true
+			case alerts.MetricTradeAge:
+				p.trades = true
+			case alerts.MetricLPLocked, alerts.MetricLPLockedChange:
+				p.lp = true
+			}
+		}
+	}
+	for _, p := range problems {
+		log.Printf("Alerts: %v", p)
+	}
+	watched := make([]alertPair, len(order))
+	for i, k := range order {
+		watched[i] = *pairs[k]
+	}
+	return alerts.NewEngine(rules), watched, problems
+}
+
+// reloadAlerts rebuilds the sinks and rules from the reloaded config. Rules
+// that did not change keep their firing state; alerts of the others resolve.
+func (m *model) reloadAlerts() tea.Cmd {
+	problems := configureAlertSinks()
+	engine, watched, ruleProblems := newAlertEngine()
+	resolved := engine.Adopt(m.alerts, time.Now())
+	m.alerts, m.alertPairs = engine, watched
+	m.alertNote = strings.Join(append(problems, ruleProblems...), "; ")
+	cmds := []tea.Cmd{dispatchAlerts(resolved)}
+	if !m.alertTicks && len(engine.Rules()) > 0 {
+		m.alertTicks = true
+		cmds = append(cmds, m.pollAlertPairs(m.livePairKey()), alertTick())
+	}
+	return tea.Batch(cmds...)
+}
+
+// observeAlerts feeds samples to the engine and dispatches what fires
+func (m model) observeAlerts(samples ...alerts.Sample) tea.Cmd {
+	if m.alerts == nil || len(samples) == 0 {
+		return nil
+	}
+	return dispatchAlerts(m.alerts.Observe(samples...))
+}
+
+// dispatchAlerts sends events to every sink; failures are logged
+func dispatchAlerts(events []alerts.Event) tea.Cmd {
+	if len(events) == 0 {
+		return nil
+	}
+	sinks := append([]alerts.Sink(nil), alertSinks...)
+	return func() tea.Msg {
+		var wg sync.WaitGroup
+		for _, s := range sinks {
+			wg.Add(1)
+			go func(s alerts.Sink) {
+				defer wg.Done()
+				for _, e := range events {
+					ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+					if err := s.Notify(ctx, e); err != nil {
+						log.Printf("Alert sink %s: %v", s.Name(), err)
+					}
+					cancel()
+				}
+			}(s)
+		}
+		wg.Wait()
+		return nil
+	}
+}
+
+// bookSamples reads the spread and mid of a book; empty when one side is
+// missing
+func bookSamples(key string, ob orderbook.Book, at time.Time) []alerts.Sample {
+	var out []alerts.Sample
+	if sp := ob.SpreadPercent(); sp != nil {
+		out = append(out, alerts.Sample{Pair: key, Metric: alerts.MetricSpread, Value: orderbook.Float(sp) * 100, At: at})
+	}
+	if mid := ob.Mid(); mid != nil {
+		out = append(out, alerts.Sample{Pair: key, Metric: alerts.MetricMid, Value: orderbook.Float(mid), At: at})
+	}
+	return out
+}
+
+// lpSamples reads the amount of each asset locked in a pool
+func lpSamples(key string, lp Liquidity, at time.Time) []alerts.Sample {
+	var out []alerts.Sample
+	for i, code := range lp.Codes {
+		v := lp.LockedR[i]
+		if code == "" || v == nil {
+			continue
+		}
+		out = append(out, alerts.Sample{Pair: key, Metric: alerts.MetricLPLocked, Asset: strings.ToUpper(code), Value: orderbook.Float(v), At: at})
+	}
+	return out
+}
+
+// pollAlertPairs fetches what the rules need for the watched pairs other
+// than skip, whose data already arrives through the pair feeds
+func (m model) pollAlertPairs(skip string) tea.Cmd {
+	var pairs []alertPair
+	for _, p := range m.alertPairs {
+		if p.key != skip {
+			pairs = append(pairs, p)
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+	client := m.client
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), overviewTimeout)
+		defer cancel()
+		c := scopedClient(client, ctx)
+		var samples []alerts.Sample
+		for _, got := range fanOut(ctx, len(pairs), func(i int) []alerts.Sample {
+			return fetchAlertSamples(ctx, c, pairs[i])
+		}) {
+			samples = append(samples, got...)
+		}
+		return alertSamplesMsg{samples: samples}
+	}
+}
+
+func fetchAlertSamples(ctx context.Context, client *horizonclient.Client, p alertPair) []alerts.Sample {
+	var out []alerts.Sample
+	now := time.Now()
+	if p.book {
+		if ob, err := fetchMergedOrderbook(client, p.base, p.quote); err == nil {
+			out = append(out, bookSamples(p.key, ob, now)...)
+		}
+	}
+	if p.trades {
+		req := horizonclient.TradeRequest{Order: horizonclient.OrderDesc, Limit: 1}
+		applyBaseAsset(&req, p.base)
+		applyCounterAsset(&req, p.quote)
+		if page, err := client.Trades(req); err == nil && len(page.Embedded.Records) > 0 {
+			out = append(out, alerts.LastTrade(p.key, page.Embedded.Records[0].LedgerCloseTime, now))
+		}
+	}
+	if p.lp {
+		if poolID, _ := resolvePoolID(client, p.base, p.quote); poolID != "" {
+			if lp, err := fetchLP(ctx, client, poolID); err == nil {
+				out = append(out, lpSamples(p.key, lp, now)...)
+			}
+		}
+	}
+	return out
+}
+
+// livePairKey is the pair whose feeds are running, if any
+func (m model) livePairKey() string {
+	if m.base == nil || m.quote == nil {
+		return ""
+	}
+	return alertPairKey(m.base, m.quote)
+}
+
+func (m model) openAlerts() (tea.Model, tea.Cmd) {
+	m.alertReturn = m.currentScreen
+	m.currentScreen = screenAlerts
+	m.showPairPopup = false
+	m.alertIndex = 0
+	return m, nil
+}
+
+func (m model) handleAlertKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var history []alerts.Alert
+	if m.alerts != nil {
+		history = m.alerts.History()
+	}
+	switch msg.String() {
+	case "esc", "b", "!":
+		m.currentScreen = m.alertReturn
+		if m.currentScreen == screenPairInfo && (m.base == nil || m.quote == nil) {
+			m.currentScreen = screenLanding
+		}
+		return m, nil
+	case "up", "k":
+		if m.alertIndex > 0 {
+			m.alertIndex--
+		}
+	case "down", "j":
+		if m.alertIndex < min(len(history), alertRows)-1 {
+			m.alertIndex++
+		}
+	case "enter", " ":
+		if m.alertIndex < len(history) {
+			m.alerts.Ack(history[m.alertIndex].ID)
+		}
+	case "A":
+		if m.alerts != nil {
+			m.alerts.Ack(0)
+		}
+	}
+	return m, nil
+}
+
+// alertBadge flags unacknowledged alerts in the footer
+func (m model) alertBadge() string {
+	if m.alerts == nil {
+		return ""
+	}
+	if n := m.alerts.Unacked(); n > 0 {
+		return fmt.Sprintf("⚠ %d alert%s (!)  ", n, plural(n))
+	}
+	return ""
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+func alertValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
+
+func alertsView(m model) string {
+	var lines []string
+	var history []alerts.Alert
+	rules := 0
+	if m.alerts != nil {
+		history = m.alerts.History()
+		rules = len(m.alerts.Rules())
+	}
+	row := func(cols ...string) string {
+		widths := []int{8, 9, 22, 14, 36, 12}
+		out := make([]string, len(cols))
+		for i, c := range cols {
+			if i < len(widths) {
+				out[i] = padRightVis(truncateMiddle(c, widths[i]), widths[i])
+			} else {
+				out[i] = c
+			}
+		}
+		return strings.Join(out, " ")
+	}
+	switch {
+	case rules == 0:
+		lines = append(lines, dimStyle.Render("no alert rules configured; add alerts.rules to config.yaml"))
+	case len(history) == 0:
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("%d rules, nothing has fired", rules)))
+	default:
+		lines = append(lines, dimStyle.Render(row("FIRED", "SEVERITY", "RULE", "PAIR", "CONDITION", "VALUE", "STATUS")))
+		for i, a := range history {
+			if i == alertRows {
+				lines = append(lines, dimStyle.Render(fmt.Sprintf("… %d older", len(history)-alertRows)))
+				break
+			}
+			status := "FIRING"
+			if !a.Active() {
+				status = "resolved " + a.ResolvedAt.Local().Format("15:04:05")
+			}
+			if a.Acked {
+				status += ", acked"
+			}
+			line := row(a.FiredAt.Local().Format("15:04:05"), firstNonEmpty(a.Severity, "-"), a.Rule,
+				firstNonEmpty(a.Pair, "network"), a.Condition, alertValue(a.Value), status)
+			switch {
+			case i == m.alertIndex:
+				line = selectedStyle.Render(line)
+			case !a.Active() || a.Acked:
+				line = dimStyle.Render(line)
+			case strings.EqualFold(a.Severity, "critical"):
+				line = errorStyle.Render(line)
+			default:
+				line = warnStyle.Render(line)
+			}
+			lines = append(lines, line)
+		}
+	}
+	if m.alertNote != "" {
+		lines = append(lines, "", errorStyle.Render(m.alertNote))
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		renderVersionInfo(),
+		"",
+		renderHeader(),
+		renderSubtitle("Alerts - rules from config.yaml, newest first"),
+		panelStyle.Render(strings.Join(lines, "\n")),
+	)
+	targetHeight := 60
+	if m.height > 0 {
+		targetHeight = m.height
+	}
+	padding := strings.Repeat("\n", max(0, targetHeight-lipgloss.Height(content)-2))
+	return lipgloss.JoinVertical(lipgloss.Left, content, padding, m.bottomLine())
+}
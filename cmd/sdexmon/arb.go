@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/sdexmon/sdexmon/internal/arb"
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+)
+
+const (
+	arbInterval = 15 * time.Second
+	arbRows     = 20
+)
+
+type arbDataMsg struct {
+	gen    uint64
+	cycles []arb.Cycle
+	quoted int // pairs with a two-sided or one-sided book
+	failed int
+	at     time.Time
+}
+
+// arbFeeBps is the cost assumed per leg. SDEX offers charge no percentage
+// fee, so it defaults to zero; set preferences.arb_fee_bps to allow for
+// slippage and transaction fees.
+func arbFeeBps() float64 {
+	if appConfig != nil {
+		return appConfig.Preferences.ArbFeeBps
+	}
+	return 0
+}
+
+func (m model) openArb() (tea.Model, tea.Cmd) {
+	m.showRefreshing(screenArb)
+	return m, m.startRefreshing()
+}
+
+func (m model) refreshArb() tea.Cmd {
+	client, gen, fee := m.client, m.arbGen, arbFeeBps()
+	pairs := append([]pairOption(nil), configuredPairs...)
+	visit := screenContext(screenArb, gen)
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(visit, overviewTimeout)
+		defer cancel()
+		quotes, failed := fetchTopOfBooks(ctx, scopedClient(client, ctx), pairs)
+		return arbDataMsg{gen: gen, cycles: arb.Find(quotes, fee, 0), quoted: len(quotes), failed: failed, at: time.Now()}
+	}
+}
+
+// fetchTopOfBooks reads the best bid and ask of every pair, sharing the
+// background fetch slots
+func fetchTopOfBooks(ctx context.Context, client *horizonclient.Client, pairs []pairOption) ([]arb.Quote, int) {
+	labels := arbLabels(pairs)
+	type book struct {
+		base, quote txnbuild.Asset
+		ob          orderbook.Book
+		err         error
+	}
+	var books []book
+	for _, p := range pairs {
+		if base, quote, ok := p.assets(); ok {
+			books = append(books, book{base: base, quote: quote})
+		}
+	}
+	got := fanOut(ctx, len(books), func(i int) book {
+		b := books[i]
+		b.ob, b.err = fetchMergedOrderbook(client, b.base, b.quote)
+		return b
+	})
+	var quotes []arb.Quote
+	failed := 0
+	for _, b := range got {
+		if b.err != nil {
+			failed++
+			continue
+		}
+		if b.ob.Empty() {
+			continue
+		}
+		q := arb.Quote{Base: labels[getAssetName(b.base)], Quote: labels[getAssetName(b.quote)]}
+		if len(b.ob.Bids) > 0 {
+			q.Bid, q.BidSize = orderbook.Float(b.ob.Bids[0].Price), orderbook.Float(b.ob.Bids[0].Amount)
+		}
+		if len(b.ob.Asks) > 0 {
+			q.Ask, q.AskSize = orderbook.Float(b.ob.Asks[0].Price), orderbook.Float(b.ob.Asks[0].Amount)
+		}
+		quotes = append(quotes, q)
+	}
+	return quotes, failed
+}
+
+// arbLabels names each asset by its code, adding the start of the issuer
+// when two assets share a code so they stay separate nodes
+func arbLabels(pairs []pairOption) map[string]string {
+	byCode := map[string]map[string]bool{}
+	var assets []txnbuild.Asset
+	for _, p := range pairs {
+		if base, quote, ok := p.assets(); ok {
+			assets = append(assets, base, quote)
+		}
+	}
+	for _, a := range assets {
+		code := assetShort(a)
+		if byCode[code] == nil {
+			byCode[code] = map[string]bool{}
+		}
+		byCode[code][getAssetName(a)] = true
+	}
+	labels := map[string]string{}
+	for _, a := range assets {
+		code := assetShort(a)
+		labels[getAssetName(a)] = code
+		if c, ok := a.(txnbuild.CreditAsset); ok && len(byCode[code]) > 1 {
+			labels[getAssetName(a)] = code + "-" + c.Issuer[:4]
+		}
+	}
+	return labels
+}
+
+func (m model) handleArbKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m, cmd, _ := m.handleRefreshingKeys(msg, "a")
+	return m, cmd
+}
+
+func arbView(m model) string {
+	var lines []string
+	switch {
+	case m.arbAt.IsZero():
+		lines = append(lines, dimStyle.Render("loading order books..."))
+	case len(m.arbCycles) == 0:
+		lines = append(lines, dimStyle.Render("no triangle returns more than it costs at the top of book"))
+	default:
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("%-34s %9s %14s %14s  %s", "CYCLE", "RETURN BP", "SIZE", "PROFIT", "LEGS")))
+		for i, c := range m.arbCycles {
+			if i == arbRows {
+				lines = append(lines, dimStyle.Render(fmt.Sprintf("… %d more", len(m.arbCycles)-arbRows)))
+				break
+			}
+			legs := make([]string, len(c.Legs))
+			for j, l := range c.Legs {
+				legs[j] = fmt.Sprintf("%s %s", l.Side, l.Pair)
+			}
+			line := fmt.Sprintf("%-34s %9s %14s %14s  %s", c.Path(),
+				fmt.Sprintf("%+.1f", c.ReturnBps()),
+				pegPrice(c.Size)+" "+c.Start(), pegPrice(c.Profit), strings.Join(legs, ", "))
+			lines = append(lines, greenStyle.Render(line))
+		}
+	}
+	if !m.arbAt.IsZero() {
+		info := fmt.Sprintf("%d books at %s, %.0fbp cost per leg, sizes limited by the top level of each book",
+			m.arbQuoted, m.arbAt.Local().Format("15:04:05"), arbFeeBps())
+		if m.arbFailed > 0 {
+			info += fmt.Sprintf(", %d books failed to load", m.arbFailed)
+		}
+		lines = append(lines, "", dimStyle.Render(info))
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		renderVersionInfo(),
+		"",
+		renderHeader(),
+		renderSubtitle("Triangular Arbitrage - cycles across the configured pairs"),
+		panelStyle.Render(strings.Join(lines, "\n")),
+	)
+	targetHeight := 60
+	if m.height > 0 {
+		targetHeight = m.height
+	}
+	padding := strings.Repeat("\n", max(0, targetHeight-lipgloss.Height(content)-2))
+	return lipgloss.JoinVertical(lipgloss.Left, content, padding, m.bottomLine())
+}
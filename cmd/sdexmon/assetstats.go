@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/sdexmon/sdexmon/internal/assetstats"
+	"github.com/sdexmon/sdexmon/internal/config"
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+)
+
+const (
+	statsInterval = time.Minute
+	// statsRecordEvery spaces the snapshots kept in the history
+	statsRecordEvery = 10 * time.Minute
+	statsHistory     = 30 * 24 * time.Hour
+)
+
+// statsWindows are the changes the panel shows
+var statsWindows = []struct {
+	label string
+	d     time.Duration
+}{{"1h", time.Hour}, {"24h", 24 * time.Hour}, {"7d", 7 * 24 * time.Hour}, {"30d", 30 * 24 * time.Hour}}
+
+// Asset the stats panel shows
+const (
+	statsOff = iota
+	statsBase
+	statsQuote
+)
+
+// statsStore keeps snapshots next to the config; nil when it cannot be
+// opened, in which case only the latest figures are shown
+var statsStore *assetstats.Store
+
+func openStatsStore() {
+	dir := filepath.Join(filepath.Dir(config.GetConfigPath()), "assetstats")
+	s, err := assetstats.Open(dir)
+	if err != nil {
+		log.Printf("Asset stats history disabled: %v", err)
+		return
+	}
+	statsStore = s
+}
+
+type (
+	statsTickMsg struct{ gen uint64 }
+	statsDataMsg struct {
+		gen     uint64
+		snap    assetstats.Snapshot
+		history []assetstats.Snapshot
+		err     error
+	}
+)
+
+func statsTick(gen uint64) tea.Cmd {
+	return tea.Tick(statsInterval, func(time.Time) tea.Msg { return statsTickMsg{gen: gen} })
+}
+
+// statsAsset is the asset the panel is showing, if any
+func (m model) statsAsset() txnbuild.Asset {
+	switch m.statsSide {
+	case statsBase:
+		return m.base
+	case statsQuote:
+		return m.quote
+	}
+	return nil
+}
+
+// toggleStats cycles the panel through the base asset, the quote asset and
+// off
+func (m model) toggleStats() (tea.Model, tea.Cmd) {
+	m.statsSide = (m.statsSide + 1) % 3
+	m.statsGen++
+	m.statsSnap, m.statsHist, m.statsNote = nil, nil, ""
+	a := m.statsAsset()
+	if a == nil {
+		return m, nil
+	}
+	if a.IsNative() {
+		m.statsNote = "XLM is native; Horizon keeps no asset stats for it"
+		return m, nil
+	}
+	m.statsNote = "loading..."
+	return m, tea.Batch(fetchStatsCmd(m.client, m.statsGen, a), statsTick(m.statsGen))
+}
+
+// fetchStatsCmd reads the asset's /assets record, adds it to the history
+// when the last snapshot kept is old enough, and loads the history
+func fetchStatsCmd(client *horizonclient.Client, gen uint64, a txnbuild.Asset) tea.Cmd {
+	code, issuer := a.GetCode(), a.GetIssuer()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), overviewTimeout)
+		defer cancel()
+		page, err := scopedClient(client, ctx).Assets(horizonclient.AssetRequest{ForAssetCode: code, ForAssetIssuer: issuer, Limit: 1})
+		if err != nil {
+			return statsDataMsg{gen: gen, err: err}
+		}
+		if len(page.Embedded.Records) == 0 {
+			return statsDataMsg{gen: gen, err: fmt.Errorf("Horizon has no stats for %s", code)}
+		}
+		now := time.Now()
+		snap := assetstats.FromHorizon(page.Embedded.Records[0], now)
+		if statsStore == nil {
+			return statsDataMsg{gen: gen, snap: snap}
+		}
+		history, err := statsStore.History(snap.Asset, now.Add(-statsHistory))
+		if err != nil {
+			return statsDataMsg{gen: gen, snap: snap, err: err}
+		}
+		if len(history) == 0 || now.Sub(history[len(history)-1].At) >= statsRecordEvery {
+			if err := statsStore.Append(snap); err != nil {
+				log.Printf("Asset stats %s: %v", code, err)
+			}
+			history = append(history, snap)
+		}
+		return statsDataMsg{gen: gen, snap: snap, history: history}
+	}
+}
+
+// renderStats shows the asset's holders and where its supply sits, with
+// the change over each window of the history
+func (m model) renderStats() string {
+	a := m.statsAsset()
+	title := "ASSET STATS"
+	if a != nil {
+		title += " " + assetShort(a)
+	}
+	rows := []string{boldStyle.Render(title)}
+	if m.statsNote != "" {
+		rows = append(rows, dimStyle.Render(m.statsNote))
+	}
+	if m.statsSnap == nil {
+		return strings.Join(rows, "\n")
+	}
+	s := *m.statsSnap
+	flags := strings.Join(s.Flags(), ", ")
+	if flags == "" {
+		flags = "none"
+	}
+	rows = append(rows, dimStyle.Render(fmt.Sprintf("issuer %s  flags: %s  as of %s", truncateMiddle(a.GetIssuer(), 16),
+		flags, s.At.Local().Format("15:04:05"))))
+
+	header := fmt.Sprintf("%-24s %16s", "", "NOW")
+	for _, w := range statsWindows {
+		header += fmt.Sprintf(" %14s", "Δ"+w.label)
+	}
+	rows = append(rows, dimStyle.Render(header))
+	var past []*assetstats.Snapshot
+	for _, w := range statsWindows {
+		if p, ok := assetstats.Before(m.statsHist, s.At.Add(-w.d)); ok {
+			past = append(past, &p)
+		} else {
+			past = append(past, nil)
+		}
+	}
+	line := func(label string, get func(assetstats.Snapshot) *big.Rat, count bool) string {
+		format := func(v *big.Rat) string {
+			if count {
+				return v.FloatString(0)
+			}
+			return formatPrice(orderbook.Float(v))
+		}
+		row := fmt.Sprintf("%-24s %16s", label, format(get(s)))
+		for _, p := range past {
+			if p == nil {
+				row += fmt.Sprintf(" %14s", "-")
+				continue
+			}
+			d := new(big.Rat).Sub(get(s), get(*p))
+			text := fmt.Sprintf("%14s", format(d))
+			switch d.Sign() {
+			case 1:
+				text = greenStyle.Render(fmt.Sprintf("%14s", "+"+format(d)))
+			case -1:
+				text = redStyle.Render(text)
+			}
+			row += " " + text
+		}
+		return row
+	}
+	count := func(get func(assetstats.Snapshot) int) func(assetstats.Snapshot) *big.Rat {
+		return func(s assetstats.Snapshot) *big.Rat { return big.NewRat(int64(get(s)), 1) }
+	}
+	amount := func(get func(assetstats.Snapshot) assetstats.Amount) func(assetstats.Snapshot) *big.Rat {
+		return func(s assetstats.Snapshot) *big.Rat { return get(s).Rat() }
+	}
+	rows = append(rows,
+		line("holders", count(assetstats.Snapshot.Holders), true),
+		line("  authorized", count(func(s assetstats.Snapshot) int { return s.Accounts }), true),
+		line("  maintain liabilities", count(func(s assetstats.Snapshot) int { return s.AccountsMaintain }), true),
+		line("  unauthorized", count(func(s assetstats.Snapshot) int { return s.AccountsUnauthorized }), true),
+		line("supply", assetstats.Snapshot.Supply, false),
+		line("  authorized balances", amount(func(s assetstats.Snapshot) assetstats.Amount { return s.Authorized }), false),
+		line("  maintain liabilities", amount(func(s assetstats.Snapshot) assetstats.Amount { return s.Maintain }), false),
+		line("  unauthorized balances", amount(func(s assetstats.Snapshot) assetstats.Amount { return s.Unauthorized }), false),
+		line(fmt.Sprintf("  claimable (%d)", s.ClaimableBalances), amount(func(s assetstats.Snapshot) assetstats.Amount { return s.Claimable }), false),
+		line(fmt.Sprintf("  in pools (%d)", s.Pools), amount(func(s assetstats.Snapshot) assetstats.Amount { return s.InPools }), false),
+	)
+	if s.Contracts > 0 || s.InContracts.Rat().Sign() > 0 {
+		rows = append(rows, line(fmt.Sprintf("  in contracts (%d)", s.Contracts),
+			amount(func(s assetstats.Snapshot) assetstats.Amount { return s.InContracts }), false))
+	}
+	if statsStore == nil {
+		rows = append(rows, dimStyle.Render("history unavailable; changes need stored snapshots"))
+	} else {
+		rows = append(rows, dimStyle.Render(fmt.Sprintf("refreshed every %s while shown; a snapshot is kept every %s",
+			statsInterval, statsRecordEvery)))
+	}
+	return strings.Join(rows, "\n")
+}
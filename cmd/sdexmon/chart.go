@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/sdexmon/sdexmon/internal/candles"
+)
+
+const (
+	chartPriceRows  = 10
+	chartVolumeRows = 3
+	chartLabelW     = 12
+)
+
+// candlesDataMsg delivers trade aggregations for one resolution
+type candlesDataMsg struct {
+	gen    uint64
+	res    time.Duration
+	series candles.Series
+	err    error
+}
+
+func (m model) chartResolution() time.Duration {
+	return candles.Resolutions[m.chartRes]
+}
+
+// fetchCandlesCmd loads the latest trade aggregations for the pair
+func fetchCandlesCmd(sc fetchScope, base, quote txnbuild.Asset, res time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		series, err := fetchCandles(sc.client, base, quote, res)
+		if sc.stale() {
+			return nil
+		}
+		return candlesDataMsg{gen: sc.gen, res: res, series: series, err: err}
+	}
+}
+
+func fetchCandles(client *horizonclient.Client, base, quote txnbuild.Asset, res time.Duration) (candles.Series, error) {
+	if client == nil || base == nil || quote == nil {
+		return candles.Series{}, fmt.Errorf("not configured")
+	}
+	req := horizonclient.TradeAggregationRequest{
+		Resolution: res,
+		Order:      horizonclient.OrderDesc,
+		Limit:      candles.MaxCandles,
+	}
+	applyAggregationAssets(&req, base, quote)
+	page, err := client.TradeAggregations(req)
+	if err != nil {
+		return candles.Series{}, err
+	}
+	list := make([]candles.Candle, 0, len(page.Embedded.Records))
+	for _, r := range page.Embedded.Records {
+		list = append(list, candleOf(r))
+	}
+	return candles.NewSeries(res, time.Now(), list), nil
+}
+
+func candleOf(r hProtocol.TradeAggregation) candles.Candle {
+	f := func(s string) float64 {
+		v, _ := strconv.ParseFloat(s, 64)
+		return v
+	}
+	return candles.Candle{
+		Start:  time.UnixMilli(r.Timestamp).UTC(),
+		Open:   f(r.Open),
+		High:   f(r.High),
+		Low:    f(r.Low),
+		Close:  f(r.Close),
+		Volume: f(r.BaseVolume),
+		Trades: r.TradeCount,
+	}
+}
+
+func applyAggregationAssets(req *horizonclient.TradeAggregationRequest, base, quote txnbuild.Asset) {
+	switch v := base.(type) {
+	case txnbuild.NativeAsset:
+		req.BaseAssetType = "native"
+	case txnbuild.CreditAsset:
+		req.BaseAssetType = assetTypeEnum(v)
+		req.BaseAssetCode = v.Code
+		req.BaseAssetIssuer = v.Issuer
+	}
+	switch v := quote.(type) {
+	case txnbuild.NativeAsset:
+		req.CounterAssetType = "native"
+	case txnbuild.CreditAsset:
+		req.CounterAssetType = assetTypeEnum(v)
+		req.CounterAssetCode = v.Code
+		req.CounterAssetIssuer = v.Issuer
+	}
+}
+
+// applyTradesToCandles updates the current candle live from incoming trades
+func (m *model) applyTradesToCandles(list []hProtocol.Trade) {
+	if m.candles.Resolution == 0 {
+		return
+	}
+	for _, t := range list {
+		if t.Price.D == 0 {
+			continue
+		}
+		volume, _ := strconv.ParseFloat(t.BaseAmount, 64)
+		m.candles.Add(time.Time(t.LedgerCloseTime), float64(t.Price.N)/float64(t.Price.D), volume)
+	}
+}
+
+// reloadChartCmd clears the chart and fetches the selected resolution
+func (m *model) reloadChartCmd() tea.Cmd {
+	m.candles = candles.Series{}
+	m.chartNote = "loading..."
+	if m.base == nil || m.quote == nil {
+		return nil
+	}
+	return fetchCandlesCmd(m.scope(), m.base, m.quote, m.chartResolution())
+}
+
+// renderChart draws candlesticks with volume bars below them
+func (m model) renderChart(width int) string {
+	var title strings.Builder
+	title.WriteString(boldStyle.Render("CHART"))
+	title.WriteString("  ")
+	for i, res := range candles.Resolutions {
+		label := resolutionLabel(res)
+		if i == m.chartRes {
+			title.WriteString(selectedStyle.Render("[" + label + "]"))
+		} else {
+			title.WriteString(dimStyle.Render(" " + label + " "))
+		}
+		title.WriteString(" ")
+	}
+	rows := []string{title.String()}
+
+	if len(m.candles.Candles) == 0 {
+		note := firstNonEmpty(m.chartNote, "no trades")
+		rows = append(rows, dimStyle.Render(note))
+		for i := 0; i < chartPriceRows+chartVolumeRows; i++ {
+			rows = append(rows, "")
+		}
+		return strings.Join(rows, "\n")
+	}
+
+	// one column per candle plus a gap
+	slots := (width - chartLabelW - 1) / 2
+	if slots < 1 {
+		slots = 1
+	}
+	window := m.candles.Window(time.Now(), slots)
+
+	lo, hi, maxVol := math.Inf(1), math.Inf(-1), 0.0
+	for _, c := range window {
+		if c.Close == 0 {
+			continue
+		}
+		lo = math.Min(lo, c.Low)
+		hi = math.Max(hi, c.High)
+		maxVol = math.Max(maxVol, c.Volume)
+	}
+	if math.IsInf(lo, 1) {
+		lo, hi = 0, 0
+	}
+	if hi == lo {
+		hi, lo = hi*1.001+1e-7, lo*0.999
+	}
+	rowOf := func(p float64) int {
+		r := int(math.Round((hi - p) / (hi - lo) * float64(chartPriceRows-1)))
+		return max(0, min(chartPriceRows-1, r))
+	}
+
+	for r := 0; r < chartPriceRows; r++ {
+		label := ""
+		switch r {
+		case 0:
+			label = formatPrice(hi)
+		case chartPriceRows / 2:
+			label = formatPrice(hi - (hi-lo)*float64(r)/float64(chartPriceRows-1))
+		case chartPriceRows - 1:
+			label = formatPrice(lo)
+		}
+		var line strings.Builder
+		line.WriteString(dimStyle.Render(padLeftVis(label, chartLabelW-1)) + " ")
+		for _, c := range window {
+			line.WriteString(candleCell(c, r, rowOf))
+			line.WriteString(" ")
+		}
+		rows = append(rows, line.String())
+	}
+
+	const blocks = " ▁▂▃▄▅▆▇█"
+	for r := 0; r < chartVolumeRows; r++ {
+		label := ""
+		if r == 0 {
+			label = "vol " + formatCompact(maxVol)
+		}
+		var line strings.Builder
+		line.WriteString(dimStyle.Render(padLeftVis(label, chartLabelW-1)) + " ")
+		for _, c := range window {
+			eighths := 0
+			if maxVol > 0 {
+				eighths = int(math.Round(c.Volume / maxVol * float64(chartVolumeRows*8)))
+			}
+			// fill from the bottom row up
+			level := eighths - (chartVolumeRows-1-r)*8
+			level = max(0, min(8, level))
+			cell := string([]rune(blocks)[level])
+			if c.Up() {
+				line.WriteString(greenStyle.Faint(true).Render(cell))
+			} else {
+				line.WriteString(redStyle.Faint(true).Render(cell))
+			}
+			line.WriteString(" ")
+		}
+		rows = append(rows, line.String())
+	}
+
+	last := m.candles.Candles[len(m.candles.Candles)-1]
+	rows = append(rows, dimStyle.Render(fmt.Sprintf("%s  O %s  H %s  L %s  C %s  V %s",
+		last.Start.Local().Format("Jan 02 15:04"), formatPrice(last.Open), formatPrice(last.High),
+		formatPrice(last.Low), formatPrice(last.Close), formatCompact(last.Volume))))
+	return lipgloss.NewStyle().Render(strings.Join(rows, "\n"))
+}
+
+// candleCell renders the part of candle c that falls on price row r
+func candleCell(c candles.Candle, r int, rowOf func(float64) int) string {
+	if c.Close == 0 {
+		return " "
+	}
+	if c.Trades == 0 {
+		if r == rowOf(c.Close) {
+			return dimStyle.Render("·")
+		}
+		return " "
+	}
+	style := greenStyle
+	if !c.Up() {
+		style = redStyle
+	}
+	bodyTop, bodyBot := rowOf(math.Max(c.Open, c.Close)), rowOf(math.Min(c.Open, c.Close))
+	switch {
+	case r >= bodyTop && r <= bodyBot:
+		return style.Render("█")
+	case r >= rowOf(c.High) && r <= rowOf(c.Low):
+		return style.Render("│")
+	}
+	return " "
+}
+
+func resolutionLabel(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+// formatCompact abbreviates large volumes (12.3k, 4.5M)
+func formatCompact(v float64) string {
+	switch {
+	case v >= 1e9:
+		return strconv.FormatFloat(v/1e9, 'f', 1, 64) + "B"
+	case v >= 1e6:
+		return strconv.FormatFloat(v/1e6, 'f', 1, 64) + "M"
+	case v >= 1e3:
+		return strconv.FormatFloat(v/1e3, 'f', 1, 64) + "k"
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+
+	"github.com/sdexmon/sdexmon/internal/config"
+	"github.com/sdexmon/sdexmon/internal/failover"
+)
+
+// horizonProbeInterval is how often the endpoints' root documents are
+// checked for latency and ingestion lag
+const horizonProbeInterval = 30 * time.Second
+
+// horizonPool fails requests over between the network's Horizon endpoints.
+// It is built with the first client, after the network has been selected.
+var (
+	horizonPool     *failover.Pool
+	horizonPoolOnce sync.Once
+)
+
+// newClient returns a Horizon client whose requests go through the endpoint
+// pool. Probing only runs when there is more than one endpoint to pick from.
+func newClient() *horizonclient.Client {
+	horizonPoolOnce.Do(func() {
+		p, err := failover.New(config.HorizonURLs(), nil)
+		if err != nil {
+			log.Printf("Horizon endpoints: %v", err)
+			return
+		}
+		horizonPool = p
+		if p.Len() > 1 {
+			go p.Run(context.Background(), horizonProbeInterval)
+		}
+	})
+	if horizonPool == nil {
+		return config.NewClient()
+	}
+	return &horizonclient.Client{HorizonURL: horizonPool.Primary(), HTTP: horizonPool}
+}
+
+// activeHorizonLabel names the endpoint requests currently go to, marked
+// when it is not the primary
+func activeHorizonLabel() string {
+	if horizonPool == nil {
+		return hostOf(config.HorizonURL())
+	}
+	active := horizonPool.Active()
+	if active != horizonPool.Primary() {
+		return "failover: " + hostOf(active)
+	}
+	return hostOf(active)
+}
+
+func hostOf(u string) string {
+	if parsed, err := url.Parse(u); err == nil && parsed.Host != "" {
+		return parsed.Host
+	}
+	return u
+}
+
+// horizonHealthMarkdown renders the endpoint table of the debug view
+func horizonHealthMarkdown() string {
+	if horizonPool == nil {
+		return ""
+	}
+	active := horizonPool.Active()
+	var b strings.Builder
+	b.WriteString("\n## Horizon Endpoints\n\n| Endpoint | Latency | Errors | Lag | State |\n|----------|---------|--------|-----|-------|\n")
+	for _, h := range horizonPool.Health() {
+		lag := "-"
+		if h.Lag >= 0 {
+			lag = fmt.Sprintf("%d", h.Lag)
+		}
+		state := "ok"
+		switch {
+		case h.Down:
+			state = "down: " + h.LastError
+		case h.URL == active:
+			state = "active"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %.0f%% | %s | %s |\n",
+			hostOf(h.URL), h.Latency.Round(time.Millisecond), h.ErrorRate*100, lag, state)
+	}
+	return b.String()
+}
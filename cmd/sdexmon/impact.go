@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+	"github.com/sdexmon/sdexmon/internal/slippage"
+)
+
+const impactColW = 20
+
+func newImpactInput() textinput.Model {
+	in := textinput.New()
+	in.Placeholder = "e.g. 10k or 0.5"
+	in.Prompt = "SIZE > "
+	in.CharLimit = 24
+	return in
+}
+
+// handleImpactKeys drives the price-impact panel while its size input has
+// focus. Everything except esc and tab is typed into the input.
+func (m model) handleImpactKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.showImpact = false
+		m.impactInput.Blur()
+		return m, nil
+	case "tab":
+		if m.impactSide == slippage.Buy {
+			m.impactSide = slippage.Sell
+		} else {
+			m.impactSide = slippage.Buy
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.impactInput, cmd = m.impactInput.Update(msg)
+	return m, cmd
+}
+
+// impactPool returns the current pair's pool reserves oriented to base/quote,
+// with the pool's own fee
+func (m model) impactPool() (slippage.Pool, bool) {
+	bc, qc := assetShort(m.base), assetShort(m.quote)
+	var base, quote *big.Rat
+	for i, code := range m.lp.Codes {
+		r := m.lp.LockedR[i]
+		if r == nil {
+			continue
+		}
+		switch code {
+		case bc:
+			base = r
+		case qc:
+			quote = r
+		}
+	}
+	if base == nil || quote == nil {
+		return slippage.Pool{}, false
+	}
+	fee := int64(m.lp.FeeBP)
+	if fee <= 0 {
+		fee = slippage.PoolFeeBps // provider did not report the pool's fee
+	}
+	return slippage.Pool{Base: base, Quote: quote, FeeBps: fee}, true
+}
+
+// renderImpact estimates the order in the size input against the merged book
+// and the pair's liquidity pool
+func (m model) renderImpact() string {
+	bc, qc := assetShort(m.base), assetShort(m.quote)
+
+	var title strings.Builder
+	title.WriteString(boldStyle.Render("PRICE IMPACT"))
+	title.WriteString("  ")
+	for _, side := range []slippage.Side{slippage.Buy, slippage.Sell} {
+		label := fmt.Sprintf("%s %s", side, bc)
+		if side == m.impactSide {
+			title.WriteString(selectedStyle.Render("[" + label + "]"))
+		} else {
+			title.WriteString(dimStyle.Render(" " + label + " "))
+		}
+		title.WriteString(" ")
+	}
+	lines := []string{title.String(), m.impactInput.View() + dimStyle.Render("  "+bc)}
+
+	if strings.TrimSpace(m.impactInput.Value()) == "" {
+		return strings.Join(append(lines, dimStyle.Render("Enter an order size in "+bc)), "\n")
+	}
+	size, err := slippage.ParseSize(m.impactInput.Value())
+	if err != nil {
+		return strings.Join(append(lines, errorStyle.Render(err.Error())), "\n")
+	}
+
+	side := m.impactSide
+	book := slippage.WalkBook(m.orderbook, side, size)
+	pool, hasPool := m.impactPool()
+	var amm slippage.Fill
+	if hasPool {
+		amm = pool.Swap(side, size)
+	}
+	mid := m.orderbook.Mid()
+	if mid == nil && hasPool {
+		mid = pool.Price()
+	}
+
+	totalLabel := "Cost (" + qc + ")"
+	if side == slippage.Sell {
+		totalLabel = "Proceeds (" + qc + ")"
+	}
+	row := func(label string, f func(slippage.Fill) string) string {
+		poolCell := "-"
+		if hasPool {
+			poolCell = f(amm)
+		}
+		return padRightVis(dimStyle.Render(label), 18) +
+			padLeftVis(f(book), impactColW) + padLeftVis(poolCell, impactColW)
+	}
+	price := func(r *big.Rat) string { return firstNonEmpty(orderbook.Format(r, 7), "-") }
+
+	lines = append(lines,
+		padRightVis("", 18)+padLeftVis(dimStyle.Render("ORDER BOOK"), impactColW)+padLeftVis(dimStyle.Render("POOL"), impactColW),
+		row("Filled ("+bc+")", func(f slippage.Fill) string {
+			s := formatRatWithDecimals(f.Filled, 2, 0)
+			if !f.Complete() {
+				s += "*"
+			}
+			return s
+		}),
+		row(totalLabel, func(f slippage.Fill) string { return formatRatWithDecimals(f.Total, 2, 0) }),
+		row("Avg price", func(f slippage.Fill) string { return price(f.Avg) }),
+		row("Worst price", func(f slippage.Fill) string { return price(f.Worst) }),
+		row("Slippage vs mid", func(f slippage.Fill) string {
+			if s := f.SlippagePercent(side, mid); s != nil {
+				return orderbook.Format(s, 3) + "%"
+			}
+			return "-"
+		}),
+	)
+
+	var verdict string
+	switch {
+	case !hasPool:
+		verdict = dimStyle.Render("No pool reserves for this pair")
+	case slippage.Better(side, book, amm):
+		verdict = greenStyle.Render("Order book gives the better execution")
+	case slippage.Better(side, amm, book):
+		verdict = greenStyle.Render("Pool gives the better execution")
+	default:
+		verdict = dimStyle.Render("Neither venue can fill this size")
+		if book.Avg != nil {
+			verdict = dimStyle.Render("Book and pool execute the same")
+		}
+	}
+	lines = append(lines, verdict)
+	if !book.Complete() || (hasPool && !amm.Complete()) {
+		lines = append(lines, dimStyle.Render("* partial fill: not enough liquidity for the full size"))
+	}
+	return strings.Join(lines, "\n")
+}
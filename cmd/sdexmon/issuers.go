@@ -0,0 +1,420 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/sdexmon/sdexmon/internal/assetstats"
+	"github.com/sdexmon/sdexmon/internal/issuer"
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+)
+
+const (
+	issuerBackfill = 200              // latest operations read per issuer on start
+	issuerInterval = 15 * time.Second // polling when streaming is off
+	issuerKeep     = 500              // events remembered
+	issuerRows     = 25
+)
+
+type (
+	// issuerOpsMsg carries a page of an issuer's operations; backfill pages
+	// are newest first, polled ones oldest first
+	issuerOpsMsg struct {
+		gen      uint64
+		account  string
+		ops      []operations.Operation
+		backfill bool
+		err      error
+	}
+	issuerOpMsg struct {
+		gen     uint64
+		account string
+		op      operations.Operation
+	}
+	issuerTickMsg struct {
+		gen      uint64
+		account  string
+		backfill bool // retrying a failed backfill
+	}
+	// issuerSeedMsg carries the outstanding supply of the tracked assets
+	issuerSeedMsg struct {
+		gen      uint64
+		supplies []issuerSupply
+		err      error // the first asset that could not be read
+	}
+)
+
+type issuerSupply struct {
+	code, issuer string
+	amount       *big.Rat
+	at           time.Time
+}
+
+// issuerAccounts maps each curated issuer to the codes it issues
+func issuerAccounts() map[string][]string {
+	out := map[string][]string{}
+	for code, a := range curatedAssets {
+		if ca, ok := a.(txnbuild.CreditAsset); ok {
+			out[ca.Issuer] = append(out[ca.Issuer], code)
+		}
+	}
+	for _, codes := range out {
+		sort.Strings(codes)
+	}
+	return out
+}
+
+// openIssuers shows the issuer screen and follows the issuers while it is
+// shown. The first visit seeds each asset's supply and backfills the latest
+// operations; later visits resume after the last operation seen.
+func (m model) openIssuers() (tea.Model, tea.Cmd) {
+	m.currentScreen = screenIssuers
+	m.showPairPopup = false
+	if !onPubnet() {
+		return m, nil
+	}
+	m.startIssuers()
+	if m.issuerLedger == nil {
+		m.issuerLedger = issuer.NewLedger(issuerKeep)
+		m.issuerCursors = map[string]string{}
+		for account, codes := range issuerAccounts() {
+			for _, code := range codes {
+				m.issuerLedger.Track(code, account)
+			}
+		}
+		return m, seedIssuersCmd(m.issuerScope, m.issuerLedger.Supplies())
+	}
+	var cmds []tea.Cmd
+	for account := range issuerAccounts() {
+		cmds = append(cmds, m.followIssuer(account))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// startIssuers begins a new issuer session, cancelling the previous one
+func (m *model) startIssuers() {
+	m.stopIssuers()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.issuerScope = fetchScope{gen: m.issuerGen, ctx: ctx, client: m.client}
+	m.issuerCancel = cancel
+}
+
+// stopIssuers cancels the issuer session's requests and streams; its late
+// messages are dropped
+func (m *model) stopIssuers() {
+	if m.issuerCancel != nil {
+		m.issuerCancel()
+		m.issuerCancel = nil
+	}
+	m.issuerGen++
+}
+
+// followIssuer backfills an account without a cursor, or resumes after the
+// cursor by stream or poll
+func (m model) followIssuer(account string) tea.Cmd {
+	cursor := m.issuerCursors[account]
+	switch {
+	case cursor == "":
+		return fetchIssuerOpsCmd(m.issuerScope, account, "", true)
+	case m.stream != nil && streamingEnabled():
+		return streamIssuerOpsCmd(m.stream, m.issuerScope, account, cursor)
+	}
+	return fetchIssuerOpsCmd(m.issuerScope, account, cursor, false)
+}
+
+// seedIssuersCmd reads the outstanding supply of each asset from /assets
+func seedIssuersCmd(sc fetchScope, supplies []issuer.Supply) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(sc.ctx, overviewTimeout)
+		defer cancel()
+		c := scopedClient(sc.client, ctx)
+		type seed struct {
+			supply issuerSupply
+			err    error
+		}
+		msg := issuerSeedMsg{gen: sc.gen}
+		for _, got := range fanOut(ctx, len(supplies), func(i int) seed {
+			code, iss := supplies[i].Code, supplies[i].Issuer
+			page, err := c.Assets(horizonclient.AssetRequest{ForAssetCode: code, ForAssetIssuer: iss, Limit: 1})
+			if err == nil && len(page.Embedded.Records) == 0 {
+				err = fmt.Errorf("Horizon has no stats for %s", code)
+			}
+			if err != nil {
+				return seed{err: err}
+			}
+			snap := assetstats.FromHorizon(page.Embedded.Records[0], time.Now())
+			return seed{supply: issuerSupply{code: code, issuer: iss, amount: snap.Supply(), at: snap.At}}
+		}) {
+			switch {
+			case got.err != nil:
+				if msg.err == nil {
+					msg.err = got.err
+				}
+			case got.supply.amount != nil:
+				msg.supplies = append(msg.supplies, got.supply)
+			}
+		}
+		return msg
+	}
+}
+
+// fetchIssuerOpsCmd reads the latest operations of an account when
+// backfilling, or those after cursor when polling
+func fetchIssuerOpsCmd(sc fetchScope, account, cursor string, backfill bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(sc.ctx, overviewTimeout)
+		defer cancel()
+		req := horizonclient.OperationRequest{ForAccount: account, Cursor: cursor, Limit: issuerBackfill,
+			Order: horizonclient.OrderAsc}
+		if backfill {
+			req.Order = horizonclient.OrderDesc
+		}
+		release, ok := takeFetchSlot(ctx)
+		if !ok {
+			return issuerOpsMsg{gen: sc.gen, account: account, backfill: backfill, err: ctx.Err()}
+		}
+		defer release()
+		page, err := scopedClient(sc.client, ctx).Operations(req)
+		if err != nil {
+			return issuerOpsMsg{gen: sc.gen, account: account, backfill: backfill, err: err}
+		}
+		return issuerOpsMsg{gen: sc.gen, account: account, ops: page.Embedded.Records, backfill: backfill}
+	}
+}
+
+func issuerTick(gen uint64, account string, backfill bool) tea.Cmd {
+	return tea.Tick(issuerInterval, func(time.Time) tea.Msg {
+		return issuerTickMsg{gen: gen, account: account, backfill: backfill}
+	})
+}
+
+// streamIssuerOpsCmd streams an account's operations from cursor until the
+// issuer session ends, resuming after the last one seen when it reconnects
+func streamIssuerOpsCmd(s *marketStreamer, sc fetchScope, account, cursor string) tea.Cmd {
+	return func() tea.Msg {
+		go func() {
+			backoff := streamRetryMin
+			for {
+				err := s.client.StreamOperations(sc.ctx,
+					horizonclient.OperationRequest{ForAccount: account, Cursor: cursor},
+					func(op operations.Operation) {
+						backoff = streamRetryMin
+						cursor = op.PagingToken()
+						if sc.ctx.Err() == nil && s.send != nil {
+							s.send(issuerOpMsg{gen: sc.gen, account: account, op: op})
+						}
+					})
+				if sc.ctx.Err() != nil {
+					return
+				}
+				log.Printf("Stream operations %s interrupted: %v", truncateMiddle(account, 12), err)
+				select {
+				case <-sc.ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+				backoff *= 2
+				if backoff > streamRetryMax {
+					backoff = streamRetryMax
+				}
+			}
+		}()
+		return nil
+	}
+}
+
+// handleIssuerSeed seeds the ledger and starts the backfills; an asset that
+// could not be read shows activity only
+func (m model) handleIssuerSeed(msg issuerSeedMsg) (tea.Model, tea.Cmd) {
+	if msg.gen != m.issuerGen || m.issuerLedger == nil {
+		return m, nil
+	}
+	for _, s := range msg.supplies {
+		m.issuerLedger.Seed(s.code, s.issuer, s.amount, s.at)
+	}
+	if msg.err != nil {
+		m.issuerNote = "supply: " + msg.err.Error()
+	}
+	var cmds []tea.Cmd
+	for account := range issuerAccounts() {
+		cmds = append(cmds, m.followIssuer(account))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// handleIssuerOps records a page and keeps following the account
+func (m model) handleIssuerOps(msg issuerOpsMsg) (tea.Model, tea.Cmd) {
+	if msg.gen != m.issuerGen || m.issuerLedger == nil {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.issuerNote = fmt.Sprintf("operations of %s: %v", truncateMiddle(msg.account, 12), msg.err)
+		// a failed backfill is retried rather than followed from the wrong place
+		return m, issuerTick(msg.gen, msg.account, msg.backfill)
+	}
+	m.issuerNote = ""
+	for _, op := range msg.ops {
+		m.issuerLedger.Add(issuer.Classify(op, msg.account)...)
+	}
+	if len(msg.ops) > 0 {
+		newest := msg.ops[len(msg.ops)-1]
+		if msg.backfill {
+			newest = msg.ops[0]
+		}
+		m.issuerCursors[msg.account] = newest.PagingToken()
+	}
+	if msg.backfill {
+		if m.stream != nil && streamingEnabled() {
+			cursor := m.issuerCursors[msg.account]
+			if cursor == "" {
+				cursor = "now"
+			}
+			return m, streamIssuerOpsCmd(m.stream, m.issuerScope, msg.account, cursor)
+		}
+	}
+	return m, issuerTick(msg.gen, msg.account, false)
+}
+
+// handleIssuerOp records a streamed operation
+func (m model) handleIssuerOp(msg issuerOpMsg) (tea.Model, tea.Cmd) {
+	if msg.gen != m.issuerGen || m.issuerLedger == nil {
+		return m, nil
+	}
+	m.issuerLedger.Add(issuer.Classify(msg.op, msg.account)...)
+	m.issuerCursors[msg.account] = msg.op.PagingToken()
+	return m, nil
+}
+
+// handleIssuerTick polls an account, or retries its backfill
+func (m model) handleIssuerTick(msg issuerTickMsg) (tea.Model, tea.Cmd) {
+	if msg.gen != m.issuerGen {
+		return m, nil
+	}
+	if msg.backfill {
+		return m, fetchIssuerOpsCmd(m.issuerScope, msg.account, "", true)
+	}
+	return m, fetchIssuerOpsCmd(m.issuerScope, msg.account, m.issuerCursors[msg.account], false)
+}
+
+func (m model) handleIssuerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "b", "s":
+		m.stopIssuers()
+		m.backToPair()
+	}
+	return m, nil
+}
+
+func issuerKindText(k issuer.Kind) string {
+	switch k {
+	case issuer.Mint:
+		return greenStyle.Render(fmt.Sprintf("%-15s", k))
+	case issuer.Burn:
+		return redStyle.Render(fmt.Sprintf("%-15s", k))
+	case issuer.Clawback:
+		return errorStyle.Render(fmt.Sprintf("%-15s", k))
+	}
+	return warnStyle.Render(fmt.Sprintf("%-15s", k))
+}
+
+func issuersView(m model) string {
+	var lines []string
+	switch {
+	case !onPubnet():
+		lines = append(lines, dimStyle.Render("the curated issuers are pubnet accounts"))
+	case m.issuerLedger == nil:
+		lines = append(lines, dimStyle.Render("loading..."))
+	default:
+		lines = append(lines, boldStyle.Render("SUPPLY"), dimStyle.Render(fmt.Sprintf("%-6s %-14s %16s %16s %16s %16s %17s %14s %6s  %s",
+			"ASSET", "ISSUER", "OUTSTANDING", "MINTED", "BURNED", "CLAWED BACK", "NET", "MINT/BURN/CLAW", "AUTH", "SINCE")))
+		for _, s := range m.issuerLedger.Supplies() {
+			net := s.Net()
+			netText := formatPrice(orderbook.Float(net))
+			if net.Sign() > 0 {
+				netText = "+" + netText
+			}
+			netText = fmt.Sprintf("%17s", netText)
+			switch net.Sign() {
+			case 1:
+				netText = greenStyle.Render(netText)
+			case -1:
+				netText = redStyle.Render(netText)
+			}
+			since := "-"
+			if !s.First.IsZero() {
+				since = s.First.Local().Format("2006-01-02 15:04")
+			}
+			outstanding := "-"
+			if o := s.Outstanding(); o != nil {
+				outstanding = formatPrice(orderbook.Float(o))
+			}
+			lines = append(lines, fmt.Sprintf("%-6s %-14s %16s %16s %16s %16s %s %14s %6d  %s", s.Code, truncateMiddle(s.Issuer, 14),
+				outstanding, formatPrice(orderbook.Float(s.Minted)), formatPrice(orderbook.Float(s.Burned)),
+				formatPrice(orderbook.Float(s.ClawedBack)), netText,
+				fmt.Sprintf("%d/%d/%d", s.Mints, s.Burns, s.Clawbacks), s.AuthChanges, since))
+		}
+
+		lines = append(lines, "", boldStyle.Render("ACTIVITY"), dimStyle.Render(fmt.Sprintf("%-19s %-15s %-6s %16s  %-14s  %s",
+			"TIME", "KIND", "ASSET", "AMOUNT", "ACCOUNT", "DETAIL")))
+		recent := m.issuerLedger.Recent()
+		if len(recent) == 0 {
+			lines = append(lines, dimStyle.Render("no mints, burns, clawbacks or flag changes yet"))
+		}
+		for i, e := range recent {
+			if i == issuerRows {
+				lines = append(lines, dimStyle.Render(fmt.Sprintf("… %d more", len(recent)-issuerRows)))
+				break
+			}
+			amount := ""
+			if e.Amount != nil {
+				amount = formatPrice(orderbook.Float(e.Amount))
+			}
+			code := e.Code
+			if code == "" {
+				code = "-"
+			}
+			account := e.Account
+			if account == "" {
+				account = e.Issuer
+			}
+			lines = append(lines, fmt.Sprintf("%-19s %s %-6s %16s  %-14s  %s", e.At.Local().Format("2006-01-02 15:04:05"),
+				issuerKindText(e.Kind), code, amount, truncateMiddle(account, 14), e.Detail))
+		}
+
+		info := fmt.Sprintf("outstanding is Horizon's supply on first opening, moved by the operations since; "+
+			"the other totals count the latest %d operations of each issuer and all after them", issuerBackfill)
+		mode := "polled every " + issuerInterval.String() + " while shown"
+		if m.stream != nil && streamingEnabled() {
+			mode = "streamed while shown"
+		}
+		lines = append(lines, "", dimStyle.Render(info+"; "+mode))
+	}
+	if m.issuerNote != "" {
+		lines = append(lines, errorStyle.Render(m.issuerNote))
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		renderVersionInfo(),
+		"",
+		renderHeader(),
+		renderSubtitle("ISSUERS - mints, burns, clawbacks and authorization"),
+		panelStyle.Render(strings.Join(lines, "\n")),
+	)
+	targetHeight := 60
+	if m.height > 0 {
+		targetHeight = m.height
+	}
+	padding := strings.Repeat("\n", max(0, targetHeight-lipgloss.Height(content)-2))
+	return lipgloss.JoinVertical(lipgloss.Left, content, padding, m.bottomLine())
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+
+	"github.com/sdexmon/sdexmon/internal/config"
+	"github.com/sdexmon/sdexmon/internal/pools"
+)
+
+// LiquidityProvider fetches the metrics shown in the liquidity pool panels
+type LiquidityProvider interface {
+	Name() string
+	Pool(ctx context.Context, client *horizonclient.Client, poolID string) (Liquidity, error)
+}
+
+// Provider names accepted by preferences.lp_provider
+const (
+	lpProviderExpert  = "stellar_expert"
+	lpProviderHorizon = "horizon"
+)
+
+// poolTradeLog keeps the last 7 days of trades of every pool the Horizon
+// provider has shown, so refreshes only fetch new trades
+var poolTradeLog = pools.NewTradeLog(7 * 24 * time.Hour)
+
+// lpProvider returns the provider selected in the config, stellar.expert by
+// default. Networks stellar.expert does not index always use Horizon.
+func lpProvider() LiquidityProvider {
+	if config.ExplorerURL() == "" {
+		return horizonLP{}
+	}
+	if appConfig != nil && strings.EqualFold(appConfig.Preferences.LPProvider, lpProviderHorizon) {
+		return horizonLP{}
+	}
+	return expertLP{}
+}
+
+// fetchLP fetches pool metrics from the configured provider
+func fetchLP(ctx context.Context, client *horizonclient.Client, poolID string) (Liquidity, error) {
+	return lpProvider().Pool(ctx, client, poolID)
+}
+
+// expertLP reads pool metrics from the stellar.expert API
+type expertLP struct{}
+
+func (expertLP) Name() string { return lpProviderExpert }
+
+func (expertLP) Pool(ctx context.Context, _ *horizonclient.Client, poolID string) (Liquidity, error) {
+	return fetchLPByID(ctx, poolID)
+}
+
+// horizonLP derives pool metrics from Horizon alone: reserves, shares and fee
+// from the pool itself, volume and fees from the pool's trades
+type horizonLP struct{}
+
+func (horizonLP) Name() string { return lpProviderHorizon }
+
+func (horizonLP) Pool(ctx context.Context, client *horizonclient.Client, poolID string) (Liquidity, error) {
+	if client == nil {
+		return Liquidity{}, fmt.Errorf("no horizon client")
+	}
+	pool, err := client.LiquidityPoolDetail(horizonclient.LiquidityPoolRequest{LiquidityPoolID: poolID})
+	if err != nil {
+		return Liquidity{}, err
+	}
+	if len(pool.Reserves) != 2 {
+		return Liquidity{}, fmt.Errorf("pool %s has %d reserves", poolID, len(pool.Reserves))
+	}
+
+	data := Liquidity{FeeBP: int(pool.FeeBP), Shares: lpAmountOf(pool.TotalShares)}
+	var assets [2]string
+	for i, r := range pool.Reserves {
+		assets[i] = r.Asset
+		data.Codes[i] = strings.SplitN(r.Asset, ":", 2)[0]
+		if r.Asset == "native" {
+			data.Codes[i] = "XLM"
+		}
+		data.Decimals[i] = 7
+		if amt, ok := new(big.Rat).SetString(r.Amount); ok {
+			data.LockedR[i] = amt
+			data.Locked[i] = formatLPRat(amt)
+		}
+	}
+
+	trades, truncated, err := poolTradeLog.Trades(scopedClient(client, ctx), poolID)
+	if err != nil {
+		return Liquidity{}, fmt.Errorf("pool trades: %w", err)
+	}
+	now := time.Now()
+	vol1d, fees1d := pools.TradeStats(trades, poolID, assets, pool.FeeBP, now.Add(-24*time.Hour))
+	vol7d, fees7d := pools.TradeStats(trades, poolID, assets, pool.FeeBP, now.Add(-7*24*time.Hour))
+	for i := 0; i < 2; i++ {
+		data.Vol1dR[i], data.Vol7dR[i] = vol1d[i], vol7d[i]
+		data.Fees1dR[i], data.Fees7dR[i] = fees1d[i], fees7d[i]
+		data.Vol1d[i] = formatLPRat(vol1d[i])
+		data.Vol7d[i] = formatLPRat(vol7d[i])
+		data.Fees1d[i] = formatLPRat(fees1d[i])
+		data.Fees7d[i] = formatLPRat(fees7d[i])
+	}
+	data.Truncated = truncated
+	return data, nil
+}
+
+// lpAmountOf formats a Horizon decimal amount like the stellar.expert
+// amounts shown in the pool panels
+func lpAmountOf(amount string) string {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return ""
+	}
+	return formatLPRat(r)
+}
+
+// formatLPRat formats an exact amount in whole units like lpAmountOf
+func formatLPRat(r *big.Rat) string {
+	stroops := new(big.Rat).Mul(r, big.NewRat(10000000, 1))
+	return formatLPAmount(stroops.FloatString(0))
+}
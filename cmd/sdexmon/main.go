@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math/big"
 	"net/http"
 	"os"
 	"strconv"
@@ -22,8 +24,19 @@ import (
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/go/txnbuild"
 
+	"github.com/sdexmon/sdexmon/internal/alerts"
+	"github.com/sdexmon/sdexmon/internal/arb"
+	"github.com/sdexmon/sdexmon/internal/assetstats"
+	"github.com/sdexmon/sdexmon/internal/candles"
 	"github.com/sdexmon/sdexmon/internal/config"
+	"github.com/sdexmon/sdexmon/internal/issuer"
+	"github.com/sdexmon/sdexmon/internal/makers"
 	"github.com/sdexmon/sdexmon/internal/models"
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+	"github.com/sdexmon/sdexmon/internal/peg"
+	"github.com/sdexmon/sdexmon/internal/pools"
+	"github.com/sdexmon/sdexmon/internal/slippage"
+	"github.com/sdexmon/sdexmon/internal/tradestore"
 	"github.com/sdexmon/sdexmon/internal/ui"
 	"github.com/sdexmon/sdexmon/internal/version"
 )
@@ -45,6 +58,13 @@ const (
 	screenPairInfo
 	screenPairDebug
 	screenPairInput // custom pair input screen
+	screenMaintenance
+	screenOverview // all configured pairs at a glance
+	screenPeg      // peg deviation monitor
+	screenArb      // triangular arbitrage across configured pairs
+	screenAlerts   // alert rules that fired, with acknowledge
+	screenSLA      // market-maker spread and depth obligations
+	screenIssuers  // mints, burns and flag changes of the curated issuers
 )
 
 const asciiAquila = `███████  ██████  █████  ██████       █████   ██████  ██    ██ ██ ██       █████  
@@ -71,7 +91,33 @@ var gitCommit = "unknown"
 
 // Curated assets and pairs (static table)
 
-type pairOption struct{ Base, Quote string }
+// pairOption is a selectable pair. Base and Quote are the asset codes shown
+// in the selector; configured pairs also carry their parsed assets and label.
+type pairOption struct {
+	Base, Quote string
+	Name        string
+	BaseAsset   txnbuild.Asset
+	QuoteAsset  txnbuild.Asset
+}
+
+// assets returns the pair's assets, resolving curated codes when the option
+// was not built from the config
+func (p pairOption) assets() (base, quote txnbuild.Asset, ok bool) {
+	if p.BaseAsset != nil && p.QuoteAsset != nil {
+		return p.BaseAsset, p.QuoteAsset, true
+	}
+	base, ok1 := curatedAssets[p.Base]
+	quote, ok2 := curatedAssets[p.Quote]
+	return base, quote, ok1 && ok2
+}
+
+// label is the name shown in the pair selector
+func (p pairOption) label() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Base + "/" + p.Quote
+}
 
 var curatedAssets = map[string]txnbuild.Asset{
 	"USDZ": txnbuild.CreditAsset{Code: "USDZ", Issuer: "GAKTLPC4ZV37SSCITQ5IS5AQ4WPF4CF4VZJQPPAROSGXMYOATF5U6XPR"},
@@ -84,23 +130,23 @@ var curatedAssets = map[string]txnbuild.Asset{
 }
 
 var curatedPairs = []pairOption{
-	{"USDC", "USDZ"},
-	{"USDZ", "ZARZ"},
-	{"USDZ", "EURZ"},
-	{"USDZ", "BTCZ"},
-	{"USDZ", "XAUZ"},
-	{"EURZ", "ZARZ"},
-	{"EURZ", "XAUZ"},
-	{"EURZ", "BTCZ"},
-	{"ZARZ", "XAUZ"},
-	{"ZARZ", "BTCZ"},
-	{"XAUZ", "BTCZ"},
-	{"XLM", "USDC"},
-	{"XLM", "USDZ"},
-	{"XLM", "EURZ"},
-	{"XLM", "ZARZ"},
-	{"XLM", "XAUZ"},
-	{"XLM", "BTCZ"},
+	{Base: "USDC", Quote: "USDZ"},
+	{Base: "USDZ", Quote: "ZARZ"},
+	{Base: "USDZ", Quote: "EURZ"},
+	{Base: "USDZ", Quote: "BTCZ"},
+	{Base: "USDZ", Quote: "XAUZ"},
+	{Base: "EURZ", Quote: "ZARZ"},
+	{Base: "EURZ", Quote: "XAUZ"},
+	{Base: "EURZ", Quote: "BTCZ"},
+	{Base: "ZARZ", Quote: "XAUZ"},
+	{Base: "ZARZ", Quote: "BTCZ"},
+	{Base: "XAUZ", Quote: "BTCZ"},
+	{Base: "XLM", Quote: "USDC"},
+	{Base: "XLM", Quote: "USDZ"},
+	{Base: "XLM", Quote: "EURZ"},
+	{Base: "XLM", Quote: "ZARZ"},
+	{Base: "XLM", Quote: "XAUZ"},
+	{Base: "XLM", Quote: "BTCZ"},
 }
 
 // Global configuration loaded from YAML
@@ -108,6 +154,9 @@ var appConfig *config.Config
 var configuredPairs []pairOption
 var liquidityPoolIDs map[string]string
 
+// poolResolver discovers and caches the liquidity pool of any pair
+var poolResolver = pools.NewResolver()
+
 // Fallback curated data (used if config loading fails)
 var fallbackLiquidityPoolIDs = map[string]string{
 	"USDC-USDZ": "314e17d86ffc767a6132fba31cc9f53f23ca359d2db788f26f0d364d75e82c57",
@@ -148,20 +197,41 @@ var fallbackLiquidityPoolIDs = map[string]string{
 
 // Messages
 
+// Pair-scoped messages carry the pair generation they were issued for (see
+// pollScheduler) and are dropped when it no longer matches the model's.
 type (
-	orderbookTickMsg     struct{}
-	tradesTickMsg        struct{}
-	lpTickMsg            struct{}
+	orderbookTickMsg     struct{ gen uint64 }
+	tradesTickMsg        struct{ gen uint64 }
+	lpTickMsg            struct{ gen uint64 }
 	networkTickMsg       struct{}
-	orderbookDataMsg     struct{ ob hProtocol.OrderBookSummary }
-	tradesDataMsg        struct{ list []hProtocol.Trade }
-	lpDataMsg            struct{ data Liquidity }
-	lpNoteMsg            string
-	exposureDataMsg      struct{ pools []Liquidity }
-	baseExposureDataMsg  struct{ pools []Liquidity }
-	quoteExposureDataMsg struct{ pools []Liquidity }
-	networkStatsMsg      struct{ capacityUsage float64 }
-	errMsg               error
+	orderbookDataMsg     struct {
+		gen uint64
+		ob  orderbook.Book
+	}
+	tradesDataMsg struct {
+		gen  uint64
+		list []hProtocol.Trade
+	}
+	lpDataMsg struct {
+		gen    uint64
+		poolID string
+		data   Liquidity
+	}
+	lpNoteMsg struct {
+		gen  uint64
+		note string
+	}
+	exposureDataMsg     struct{ pools []Liquidity }
+	baseExposureDataMsg struct {
+		gen   uint64
+		pools []Liquidity
+	}
+	quoteExposureDataMsg struct {
+		gen   uint64
+		pools []Liquidity
+	}
+	networkStatsMsg struct{ capacityUsage float64 }
+	errMsg          error
 )
 
 // FeeStats represents the response from /fee_stats endpoint
@@ -188,11 +258,39 @@ type model struct {
 	quote         txnbuild.Asset
 	selectedAsset txnbuild.Asset // for single asset exposure view
 
-	orderbook hProtocol.OrderBookSummary
+	orderbook orderbook.Book
 	trades    []hProtocol.Trade
 
+	// SSE streaming (nil stream when unavailable, e.g. headless use)
+	stream    *marketStreamer
+	streaming bool // order book and trades currently arrive via stream
+
+	// pair generation; bumped on every pair switch
+	sched *pollScheduler
+	gen   uint64
+
 	tradeCursor string // paging token of last trade we processed
 
+	// stored trade history; tradeOffset > 0 shows tradeHistory instead of
+	// the live trades
+	tradeOffset  int
+	tradeHistory []hProtocol.Trade
+	showVolume   bool
+	volumes      []tradestore.AccountVolume
+	volumeNote   string
+
+	// our market makers' offers on the pair
+	makerOffers makers.Set
+	makerNote   string
+	showMakers  bool
+
+	// Horizon /assets stats of the base or quote asset
+	statsSide int    // statsOff, statsBase or statsQuote
+	statsGen  uint64 // bumped on every toggle
+	statsSnap *assetstats.Snapshot
+	statsHist []assetstats.Snapshot // oldest first
+	statsNote string
+
 	// liquidity data
 	lp            Liquidity
 	lpPoolID      string
@@ -201,6 +299,71 @@ type model struct {
 	baseExposure  []Liquidity // exposure pools for base asset in pair
 	quoteExposure []Liquidity // exposure pools for quote asset in pair
 
+	// candlestick chart
+	showChart bool
+	chartRes  int // index into candles.Resolutions
+	candles   candles.Series
+	chartNote string
+
+	// multi-pair overview
+	overview      []overviewRow // in configuredPairs order
+	overviewGen   uint64        // bumped on entering and leaving the screen
+	overviewIndex int           // selected row, in sorted order
+	overviewSort  int           // column
+	overviewDesc  bool
+
+	// peg monitor
+	pegGen      uint64
+	pegReadings []peg.Reading
+	pegRates    peg.Rates
+	pegSource   string
+	pegNote     string
+
+	// triangular arbitrage
+	arbGen    uint64
+	arbCycles []arb.Cycle
+	arbQuoted int
+	arbFailed int
+	arbAt     time.Time
+
+	// alert rules; the engine is shared, so acks survive copies of the model
+	alerts      *alerts.Engine
+	alertPairs  []alertPair // watched pairs, polled in the background
+	alertNote   string
+	alertIndex  int
+	alertReturn screenState // screen the alert panel was opened from
+	alertTicks  bool        // the background alert tick is scheduled
+
+	// market-maker SLA; samples go to slaStore
+	slaGen     uint64
+	slaWindow  int // index into slaWindows
+	slaReports []slaReport
+	slaNote    string
+	slaLiveAt  time.Time // last sample taken from the live feed
+	slaLiveKey string    // pair of that sample
+
+	// issuer monitor, following the issuers while its screen is shown; the
+	// ledger and cursors are kept across visits and shared by copies of the
+	// model
+	issuerLedger  *issuer.Ledger
+	issuerCursors map[string]string // last operation seen per issuer
+	issuerGen     uint64            // issuer session; bumped when it stops
+	issuerScope   fetchScope
+	issuerCancel  context.CancelFunc
+	issuerNote    string
+
+	// price impact calculator
+	showImpact  bool
+	impactInput textinput.Model
+	impactSide  slippage.Side
+
+	// strict-send / strict-receive path quotes
+	showPaths    bool
+	pathsInput   textinput.Model
+	pathsReceive bool
+	paths        []pathRoute
+	pathsNote    string
+
 	// debug log buffer
 	debugLogs []string
 
@@ -232,6 +395,7 @@ type model struct {
 
 	// maintenance mode
 	maintenanceState models.MaintenanceState
+	maintenanceFrom  screenState // screen to return to when leaving maintenance
 
 	status string
 	err    error
@@ -267,8 +431,13 @@ func initialModel(client *horizonclient.Client, base, quote txnbuild.Asset) mode
 	// but don't skip the landing page
 	initialScreen := screenLanding
 
+	problems := configureAlertSinks()
+	engine, watched, ruleProblems := newAlertEngine()
+	problems = append(problems, ruleProblems...)
+
 	return model{
 		client:           client,
+		sched:            newPollScheduler(),
 		currentScreen:    initialScreen,
 		base:             base,
 		quote:            quote,
@@ -281,19 +450,33 @@ func initialModel(client *horizonclient.Client, base, quote txnbuild.Asset) mode
 		debugMode:        debugMode,
 		debugLogs:        make([]string, 0, 100),
 		exposurePools:    make([]Liquidity, 0),
+		makerOffers:      makers.Set{},
 		showPairPopup:    false, // Start on landing page, open popup on enter
 		pairIndex:        currentPairIndex(base, quote),
+		impactInput:      newImpactInput(),
+		pathsInput:       newPathsInput(),
 		maintenanceState: initMaintenanceState(),
 		status:           "Select pair to begin",
+		alerts:           engine,
+		alertPairs:       watched,
+		alertNote:        strings.Join(problems, "; "),
+		alertTicks:       len(engine.Rules()) > 0,
 	}
 }
 
 func (m model) Init() tea.Cmd {
 	// Start network capacity polling immediately
-	return tea.Batch(
+	cmds := []tea.Cmd{
 		fetchNetworkStatsCmd(m.client),
 		tea.Tick(networkInterval, func(time.Time) tea.Msg { return networkTickMsg{} }),
-	)
+	}
+	if m.alertTicks {
+		cmds = append(cmds, m.pollAlertPairs(""), alertTick())
+	}
+	if targets, _ := slaTargets(); len(targets) > 0 {
+		cmds = append(cmds, m.pollSLA(), slaTick())
+	}
+	return tea.Batch(cmds...)
 }
 
 // Update
@@ -301,6 +484,20 @@ func (m model) Init() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// Maintenance screens take text input, so only ctrl+c quits there
+		if m.currentScreen == screenMaintenance && msg.String() != "ctrl+c" {
+			return handleMaintenanceUpdate(m, msg)
+		}
+		// Likewise the price impact and path quote inputs while open
+		if m.currentScreen == screenPairInfo && !m.showPairPopup && msg.String() != "ctrl+c" {
+			if m.showImpact {
+				return m.handleImpactKeys(msg)
+			}
+			if m.showPaths {
+				return m.handlePathsKeys(msg)
+			}
+		}
+
 		// Global quit (but block on upgrade screen)
 		if msg.String() == "ctrl+c" || msg.String() == "q" {
 			if m.currentScreen == screenUpgradeRequired {
@@ -316,6 +513,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Block all navigation on upgrade screen
 			return m, nil
 
+		case screenOverview:
+			return m.handleOverviewKeys(msg)
+
+		case screenPeg:
+			return m.handlePegKeys(msg)
+
+		case screenArb:
+			return m.handleArbKeys(msg)
+
+		case screenAlerts:
+			return m.handleAlertKeys(msg)
+
+		case screenSLA:
+			return m.handleSLAKeys(msg)
+
+		case screenIssuers:
+			return m.handleIssuerKeys(msg)
+
 		case screenLanding:
 			// Handle popup pair selector if open from landing
 			if m.showPairPopup {
@@ -334,11 +549,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						// Select current filtered pair
 						if len(m.filteredPairs) > 0 && m.pairIndex < len(m.filteredPairs) {
 							opt := m.filteredPairs[m.pairIndex]
-							base, ok1 := curatedAssets[opt.Base]
-							quote, ok2 := curatedAssets[opt.Quote]
-							if ok1 && ok2 {
+							if base, quote, ok := opt.assets(); ok {
 								m.base, m.quote = base, quote
-								m.tradeCursor = ""
 								m.showPairPopup = false
 								m.searchMode = false
 								m.searchInput.Blur()
@@ -346,15 +558,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 								m.filteredPairs = configuredPairs
 								m.currentScreen = screenPairInfo
 								m.status = "pair selected"
-								return m, tea.Batch(
-									fetchOrderbookCmd(m.client, m.base, m.quote),
-									fetchTradesCmd(m.client, m.base, m.quote, m.tradeCursor, true),
-									resolveAndFetchLPCmd(m.client, m.base, m.quote),
-									fetchBaseExposureCmd(m.client, m.base),
-									fetchQuoteExposureCmd(m.client, m.quote),
-									tea.Tick(orderbookInterval, func(time.Time) tea.Msg { return orderbookTickMsg{} }),
-									tea.Tick(tradesInterval, func(time.Time) tea.Msg { return tradesTickMsg{} }),
-								)
+								return m, m.startPairFeeds()
 							}
 						}
 						return m, nil
@@ -387,6 +591,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.searchMode = true
 					m.searchInput.Focus()
 					return m, nil
+				case "m":
+					m.showPairPopup = false
+					return m.openMaintenance()
 				case "up", "k":
 					if m.pairIndex > 0 {
 						m.pairIndex--
@@ -400,23 +607,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				case "enter":
 				if len(configuredPairs) > 0 {
 					opt := configuredPairs[m.pairIndex]
-						base, ok1 := curatedAssets[opt.Base]
-						quote, ok2 := curatedAssets[opt.Quote]
-						if ok1 && ok2 {
+						if base, quote, ok := opt.assets(); ok {
 							m.base, m.quote = base, quote
-							m.tradeCursor = ""
 							m.showPairPopup = false
 							m.currentScreen = screenPairInfo
 							m.status = "pair selected"
-							return m, tea.Batch(
-								fetchOrderbookCmd(m.client, m.base, m.quote),
-								fetchTradesCmd(m.client, m.base, m.quote, m.tradeCursor, true),
-								resolveAndFetchLPCmd(m.client, m.base, m.quote),
-								fetchBaseExposureCmd(m.client, m.base),
-								fetchQuoteExposureCmd(m.client, m.quote),
-								tea.Tick(orderbookInterval, func(time.Time) tea.Msg { return orderbookTickMsg{} }),
-								tea.Tick(tradesInterval, func(time.Time) tea.Msg { return tradesTickMsg{} }),
-							)
+							return m, m.startPairFeeds()
 						}
 					}
 					return m, nil
@@ -430,6 +626,20 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.showPairPopup = true
 				m.pairIndex = currentPairIndex(m.base, m.quote)
 				return m, nil
+			case "m":
+				return m.openMaintenance()
+			case "w":
+				return m.openOverview()
+			case "g":
+				return m.openPegMonitor()
+			case "a":
+				return m.openArb()
+			case "l":
+				return m.openSLA()
+			case "s":
+				return m.openIssuers()
+			case "!":
+				return m.openAlerts()
 			}
 
 		case screenPairInput:
@@ -450,18 +660,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				}
 				m.base, m.quote = base, quote
-				m.tradeCursor = ""
 				m.currentScreen = screenPairInfo
 				m.status = "pair updated"
-				return m, tea.Batch(
-					fetchOrderbookCmd(m.client, m.base, m.quote),
-					fetchTradesCmd(m.client, m.base, m.quote, m.tradeCursor, true),
-					resolveAndFetchLPCmd(m.client, m.base, m.quote),
-					fetchBaseExposureCmd(m.client, m.base),
-					fetchQuoteExposureCmd(m.client, m.quote),
-					tea.Tick(orderbookInterval, func(time.Time) tea.Msg { return orderbookTickMsg{} }),
-					tea.Tick(tradesInterval, func(time.Time) tea.Msg { return tradesTickMsg{} }),
-				)
+				return m, m.startPairFeeds()
 			case "tab":
 				if m.baseInput.Focused() {
 					m.baseInput.Blur()
@@ -496,26 +697,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						// Select current filtered pair
 						if len(m.filteredPairs) > 0 && m.pairIndex < len(m.filteredPairs) {
 							opt := m.filteredPairs[m.pairIndex]
-							base, ok1 := curatedAssets[opt.Base]
-							quote, ok2 := curatedAssets[opt.Quote]
-							if ok1 && ok2 {
+							if base, quote, ok := opt.assets(); ok {
 								m.base, m.quote = base, quote
-								m.tradeCursor = ""
 								m.showPairPopup = false
 								m.searchMode = false
 								m.searchInput.Blur()
 								m.searchInput.SetValue("")
 								m.filteredPairs = configuredPairs
 								m.status = "pair updated"
-								return m, tea.Batch(
-									fetchOrderbookCmd(m.client, m.base, m.quote),
-									fetchTradesCmd(m.client, m.base, m.quote, m.tradeCursor, true),
-									resolveAndFetchLPCmd(m.client, m.base, m.quote),
-									fetchBaseExposureCmd(m.client, m.base),
-									fetchQuoteExposureCmd(m.client, m.quote),
-									tea.Tick(orderbookInterval, func(time.Time) tea.Msg { return orderbookTickMsg{} }),
-									tea.Tick(tradesInterval, func(time.Time) tea.Msg { return tradesTickMsg{} }),
-								)
+								return m, m.startPairFeeds()
 							}
 						}
 						return m, nil
@@ -548,6 +738,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.searchMode = true
 					m.searchInput.Focus()
 					return m, nil
+				case "m":
+					m.showPairPopup = false
+					return m.openMaintenance()
 				case "up", "k":
 					if m.pairIndex > 0 {
 						m.pairIndex--
@@ -561,22 +754,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				case "enter":
 				if len(configuredPairs) > 0 {
 					opt := configuredPairs[m.pairIndex]
-						base, ok1 := curatedAssets[opt.Base]
-						quote, ok2 := curatedAssets[opt.Quote]
-						if ok1 && ok2 {
+						if base, quote, ok := opt.assets(); ok {
 							m.base, m.quote = base, quote
-							m.tradeCursor = ""
 							m.showPairPopup = false
 							m.status = "pair updated"
-							return m, tea.Batch(
-								fetchOrderbookCmd(m.client, m.base, m.quote),
-								fetchTradesCmd(m.client, m.base, m.quote, m.tradeCursor, true),
-								resolveAndFetchLPCmd(m.client, m.base, m.quote),
-								fetchBaseExposureCmd(m.client, m.base),
-								fetchQuoteExposureCmd(m.client, m.quote),
-								tea.Tick(orderbookInterval, func(time.Time) tea.Msg { return orderbookTickMsg{} }),
-								tea.Tick(tradesInterval, func(time.Time) tea.Msg { return tradesTickMsg{} }),
-							)
+							return m, m.startPairFeeds()
 						}
 					}
 					return m, nil
@@ -594,6 +776,50 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "d":
 				m.currentScreen = screenPairDebug
 				return m, nil
+			case "m":
+				return m.openMaintenance()
+			case "i":
+				// the impact and path panels each own the keyboard, so only one is open
+				m.showImpact, m.showPaths = true, false
+				m.impactInput.Focus()
+				return m, nil
+			case "o":
+				m.showPaths, m.showImpact = true, false
+				m.pathsInput.Focus()
+				return m, nil
+			case "c":
+				m.showChart = !m.showChart
+				if m.showChart {
+					return m, m.reloadChartCmd()
+				}
+				m.candles = candles.Series{}
+				return m, nil
+			case "r":
+				if !m.showChart {
+					return m, nil
+				}
+				m.chartRes = (m.chartRes + 1) % len(candles.Resolutions)
+				return m, m.reloadChartCmd()
+			case "w":
+				return m.openOverview()
+			case "g":
+				return m.openPegMonitor()
+			case "a":
+				return m.openArb()
+			case "l":
+				return m.openSLA()
+			case "s":
+				return m.openIssuers()
+			case "!":
+				return m.openAlerts()
+			case "[", "]":
+				return m.scrollTrades(msg.String() == "[")
+			case "v":
+				return m.toggleVolume()
+			case "u":
+				return m.toggleMakers()
+			case "t":
+				return m.toggleStats()
 			}
 
 		case screenPairDebug:
@@ -611,19 +837,57 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case orderbookTickMsg:
+		if msg.gen != m.gen || m.streaming {
+			return m, nil
+		}
 		return m, tea.Batch(
-			fetchOrderbookCmd(m.client, m.base, m.quote),
-			tea.Tick(orderbookInterval, func(time.Time) tea.Msg { return orderbookTickMsg{} }),
+			fetchOrderbookCmd(m.scope(), m.base, m.quote),
+			orderbookTick(m.gen),
 		)
 	case tradesTickMsg:
+		if msg.gen != m.gen || m.streaming {
+			return m, nil
+		}
 		return m, tea.Batch(
-			fetchTradesCmd(m.client, m.base, m.quote, m.tradeCursor, false),
-			tea.Tick(tradesInterval, func(time.Time) tea.Msg { return tradesTickMsg{} }),
+			fetchTradesCmd(m.scope(), m.base, m.quote, m.tradeCursor, false),
+			tradesTick(m.gen),
 		)
+	case makerTickMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		return m, tea.Batch(m.fetchMakerOffers(m.scope()), makerTick(m.gen))
+	case makerOffersMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		m.makerNote = ""
+		if msg.err != nil {
+			m.makerNote = fmt.Sprintf("offers of %s: %v", truncateMiddle(msg.account, 12), msg.err)
+			return m, nil
+		}
+		if len(msg.offers) >= makerOfferLimit {
+			m.makerNote = fmt.Sprintf("only the first %d offers of %s are read", makerOfferLimit, truncateMiddle(msg.account, 12))
+		}
+		m.makerOffers.Replace(msg.account, m.pairOffers(msg.offers))
+		return m, nil
+	case makerOfferMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		// a streamed offer with nothing left, or moved to another pair, is gone
+		m.makerOffers.Remove(msg.offer.ID)
+		for _, o := range m.pairOffers([]hProtocol.Offer{msg.offer}) {
+			m.makerOffers[o.ID] = o
+		}
+		return m, nil
 	case lpTickMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
 		return m, tea.Batch(
-			resolveAndFetchLPCmd(m.client, m.base, m.quote),
-			tea.Tick(lpInterval, func(time.Time) tea.Msg { return lpTickMsg{} }),
+			resolveAndFetchLPCmd(m.scope(), m.base, m.quote),
+			lpTick(m.gen),
 		)
 	case networkTickMsg:
 		return m, tea.Batch(
@@ -632,11 +896,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		)
 
 	case orderbookDataMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
 		m.orderbook = msg.ob
 		m.lastOrderbookAt = time.Now()
 		m.err = nil
-		return m, nil
+		return m, tea.Batch(m.observeAlerts(bookSamples(m.livePairKey(), msg.ob, m.lastOrderbookAt)...),
+			m.sampleLiveSLA(msg.ob, m.lastOrderbookAt))
 	case tradesDataMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
 		if len(msg.list) > 0 {
 			// append and cap
 			m.trades = append(m.trades, msg.list...)
@@ -645,31 +916,220 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			// advance cursor
 			m.tradeCursor = msg.list[len(msg.list)-1].PagingToken()
+			m.applyTradesToCandles(msg.list)
 		}
 		m.lastTradesAt = time.Now()
 		m.err = nil
+		var alertCmd tea.Cmd
+		if len(msg.list) > 0 {
+			last := msg.list[len(msg.list)-1]
+			alertCmd = m.observeAlerts(alerts.LastTrade(m.livePairKey(), last.LedgerCloseTime, m.lastTradesAt))
+		}
+		return m, tea.Batch(storeTradesCmd(m.base, m.quote, msg.list), alertCmd)
+	case screenTickMsg:
+		return m, m.refreshTick(msg)
+	case overviewRowMsg:
+		if msg.gen != m.overviewGen {
+			return m, nil
+		}
+		for i := range m.overview {
+			if m.overview[i].pair.label() == msg.row.pair.label() {
+				m.overview[i] = msg.row
+			}
+		}
+		return m, nil
+	case pegDataMsg:
+		if msg.gen != m.pegGen {
+			return m, nil
+		}
+		m.pegNote = ""
+		if msg.err != nil {
+			m.pegNote = "rates: " + msg.err.Error()
+		}
+		if msg.readings != nil {
+			m.pegReadings, m.pegRates, m.pegSource = msg.readings, msg.rates, msg.source
+		}
+		return m, nil
+	case arbDataMsg:
+		if msg.gen != m.arbGen {
+			return m, nil
+		}
+		m.arbCycles, m.arbQuoted, m.arbFailed, m.arbAt = msg.cycles, msg.quoted, msg.failed, msg.at
+		return m, nil
+	case slaTickMsg:
+		return m, tea.Batch(m.pollSLA(), slaTick())
+	case slaReportMsg:
+		if msg.gen != m.slaGen {
+			return m, nil
+		}
+		m.slaNote = ""
+		if msg.err != nil {
+			m.slaNote = "samples: " + msg.err.Error()
+		}
+		if msg.reports != nil {
+			m.slaReports = msg.reports
+		}
+		return m, nil
+	case statsTickMsg:
+		if msg.gen != m.statsGen || m.statsAsset() == nil {
+			return m, nil
+		}
+		return m, tea.Batch(fetchStatsCmd(m.client, m.statsGen, m.statsAsset()), statsTick(m.statsGen))
+	case statsDataMsg:
+		if msg.gen != m.statsGen {
+			return m, nil
+		}
+		m.statsNote = ""
+		if msg.err != nil {
+			m.statsNote = "stats: " + msg.err.Error()
+		}
+		if msg.snap.Asset != "" {
+			snap := msg.snap
+			m.statsSnap, m.statsHist = &snap, msg.history
+		}
+		return m, nil
+	case issuerOpsMsg:
+		return m.handleIssuerOps(msg)
+	case issuerOpMsg:
+		return m.handleIssuerOp(msg)
+	case issuerTickMsg:
+		return m.handleIssuerTick(msg)
+	case issuerSeedMsg:
+		return m.handleIssuerSeed(msg)
+	case tradeHistoryMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.status = "trade history: " + msg.err.Error()
+			return m, nil
+		}
+		if len(msg.list) == 0 {
+			m.status = "no older trades stored"
+			return m, nil
+		}
+		m.tradeOffset, m.tradeHistory = msg.offset, msg.list
+		return m, nil
+	case volumeDataMsg:
+		if msg.gen != m.gen || !m.showVolume {
+			return m, nil
+		}
+		m.volumes, m.volumeNote = msg.vols, ""
+		if msg.err != nil {
+			m.volumeNote = msg.err.Error()
+		}
 		return m, nil
 	case lpDataMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
 		m.lp = msg.data
+		m.lpPoolID = msg.poolID
 		m.lpMessage = ""
 		m.lastLPAt = time.Now()
-		return m, nil
+		return m, m.observeAlerts(lpSamples(m.livePairKey(), msg.data, m.lastLPAt)...)
 	case lpNoteMsg:
-		m.lpMessage = string(msg)
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		m.lpMessage = msg.note
+		return m, nil
+	case candlesDataMsg:
+		if msg.gen != m.gen || msg.res != m.chartResolution() {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.chartNote = fmt.Sprintf("Chart fetch error: %v", msg.err)
+			return m, nil
+		}
+		m.candles = msg.series
+		m.chartNote = ""
 		return m, nil
 	case exposureDataMsg:
 		m.exposurePools = msg.pools
 		m.err = nil
 		return m, nil
 	case baseExposureDataMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
 		m.baseExposure = msg.pools
 		return m, nil
 	case quoteExposureDataMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
 		m.quoteExposure = msg.pools
 		return m, nil
 	case networkStatsMsg:
 		m.networkCapacity = msg.capacityUsage
+		if msg.capacityUsage < 0 {
+			// the fetch failed; that is not a reading
+			return m, nil
+		}
 		m.lastNetworkAt = time.Now()
+		return m, m.observeAlerts(alerts.Sample{Metric: alerts.MetricNetworkCapacity, Value: msg.capacityUsage * 100, At: m.lastNetworkAt})
+	case alertTickMsg:
+		if len(m.alerts.Rules()) == 0 {
+			m.alertTicks = false
+			return m, nil
+		}
+		return m, tea.Batch(dispatchAlerts(m.alerts.Evaluate(time.Now())), m.pollAlertPairs(m.livePairKey()), alertTick())
+	case alertSamplesMsg:
+		return m, m.observeAlerts(msg.samples...)
+	case streamFailedMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		log.Printf("Streaming unavailable, falling back to polling: %v", msg.err)
+		m.streaming = false
+		m.status = "streaming unavailable, polling"
+		if m.base == nil || m.quote == nil {
+			return m, nil
+		}
+		return m, pollFeedsCmd(m)
+	case models.AssetSearchResultsMsg, models.ConfirmationDataMsg, models.MaintenanceErrMsg:
+		return handleMaintenanceUpdate(m, msg)
+	case configReloadedMsg:
+		var err error
+		if msg.cfg != nil {
+			err = applyConfiguration(msg.cfg)
+		} else {
+			err = loadConfiguration()
+		}
+		if err != nil {
+			log.Printf("Warning: failed to reload config: %v", err)
+		}
+		m.filteredPairs = configuredPairs
+		if m.pairIndex >= len(configuredPairs) {
+			m.pairIndex = max(0, len(configuredPairs)-1)
+		}
+		if m.issuerLedger != nil && !onPubnet() {
+			// the curated issuers are pubnet accounts
+			m.stopIssuers()
+			m.issuerLedger, m.issuerCursors = nil, nil
+		}
+		cmd := m.reloadAlerts()
+		return m.configSaved(msg), cmd
+	case configSaveFailedMsg:
+		if m.currentScreen != screenMaintenance {
+			m.status = fmt.Sprintf("Failed to save config: %v", msg.err)
+			return m, nil
+		}
+		m.maintenanceState.LoadingMessage = ""
+		m.maintenanceState.ErrorMessage = fmt.Sprintf("Failed to save: %v", msg.err)
+		return m, nil
+	case pathsDataMsg:
+		if msg.gen != m.gen || msg.receive != m.pathsReceive {
+			return m, nil
+		}
+		m.paths = msg.routes
+		m.pathsNote = ""
+		if msg.err != nil {
+			m.pathsNote = "path query failed: " + msg.err.Error()
+		} else if len(msg.routes) == 0 {
+			m.pathsNote = "no routes found"
+		}
 		return m, nil
 	case errMsg:
 		m.err = msg
@@ -693,6 +1153,20 @@ func (m model) View() string {
 		return pairInfoView(m)
 	case screenPairDebug:
 		return pairDebugView(m)
+	case screenMaintenance:
+		return maintenanceView(m)
+	case screenOverview:
+		return overviewView(m)
+	case screenPeg:
+		return pegView(m)
+	case screenArb:
+		return arbView(m)
+	case screenAlerts:
+		return alertsView(m)
+	case screenSLA:
+		return slaView(m)
+	case screenIssuers:
+		return issuersView(m)
 	default:
 		return landingView(m)
 	}
@@ -709,8 +1183,9 @@ func (m *model) filterPairs() {
 
 	filtered := []pairOption{}
 	for _, p := range configuredPairs {
-		// Search matches if query is in either base or quote asset
-		if strings.Contains(p.Base, query) || strings.Contains(p.Quote, query) {
+		// Search matches if query is in either asset or the pair label
+		if strings.Contains(p.Base, query) || strings.Contains(p.Quote, query) ||
+			strings.Contains(strings.ToUpper(p.Name), query) {
 			filtered = append(filtered, p)
 		}
 	}
@@ -759,7 +1234,7 @@ func pairSelectorPopup(m model) string {
 	} else {
 		for i := start; i < end; i++ {
 			p := pairsList[i]
-			label := fmt.Sprintf("%s/%s", p.Base, p.Quote)
+			label := p.label()
 			if i == m.pairIndex {
 				lines = append(lines, selectedStyle.Render("> "+label))
 			} else {
@@ -772,7 +1247,7 @@ func pairSelectorPopup(m model) string {
 	if m.searchMode {
 		lines = append(lines, dimStyle.Render("↑/↓: navigate  enter: select  esc: exit search"))
 	} else {
-		lines = append(lines, dimStyle.Render("↑/↓: navigate  enter: select  s: search  esc: close"))
+		lines = append(lines, dimStyle.Render("↑/↓: navigate  enter: select  s: search  m: manage  esc: close"))
 	}
 
 	content := strings.Join(lines, "\n")
@@ -785,7 +1260,7 @@ func pairInfoView(m model) string {
 		return landingView(m)
 	}
 
-	subtitle := fmt.Sprintf("Pair Info - %s/%s", assetShort(m.base), assetShort(m.quote))
+	subtitle := fmt.Sprintf("Pair Info - %s/%s (%s)", assetShort(m.base), assetShort(m.quote), m.feedMode())
 
 	ob := m.renderOrderbook()
 	tr := m.renderTrades()
@@ -806,6 +1281,31 @@ func pairInfoView(m model) string {
 
 	// Full width panel
 	lpW := leftW + rightW + 1 // Combined width + spacer
+	if m.showChart {
+		// Optional chart row between the book and the pool
+		chart := panelStyle.Width(lpW).Render(m.renderChart(lpW - 4))
+		row1 = lipgloss.JoinVertical(lipgloss.Left, row1, "", chart)
+	}
+	if m.showImpact {
+		impact := panelStyle.Width(lpW).Render(m.renderImpact())
+		row1 = lipgloss.JoinVertical(lipgloss.Left, row1, "", impact)
+	}
+	if m.showPaths {
+		paths := panelStyle.Width(lpW).Render(m.renderPaths())
+		row1 = lipgloss.JoinVertical(lipgloss.Left, row1, "", paths)
+	}
+	if m.showVolume {
+		volume := panelStyle.Width(lpW).Render(m.renderVolume())
+		row1 = lipgloss.JoinVertical(lipgloss.Left, row1, "", volume)
+	}
+	if m.showMakers {
+		ours := panelStyle.Width(lpW).Render(m.renderMakers())
+		row1 = lipgloss.JoinVertical(lipgloss.Left, row1, "", ours)
+	}
+	if m.statsSide != statsOff {
+		stats := panelStyle.Width(lpW).Render(m.renderStats())
+		row1 = lipgloss.JoinVertical(lipgloss.Left, row1, "", stats)
+	}
 	row2 := panelStyle.Width(lpW).Render(lp)
 
 	// Exposure panels - equal width split
@@ -914,6 +1414,9 @@ func (m model) renderOrderbook() string {
 	barW := 12
 
 	title := boldStyle.Render("ORDER BOOK")
+	if len(marketMakers()) > 0 {
+		title += "  " + makerMarker + dimStyle.Render(" ours")
+	}
 	head := lipgloss.JoinHorizontal(lipgloss.Top,
 		dimStyle.Render(padRightVis("PRICE ("+priceUnit+")", priceW)),
 		padRight("", 2),
@@ -928,19 +1431,17 @@ func (m model) renderOrderbook() string {
 	nA := minInt(len(asks), maxRows)
 	padA := maxRows - nA
 	// build best-first slice and cumulative from best outward
-	asksBest := make([]hProtocol.PriceLevel, nA)
-	copy(asksBest, asks[:nA])
-	askCumBest := make([]float64, nA)
-	var sum float64
+	asksBest := asks[:nA]
+	oursAsk := makers.At(asksBest, m.makerOffers.Side(makers.Ask))
+	askCumBest := make([]*big.Rat, nA)
+	sum := new(big.Rat)
 	for i := 0; i < nA; i++ {
-		p, _ := strconv.ParseFloat(asksBest[i].Price, 64)
-		a, _ := strconv.ParseFloat(asksBest[i].Amount, 64)
-		sum += a * p
+		sum = new(big.Rat).Add(sum, asksBest[i].Total())
 		askCumBest[i] = sum
 	}
 	askMax := 0.0
 	if nA > 0 {
-		askMax = askCumBest[nA-1]
+		askMax = orderbook.Float(askCumBest[nA-1])
 	}
 	// padding blanks (top empty asks)
 	for k := 0; k < padA; k++ {
@@ -951,66 +1452,58 @@ func (m model) renderOrderbook() string {
 		idx := nA - 1 - di // worst -> best
 		a := asksBest[idx]
 		// Price: always use 7 decimals for better granularity
-		pStr := formatAmountWithDecimals(a.Price, 7, priceIntW+1+7)
+		pStr := formatRatWithDecimals(a.Price, 7, priceIntW+1+7)
 		// Amount: use configured baseDecimals
-		amtStr := formatAmountWithDecimals(a.Amount, baseDecimals, amountW)
+		amtStr := formatRatWithDecimals(a.Amount, baseDecimals, amountW)
 		// Total: use configured quoteDecimals
-		cumStr := formatAmountWithDecimals(fmt.Sprintf("%.7f", askCumBest[idx]), quoteDecimals, totalW)
+		cumStr := formatRatWithDecimals(askCumBest[idx], quoteDecimals, totalW)
 		ratio := 0.0
 		if askMax > 0 {
-			ratio = askCumBest[idx] / askMax
+			ratio = orderbook.Float(askCumBest[idx]) / askMax
 		}
 		bar := depthBar(barW, ratio, lipgloss.Color("52")) // red-ish
 		row := lipgloss.JoinHorizontal(lipgloss.Top,
 			padLeftVis(redStyle.Render(pStr), priceW), padRight("", 2),
 			padLeftVis(redStyle.Render(amtStr), amountW), padRight("", 2),
-			padLeftVis(redStyle.Render(cumStr), totalW), padRight("", 2), bar,
+			padLeftVis(redStyle.Render(cumStr), totalW), padRight("", 2), bar, makerMark(oursAsk[idx]),
 		)
 		rows = append(rows, row)
 	}
 
 	// ----- Spread line -----
-	bestBid, bestAsk := "", ""
-	if len(allBids) > 0 {
-		bestBid = allBids[0].Price
-	}
-	if len(allAsks) > 0 {
-		bestAsk = allAsks[0].Price
-	}
-	spreadPct := spreadPercent(bestBid, bestAsk)
+	spreadPct := spreadPercent(m.orderbook)
 	rows = append(rows, dimStyle.Render(fmt.Sprintf("Spread  %s", spreadPct)))
 
 	// ----- BIDS (downwards): render best->worse, then pad missing below -----
 	nB := minInt(len(bids), maxRows)
-	bidCum := make([]float64, nB)
-	sum = 0
+	oursBid := makers.At(bids[:nB], m.makerOffers.Side(makers.Bid))
+	bidCum := make([]*big.Rat, nB)
+	sum = new(big.Rat)
 	for i := 0; i < nB; i++ {
-		p, _ := strconv.ParseFloat(bids[i].Price, 64)
-		a, _ := strconv.ParseFloat(bids[i].Amount, 64)
-		sum += a * p
+		sum = new(big.Rat).Add(sum, bids[i].Total())
 		bidCum[i] = sum
 	}
 	bidMax := 0.0
 	if nB > 0 {
-		bidMax = bidCum[nB-1]
+		bidMax = orderbook.Float(bidCum[nB-1])
 	}
 	for i := 0; i < nB; i++ {
 		b := bids[i]
 		// Price: always use 7 decimals for better granularity
-		pStr := formatAmountWithDecimals(b.Price, 7, priceIntW+1+7)
+		pStr := formatRatWithDecimals(b.Price, 7, priceIntW+1+7)
 		// Amount: use configured baseDecimals
-		amtStr := formatAmountWithDecimals(b.Amount, baseDecimals, amountW)
+		amtStr := formatRatWithDecimals(b.Amount, baseDecimals, amountW)
 		// Total: use configured quoteDecimals
-		cumStr := formatAmountWithDecimals(fmt.Sprintf("%.7f", bidCum[i]), quoteDecimals, totalW)
+		cumStr := formatRatWithDecimals(bidCum[i], quoteDecimals, totalW)
 		ratio := 0.0
 		if bidMax > 0 {
-			ratio = bidCum[i] / bidMax
+			ratio = orderbook.Float(bidCum[i]) / bidMax
 		}
 		bar := depthBar(barW, ratio, lipgloss.Color("24")) // teal-ish
 		row := lipgloss.JoinHorizontal(lipgloss.Top,
 			padLeftVis(greenStyle.Render(pStr), priceW), padRight("", 2),
 			padLeftVis(greenStyle.Render(amtStr), amountW), padRight("", 2),
-			padLeftVis(greenStyle.Render(cumStr), totalW), padRight("", 2), bar,
+			padLeftVis(greenStyle.Render(cumStr), totalW), padRight("", 2), bar, makerMark(oursBid[i]),
 		)
 		rows = append(rows, row)
 	}
@@ -1023,63 +1516,13 @@ func (m model) renderOrderbook() string {
 }
 
 // filterOutlierBids removes bids that are <10% of the best bid or >1000% of the best bid
-func filterOutlierBids(bids []hProtocol.PriceLevel) []hProtocol.PriceLevel {
-	if len(bids) == 0 {
-		return bids
-	}
-
-	// Get best bid (highest price - first in the list)
-	bestBidPrice, err := strconv.ParseFloat(bids[0].Price, 64)
-	if err != nil || bestBidPrice <= 0 {
-		return bids
-	}
-
-	// Filter out bids that are <10% or >1000% of the best bid
-	minBidPrice := bestBidPrice * 0.10  // 10% minimum
-	maxBidPrice := bestBidPrice * 10.0   // 1000% maximum
-	filtered := make([]hProtocol.PriceLevel, 0, len(bids))
-
-	for _, bid := range bids {
-		bidPrice, err := strconv.ParseFloat(bid.Price, 64)
-		if err != nil {
-			continue
-		}
-		// Keep bids that are between 10% and 1000% of best bid
-		if bidPrice >= minBidPrice && bidPrice <= maxBidPrice {
-			filtered = append(filtered, bid)
-		}
-	}
-
-	return filtered
+func filterOutlierBids(bids []orderbook.Level) []orderbook.Level {
+	return orderbook.WithinRatio(bids, big.NewRat(1, 10), big.NewRat(10, 1))
 }
 
 // filterOutlierAsks removes asks that are >1000% (10x) of the best ask
-func filterOutlierAsks(asks []hProtocol.PriceLevel) []hProtocol.PriceLevel {
-	if len(asks) == 0 {
-		return asks
-	}
-
-	// Get best ask (lowest price - first in the list)
-	bestAskPrice, err := strconv.ParseFloat(asks[0].Price, 64)
-	if err != nil || bestAskPrice <= 0 {
-		return asks
-	}
-
-	// Filter out asks that are >1000% (10x) of the best ask
-	maxAskPrice := bestAskPrice * 10.0
-	filtered := make([]hProtocol.PriceLevel, 0, len(asks))
-
-	for _, ask := range asks {
-		askPrice, err := strconv.ParseFloat(ask.Price, 64)
-		if err != nil {
-			continue
-		}
-		if askPrice <= maxAskPrice {
-			filtered = append(filtered, ask)
-		}
-	}
-
-	return filtered
+func filterOutlierAsks(asks []orderbook.Level) []orderbook.Level {
+	return orderbook.WithinRatio(asks, new(big.Rat), big.NewRat(10, 1))
 }
 
 func firstNonEmpty(vals ...string) string {
@@ -1112,6 +1555,20 @@ func prettyJSON(s string) string {
 
 func (m model) renderLiquidity() string {
 	title := boldStyle.Render("LIQUIDITY POOL")
+	if m.lp.FeeBP > 0 || m.lp.Shares != "" {
+		var info []string
+		if m.lp.FeeBP > 0 {
+			info = append(info, fmt.Sprintf("fee %.2f%%", float64(m.lp.FeeBP)/100))
+		}
+		if m.lp.Shares != "" {
+			info = append(info, "shares "+trimLPTo2Decimals(m.lp.Shares))
+		}
+		info = append(info, "via "+lpProvider().Name())
+		if m.lp.Truncated {
+			info = append(info, "trade history capped, volume and fees are low")
+		}
+		title += "  " + dimStyle.Render(strings.Join(info, "  "))
+	}
 	lines := []string{title}
 	if m.lpMessage != "" {
 		lines = append(lines, dimStyle.Render(m.lpMessage))
@@ -1259,8 +1716,19 @@ func (m model) renderTrades() string {
 	limit := 15 // 7 + 7 + 1
 	count := 0
 	now := time.Now().UTC()
-	for i := len(m.trades) - 1; i >= 0 && count < limit; i-- {
-		t := m.trades[i]
+	// newest first, from the live feed or a page of stored history
+	trades := make([]hProtocol.Trade, 0, limit)
+	for i := len(m.trades) - 1; i >= 0 && len(trades) < limit; i-- {
+		trades = append(trades, m.trades[i])
+	}
+	if m.tradeOffset > 0 {
+		rows[0] = boldStyle.Render(fmt.Sprintf("TRADES (history, %d back)", m.tradeOffset))
+		trades = m.tradeHistory
+	}
+	for _, t := range trades {
+		if count >= limit {
+			break
+		}
 		isSell := t.BaseIsSeller
 		
 		// Format price with quote decimals
@@ -1283,187 +1751,153 @@ func (m model) renderTrades() string {
 }
 
 func (m model) midPrice() string {
-	if len(m.orderbook.Bids) == 0 || len(m.orderbook.Asks) == 0 {
-		return ""
-	}
-	bestBid, _ := strconv.ParseFloat(m.orderbook.Bids[0].Price, 64)
-	bestAsk, _ := strconv.ParseFloat(m.orderbook.Asks[0].Price, 64)
-	if bestBid <= 0 || bestAsk <= 0 {
+	return midPriceOf(m.orderbook)
+}
+
+// midPriceOf returns the formatted mid between the best bid and ask of ob
+func midPriceOf(ob orderbook.Book) string {
+	mid := ob.Mid()
+	if mid == nil {
 		return ""
 	}
-	mid := (bestBid + bestAsk) / 2
-	return formatPrice(mid)
+	return trimDecimalsKeepMin2(orderbook.Format(mid, 7))
 }
 
 // Commands
 
-func fetchOrderbookCmd(client *horizonclient.Client, base, quote txnbuild.Asset) tea.Cmd {
+func fetchOrderbookCmd(sc fetchScope, base, quote txnbuild.Asset) tea.Cmd {
 	return func() tea.Msg {
-		if client == nil || base == nil || quote == nil {
-			return errMsg(fmt.Errorf("not configured"))
-		}
-		
-		// Query order book in canonical direction (base -> quote)
-		reqDirect := horizonclient.OrderBookRequest{}
-		applySellingAsset(&reqDirect, base)
-		applyBuyingAsset(&reqDirect, quote)
-		obDirect, err := client.OrderBook(reqDirect)
-		if err != nil {
-			return errMsg(err)
+		ob, err := fetchMergedOrderbook(sc.client, base, quote)
+		if sc.stale() {
+			return nil
 		}
-		
-		// Query order book in reverse direction (quote -> base)
-		reqReverse := horizonclient.OrderBookRequest{}
-		applySellingAsset(&reqReverse, quote)
-		applyBuyingAsset(&reqReverse, base)
-		obReverse, err := client.OrderBook(reqReverse)
 		if err != nil {
 			return errMsg(err)
 		}
-		
-		// Merge the order books:
-		// - Direct asks stay as asks (selling base for quote)
-		// - Reverse bids become bids (selling quote for base = buying base with quote)
-		// - Direct bids stay as bids (buying base with quote)
-		// - Reverse asks become asks (buying quote with base = selling base for quote)
-		
-		merged := hProtocol.OrderBookSummary{
-			Bids:    make([]hProtocol.PriceLevel, 0),
-			Asks:    make([]hProtocol.PriceLevel, 0),
-			Selling: obDirect.Selling,
-			Buying:  obDirect.Buying,
-		}
-		
-		// Add direct asks (people selling base for quote)
-		merged.Asks = append(merged.Asks, obDirect.Asks...)
-		
-		// Add direct bids (people buying base with quote)
-		merged.Bids = append(merged.Bids, obDirect.Bids...)
-		
-		// Convert reverse bids to our asks
-		// Reverse bid: selling quote for base (price in base/quote)
-		// We need: selling base for quote (price in quote/base = 1/price)
-		for _, bid := range obReverse.Bids {
-			price, err := strconv.ParseFloat(bid.Price, 64)
-			if err != nil || price == 0 {
-				continue
-			}
-			// Invert the price: if reverse bid is X base/quote, we want 1/X quote/base
-			invertedPrice := 1.0 / price
-			// Amount needs to be converted too: reverse bid amount is in quote, we need base
-			amount, err := strconv.ParseFloat(bid.Amount, 64)
-			if err != nil {
-				continue
-			}
-			convertedAmount := amount * price // quote * (base/quote) = base
-			
-			merged.Asks = append(merged.Asks, hProtocol.PriceLevel{
-				Price:  fmt.Sprintf("%.7f", invertedPrice),
-				Amount: fmt.Sprintf("%.7f", convertedAmount),
-			})
-		}
-		
-		// Convert reverse asks to our bids
-		// Reverse ask: buying quote with base (price in base/quote)
-		// We need: buying base with quote (price in quote/base = 1/price)
-		for _, ask := range obReverse.Asks {
-			price, err := strconv.ParseFloat(ask.Price, 64)
-			if err != nil || price == 0 {
-				continue
-			}
-			invertedPrice := 1.0 / price
-			amount, err := strconv.ParseFloat(ask.Amount, 64)
-			if err != nil {
-				continue
-			}
-			convertedAmount := amount * price
-			
-			merged.Bids = append(merged.Bids, hProtocol.PriceLevel{
-				Price:  fmt.Sprintf("%.7f", invertedPrice),
-				Amount: fmt.Sprintf("%.7f", convertedAmount),
-			})
-		}
-		
-		return orderbookDataMsg{ob: merged}
+		return orderbookDataMsg{gen: sc.gen, ob: ob}
 	}
 }
 
-func fetchTradesCmd(client *horizonclient.Client, base, quote txnbuild.Asset, cursor string, bootstrap bool) tea.Cmd {
+// fetchMergedOrderbook queries both directions of the pair's book and merges them
+func fetchMergedOrderbook(client *horizonclient.Client, base, quote txnbuild.Asset) (orderbook.Book, error) {
+	if client == nil || base == nil || quote == nil {
+		return orderbook.Book{}, fmt.Errorf("not configured")
+	}
+
+	// Query order book in canonical direction (base -> quote)
+	reqDirect := horizonclient.OrderBookRequest{}
+	applySellingAsset(&reqDirect, base)
+	applyBuyingAsset(&reqDirect, quote)
+	obDirect, err := client.OrderBook(reqDirect)
+	if err != nil {
+		return orderbook.Book{}, err
+	}
+
+	// Query order book in reverse direction (quote -> base)
+	reqReverse := horizonclient.OrderBookRequest{}
+	applySellingAsset(&reqReverse, quote)
+	applyBuyingAsset(&reqReverse, base)
+	obReverse, err := client.OrderBook(reqReverse)
+	if err != nil {
+		return orderbook.Book{}, err
+	}
+
+	return orderbook.Merge(obDirect, obReverse), nil
+}
+
+func fetchTradesCmd(sc fetchScope, base, quote txnbuild.Asset, cursor string, bootstrap bool) tea.Cmd {
 	return func() tea.Msg {
-		if client == nil || base == nil || quote == nil {
-			return errMsg(fmt.Errorf("not configured"))
-		}
-		req := horizonclient.TradeRequest{}
-		applyBaseAsset(&req, base)
-		applyCounterAsset(&req, quote)
-		if cursor == "" {
-			// Bootstrap: get the most recent 50 in descending order
-			req.Limit = 50
-			req.Order = horizonclient.OrderDesc
-		} else {
-			req.Cursor = cursor
-			req.Order = horizonclient.OrderAsc
-			req.Limit = 200
+		recs, err := fetchTradesSince(sc.client, base, quote, cursor)
+		if sc.stale() {
+			return nil
 		}
-		page, err := client.Trades(req)
 		if err != nil {
 			return errMsg(err)
 		}
-		recs := page.Embedded.Records
-		if cursor == "" {
-			// reverse so newest last
-			for i, j := 0, len(recs)-1; i < j; i, j = i+1, j-1 {
-				recs[i], recs[j] = recs[j], recs[i]
-			}
+		return tradesDataMsg{gen: sc.gen, list: recs}
+	}
+}
+
+// fetchTradesSince returns trades after cursor, oldest first. With an empty
+// cursor it returns the most recent 50 trades.
+func fetchTradesSince(client *horizonclient.Client, base, quote txnbuild.Asset, cursor string) ([]hProtocol.Trade, error) {
+	if client == nil || base == nil || quote == nil {
+		return nil, fmt.Errorf("not configured")
+	}
+	req := horizonclient.TradeRequest{}
+	applyBaseAsset(&req, base)
+	applyCounterAsset(&req, quote)
+	if cursor == "" {
+		// Bootstrap: get the most recent 50 in descending order
+		req.Limit = 50
+		req.Order = horizonclient.OrderDesc
+	} else {
+		req.Cursor = cursor
+		req.Order = horizonclient.OrderAsc
+		req.Limit = 200
+	}
+	page, err := client.Trades(req)
+	if err != nil {
+		return nil, err
+	}
+	recs := page.Embedded.Records
+	if cursor == "" {
+		// reverse so newest last
+		for i, j := 0, len(recs)-1; i < j; i, j = i+1, j-1 {
+			recs[i], recs[j] = recs[j], recs[i]
 		}
-		return tradesDataMsg{list: recs}
 	}
+	return recs, nil
 }
 
 func fetchNetworkStatsCmd(client *horizonclient.Client) tea.Cmd {
 	return func() tea.Msg {
-		if client == nil {
-			return networkStatsMsg{capacityUsage: -1}
-		}
-
-		// Fetch fee_stats from Horizon
-		url := client.HorizonURL + "/fee_stats"
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-		if err != nil {
-			log.Printf("Failed to create network stats request: %v", err)
-			return networkStatsMsg{capacityUsage: -1}
-		}
-
-		resp, err := http.DefaultClient.Do(req)
+		capacity, err := fetchNetworkCapacity(ctx, client)
 		if err != nil {
 			log.Printf("Failed to fetch network stats: %v", err)
 			return networkStatsMsg{capacityUsage: -1}
 		}
-		defer resp.Body.Close()
+		return networkStatsMsg{capacityUsage: capacity}
+	}
+}
 
-		if resp.StatusCode != 200 {
-			log.Printf("Network stats HTTP %d", resp.StatusCode)
-			return networkStatsMsg{capacityUsage: -1}
-		}
+// fetchNetworkCapacity returns the ledger capacity usage reported by /fee_stats
+func fetchNetworkCapacity(ctx context.Context, client *horizonclient.Client) (float64, error) {
+	if client == nil {
+		return 0, fmt.Errorf("not configured")
+	}
 
-		var stats FeeStats
-		if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
-			log.Printf("Failed to decode network stats: %v", err)
-			return networkStatsMsg{capacityUsage: -1}
-		}
+	// Fetch fee_stats from Horizon
+	url := client.HorizonURL + "/fee_stats"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
 
-		// Parse ledger_capacity_usage as float
-		capacity, err := strconv.ParseFloat(stats.LedgerCapacityUsage, 64)
-		if err != nil {
-			log.Printf("Failed to parse capacity usage: %v", err)
-			return networkStatsMsg{capacityUsage: -1}
-		}
+	// through the client's transport, so the endpoint pool fails it over
+	var doer horizonclient.HTTP = http.DefaultClient
+	if client.HTTP != nil {
+		doer = client.HTTP
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
 
-		return networkStatsMsg{capacityUsage: capacity}
+	if resp.StatusCode != 200 {
+		return 0, fmt.Errorf("network stats http %d", resp.StatusCode)
+	}
+
+	var stats FeeStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return 0, err
 	}
+
+	// Parse ledger_capacity_usage as float
+	return strconv.ParseFloat(stats.LedgerCapacityUsage, 64)
 }
 
 // Helpers
@@ -1527,32 +1961,55 @@ func applyCounterAsset(req *horizonclient.TradeRequest, a txnbuild.Asset) {
 }
 
 func currentPairIndex(base, quote txnbuild.Asset) int {
-	bc := assetShort(base)
-	qc := assetShort(quote)
+	bn, qn := getAssetName(base), getAssetName(quote)
 	for i, p := range configuredPairs {
-		if p.Base == bc && p.Quote == qc {
+		pb, pq, ok := p.assets()
+		if ok && getAssetName(pb) == bn && getAssetName(pq) == qn {
 			return i
 		}
 	}
 	return 0
 }
 
-// loadConfiguration loads the YAML config and converts it to internal format
+// loadConfiguration loads the YAML config, selects the network profile and
+// converts the network's pairs to internal format. The curated pairs and pool
+// IDs are pubnet assets and only serve as a fallback there.
 func loadConfiguration() error {
-	var err error
-	appConfig, err = config.LoadConfig()
+	// a legacy file migrated on load keeps the curated pairs it showed
+	config.SetDefaultPairs(curatedConfigPairs())
+	cfg, err := config.LoadConfig()
 	if err != nil {
 		// Use fallback data
-		configuredPairs = curatedPairs
-		liquidityPoolIDs = fallbackLiquidityPoolIDs
+		appConfig = nil
+		if nerr := selectNetwork(nil); nerr != nil {
+			return nerr
+		}
+		configuredPairs = nil
+		liquidityPoolIDs = map[string]string{}
+		if onPubnet() {
+			configuredPairs = curatedPairs
+			liquidityPoolIDs = fallbackLiquidityPoolIDs
+		}
+		poolResolver.SetOverrides(poolOverrides())
+		return err
+	}
+	return applyConfiguration(cfg)
+}
+
+// applyConfiguration makes cfg the loaded config, as loadConfiguration does
+// once the file has been read
+func applyConfiguration(cfg *config.Config) error {
+	appConfig = cfg
+	if err := selectNetwork(appConfig); err != nil {
 		return err
 	}
 
 	// Convert YAML pairs to internal pairOption format
-	configuredPairs = make([]pairOption, 0, len(appConfig.Pairs))
+	pairs := appConfig.ActivePairs()
+	configuredPairs = make([]pairOption, 0, len(pairs))
 	liquidityPoolIDs = make(map[string]string)
 	
-	for _, pair := range appConfig.Pairs {
+	for _, pair := range pairs {
 		// Parse base and quote assets from YAML format
 		base, err := config.ParseAsset(pair.Base)
 		if err != nil {
@@ -1567,8 +2024,11 @@ func loadConfiguration() error {
 		
 		// Add to configured pairs
 		configuredPairs = append(configuredPairs, pairOption{
-			Base:  assetShort(base),
-			Quote: assetShort(quote),
+			Base:       assetShort(base),
+			Quote:      assetShort(quote),
+			Name:       pair.Name,
+			BaseAsset:  base,
+			QuoteAsset: quote,
 		})
 		
 		// Add LP mapping if present
@@ -1583,21 +2043,76 @@ func loadConfiguration() error {
 		}
 	}
 	
-	// Add fallback pairs if no configured pairs loaded
-	if len(configuredPairs) == 0 {
-		configuredPairs = curatedPairs
-	}
-	
-	// Add fallback LP IDs for any missing ones
-	for key, poolID := range fallbackLiquidityPoolIDs {
-		if liquidityPoolIDs[key] == "" {
-			liquidityPoolIDs[key] = poolID
+	if onPubnet() {
+		// Add fallback pairs if no configured pairs loaded
+		if len(configuredPairs) == 0 {
+			configuredPairs = curatedPairs
+		}
+		
+		// Add fallback LP IDs for any missing ones
+		for key, poolID := range fallbackLiquidityPoolIDs {
+			if liquidityPoolIDs[key] == "" {
+				liquidityPoolIDs[key] = poolID
+			}
 		}
 	}
+	poolResolver.SetOverrides(poolOverrides())
 	
 	return nil
 }
 
+// mustLoadConfiguration loads the config for startup. A missing or broken
+// config falls back to the curated data, an unusable network does not.
+func mustLoadConfiguration() {
+	err := loadConfiguration()
+	if errors.Is(err, config.ErrInvalidNetwork) {
+		fmt.Fprintf(os.Stderr, "sdexmon: %v\n", err)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to load config: %v, using fallback data", err)
+	}
+}
+
+// networkFlag is the profile named by --network or SDEXMON_NETWORK, empty
+// for the config's default
+var networkFlag string
+
+// selectNetwork activates the profile named by networkFlag
+func selectNetwork(cfg *config.Config) error {
+	n, err := cfg.Network(networkFlag)
+	if err != nil {
+		return err
+	}
+	config.SetActiveNetwork(n)
+	return nil
+}
+
+func onPubnet() bool {
+	return config.ActiveNetwork().Name == config.DefaultNetwork
+}
+
+// extractNetworkFlag removes "--network NAME" or "--network=NAME" from args,
+// wherever it appears, and returns the name
+func extractNetworkFlag(args []string) (string, []string) {
+	name := ""
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--network" || a == "-network":
+			if i+1 < len(args) {
+				name = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(a, "--network="), strings.HasPrefix(a, "-network="):
+			name = a[strings.Index(a, "=")+1:]
+		default:
+			out = append(out, a)
+		}
+	}
+	return name, out
+}
 
 // formatAssetForYAML converts asset code and issuer to YAML format
 // formatAssetForYAML converts asset code and issuer to YAML format
@@ -1647,6 +2162,18 @@ func formatAmountWithDecimals(s string, decimals int, minWidth int) string {
 	return formatted
 }
 
+// formatRatWithDecimals rounds an exact value to decimals and pads it
+func formatRatWithDecimals(r *big.Rat, decimals int, minWidth int) string {
+	formatted := orderbook.Format(r, decimals)
+	if formatted == "" {
+		formatted = orderbook.Format(new(big.Rat), decimals)
+	}
+	if minWidth > 0 && len(formatted) < minWidth {
+		formatted = strings.Repeat(" ", minWidth-len(formatted)) + formatted
+	}
+	return formatted
+}
+
 // formatPriceWithDecimals formats a price with specific decimal places
 func formatPriceWithDecimals(f float64, decimals int) string {
 	return strconv.FormatFloat(f, 'f', decimals, 64)
@@ -1871,11 +2398,11 @@ func alignDecimalFixed(s string, intW, fracW int) string {
 	return intp + "." + frac
 }
 
-func maxPriceIntWidth(bids []hProtocol.PriceLevel, asks []hProtocol.PriceLevel, maxRows int) int {
+func maxPriceIntWidth(bids []orderbook.Level, asks []orderbook.Level, maxRows int) int {
 	maxW := 1
 	// bids best->worse
 	for i := 0; i < len(bids) && i < maxRows; i++ {
-		s := toFixedDecimals(bids[i].Price, 7)
+		s := orderbook.Format(bids[i].Price, 7)
 		parts := strings.SplitN(s, ".", 2)
 		if len(parts[0]) > maxW {
 			maxW = len(parts[0])
@@ -1883,7 +2410,7 @@ func maxPriceIntWidth(bids []hProtocol.PriceLevel, asks []hProtocol.PriceLevel,
 	}
 	// asks displayed from worst->best (iterate reverse)
 	for c, i := 0, len(asks)-1; i >= 0 && c < maxRows; i, c = i-1, c+1 {
-		s := toFixedDecimals(asks[i].Price, 7)
+		s := orderbook.Format(asks[i].Price, 7)
 		parts := strings.SplitN(s, ".", 2)
 		if len(parts[0]) > maxW {
 			maxW = len(parts[0])
@@ -1892,20 +2419,13 @@ func maxPriceIntWidth(bids []hProtocol.PriceLevel, asks []hProtocol.PriceLevel,
 	return maxW
 }
 
-func spreadPercent(bestBid, bestAsk string) string {
-	if bestBid == "" || bestAsk == "" {
-		return "-"
-	}
-	bb, err1 := strconv.ParseFloat(bestBid, 64)
-	ba, err2 := strconv.ParseFloat(bestAsk, 64)
-	if err1 != nil || err2 != nil || bb <= 0 || ba <= 0 {
+// spreadPercent formats the book's spread relative to mid, "-" when unknown
+func spreadPercent(ob orderbook.Book) string {
+	sp := ob.SpreadPercent()
+	if sp == nil {
 		return "-"
 	}
-	sp := (ba - bb) / ((ba + bb) / 2) * 100
-	if sp < 0 {
-		sp = 0
-	}
-	return trimDecimalsKeepMin2(strconv.FormatFloat(sp, 'f', 4, 64)) + "%"
+	return trimDecimalsKeepMin2(orderbook.Format(sp, 4)) + "%"
 }
 
 // Reusable UI components
@@ -1929,6 +2449,7 @@ func renderFooter(shortcuts string, networkCapacity float64) string {
 		pct := networkCapacity * 100
 		statusText = fmt.Sprintf("Network Usage: %.0f%% ", pct)
 	}
+	statusText = "[" + strings.ToUpper(config.ActiveNetwork().Name) + "] " + activeHorizonLabel() + "  " + statusText
 
 	w := 140 // fixed width
 	leftText := shortcuts
@@ -1950,29 +2471,49 @@ func (m model) bottomLine() string {
 			if m.searchMode {
 				shortcuts = "↑/↓: navigate  enter: select  esc: exit search  q: quit"
 			} else {
-				shortcuts = "↑/↓: navigate  enter: select  s: search  esc: close  q: quit"
+				shortcuts = "↑/↓: navigate  enter: select  s: search  m: manage  esc: close  q: quit"
 			}
 		} else {
-			shortcuts = "enter: pairs  q: quit"
+			shortcuts = "enter: pairs  w: overview  g: pegs  a: arbitrage  l: sla  s: issuers  !: alerts  m: manage pairs  q: quit"
 		}
 	case screenPairInfo:
 		if m.showPairPopup {
 			if m.searchMode {
 				shortcuts = "↑/↓: navigate  enter: select  esc: exit search  q: quit"
 			} else {
-				shortcuts = "↑/↓: navigate  enter: select  s: search  esc: close  q: quit"
+				shortcuts = "↑/↓: navigate  enter: select  s: search  m: manage  esc: close  q: quit"
 			}
 		} else {
-			shortcuts = "p: pairs  d: detail  q: quit"
+			shortcuts = "p: pairs  c: chart  i: impact  o: paths  [/]: history  v: vol  u: ours  t: stats  w/g/a/l/s/!: all/pegs/arb/sla/issuers/alerts  d: detail  m: manage  q: quit"
+			if m.showChart {
+				shortcuts = "p: pairs  c: hide chart  r: resolution  i: impact  o: paths  [/]: history  v: vol  u: ours  t: stats  w/g/a/l/s/!: all/pegs/arb/sla/issuers/alerts  d: detail  m: manage  q: quit"
+			}
+			if m.showImpact {
+				shortcuts = "type size  tab: buy/sell  esc: close impact  ctrl+c: quit"
+			} else if m.showPaths {
+				shortcuts = "type amount  enter: quote  tab: send/receive  esc: close paths  ctrl+c: quit"
+			}
 		}
+	case screenOverview:
+		shortcuts = "↑/↓: navigate  enter: open pair  1-8: sort (again to reverse)  r: refresh  esc: back  q: quit"
+	case screenPeg, screenArb:
+		shortcuts = "r: refresh  esc: back  q: quit"
+	case screenAlerts:
+		shortcuts = "↑/↓: navigate  enter: acknowledge  A: acknowledge all  esc: back  q: quit"
+	case screenSLA:
+		shortcuts = "1-4: 1h/24h/7d/30d  r: refresh  esc: back  q: quit"
+	case screenIssuers:
+		shortcuts = "esc: back  q: quit"
 	case screenPairDebug:
 		shortcuts = "d: back  q: quit"
 	case screenPairInput:
 		shortcuts = "enter: apply  tab: switch field  esc: back  q: quit"
+	case screenMaintenance:
+		shortcuts = maintenanceShortcuts(m.maintenanceState)
 	default:
 		shortcuts = "q: quit"
 	}
-	return renderFooter(shortcuts, m.networkCapacity)
+	return renderFooter(m.alertBadge()+shortcuts, m.networkCapacity)
 }
 
 func humanElapsedShort(d time.Duration) string {
@@ -2111,10 +2652,9 @@ func pairDebugView(m model) string {
 	if quoteStr == "native" {
 		quoteStr = "XLM:native"
 	}
-	pairKey := fmt.Sprintf("%s-%s", assetShort(m.base), assetShort(m.quote))
-	lpID, found := liquidityPoolIDs[pairKey]
-	if !found {
-		lpID = "(not found)"
+	lpID := m.lpPoolID
+	if lpID == "" {
+		lpID = firstNonEmpty(m.lpMessage, "(not found)")
 	}
 
 	// Create markdown table
@@ -2126,7 +2666,7 @@ func pairDebugView(m model) string {
 | Base Asset | %s |
 | Counter Asset | %s |
 | LP Pool ID | %s |
-`, pair, baseStr, quoteStr, lpID)
+`, pair, baseStr, quoteStr, lpID) + horizonHealthMarkdown()
 
 	// Render with Glamour
 	r, err := glamour.NewTermRenderer(
@@ -2205,18 +2745,6 @@ func pairDebugView(m model) string {
 }
 
 
-func horizonURL() string {
-	if v := os.Getenv("HORIZON_URL"); v != "" {
-		return v
-	}
-	// Default to public Stellar Horizon mainnet
-	return "https://horizon.stellar.org"
-}
-
-func newClient() *horizonclient.Client {
-	return &horizonclient.Client{HorizonURL: horizonURL()}
-}
-
 // ----- Liquidity fetch -----
 
 const defaultPoolID = "7001fca2d71456cda8a061e4733f035fce36423ccf942e92db139a116d7e557b"
@@ -2242,6 +2770,18 @@ type Liquidity struct {
 	Fees7d   [2]string
 	Vol1d    [2]string
 	Vol7d    [2]string
+	Shares   string // total pool shares, formatted like Locked
+	FeeBP    int    // pool fee in basis points, 0 when unknown
+
+	// Exact amounts behind the display strings, in whole units; nil when
+	// the provider did not report them
+	LockedR [2]*big.Rat
+	Fees1dR [2]*big.Rat
+	Fees7dR [2]*big.Rat
+	Vol1dR  [2]*big.Rat
+	Vol7dR  [2]*big.Rat
+
+	Truncated bool // volume and fees miss trades beyond the provider's paging cap
 }
 
 type lpAPIResponse struct {
@@ -2265,41 +2805,92 @@ type lpAPIResponse struct {
 		D1    json.RawMessage `json:"1d"`
 		D7    json.RawMessage `json:"7d"`
 	} `json:"volume"`
-	Updated int64 `json:"updated"`
+	Shares  json.RawMessage `json:"shares"`
+	Fee     int             `json:"fee"`
+	Updated int64           `json:"updated"`
 }
 
-func resolveAndFetchLPCmd(client *horizonclient.Client, base, quote txnbuild.Asset) tea.Cmd {
+func resolveAndFetchLPCmd(sc fetchScope, base, quote txnbuild.Asset) tea.Cmd {
 	return func() tea.Msg {
-		// Allow override
-		if override := os.Getenv("LP_POOL_ID"); override != "" {
-			if data, err := fetchLPByID(override); err == nil {
-				return lpDataMsg{data: data}
-			} else {
-				return lpNoteMsg(fmt.Sprintf("Error loading pool: %v", err))
-			}
+		poolID, note := resolvePoolID(sc.client, base, quote)
+		if sc.stale() {
+			return nil
 		}
-		if base == nil || quote == nil {
-			return lpNoteMsg("No pool: not configured")
+		if poolID == "" {
+			return lpNoteMsg{gen: sc.gen, note: note}
 		}
 
-		// Look up pool ID from our predefined map
-		pairKey := fmt.Sprintf("%s-%s", assetShort(base), assetShort(quote))
-		poolID, found := liquidityPoolIDs[pairKey]
-		if !found {
-			return lpNoteMsg("No pool for " + pairKey)
+		data, err := fetchLP(sc.ctx, sc.client, poolID)
+		if sc.stale() {
+			return nil
 		}
-
-		data, err := fetchLPByID(poolID)
 		if err != nil {
-			return lpNoteMsg(fmt.Sprintf("Pool fetch error: %v", err))
+			return lpNoteMsg{gen: sc.gen, note: fmt.Sprintf("Pool fetch error: %v", err)}
 		}
-		return lpDataMsg{data: data}
+		return lpDataMsg{gen: sc.gen, poolID: poolID, data: data}
 	}
 }
 
-func fetchLPByID(poolID string) (Liquidity, error) {
-	url := "https://api.stellar.expert/explorer/public/liquidity-pool/" + poolID
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// resolvePoolID returns the liquidity pool ID for the pair, honouring the
+// LP_POOL_ID override. Pools are discovered through Horizon; configured IDs
+// only override that. When there is no pool it returns a note explaining why.
+func resolvePoolID(client *horizonclient.Client, base, quote txnbuild.Asset) (string, string) {
+	// Allow override
+	if override := os.Getenv("LP_POOL_ID"); override != "" {
+		return override, ""
+	}
+	if base == nil || quote == nil {
+		return "", "No pool: not configured"
+	}
+
+	poolID, err := poolResolver.Resolve(client, base, quote)
+	switch {
+	case errors.Is(err, pools.ErrNoPool):
+		return "", fmt.Sprintf("No pool for %s-%s", assetShort(base), assetShort(quote))
+	case err != nil:
+		return "", fmt.Sprintf("Pool lookup error: %v", err)
+	}
+	return poolID, ""
+}
+
+// poolOverrides collects the configured pool IDs, from the active network's
+// lp fields and, on pubnet, the curated fallback table, keyed for the pool
+// resolver
+func poolOverrides() map[string]string {
+	overrides := map[string]string{}
+	if onPubnet() {
+		for key, poolID := range fallbackLiquidityPoolIDs {
+			codes := strings.SplitN(key, "-", 2)
+			base, ok1 := curatedAssets[codes[0]]
+			quote, ok2 := curatedAssets[codes[1]]
+			if ok1 && ok2 {
+				overrides[pools.Key(base, quote)] = poolID
+			}
+		}
+	}
+	if appConfig == nil {
+		return overrides
+	}
+	for _, pair := range appConfig.ActivePairs() {
+		if pair.LP == "" {
+			continue
+		}
+		base, err1 := config.ParseAsset(pair.Base)
+		quote, err2 := config.ParseAsset(pair.Quote)
+		if err1 == nil && err2 == nil {
+			overrides[pools.Key(base, quote)] = pair.LP
+		}
+	}
+	return overrides
+}
+
+func fetchLPByID(parent context.Context, poolID string) (Liquidity, error) {
+	explorer := config.ExplorerURL()
+	if explorer == "" {
+		return Liquidity{}, fmt.Errorf("stellar.expert does not cover the %s network", config.ActiveNetwork().Name)
+	}
+	url := explorer + "/liquidity-pool/" + poolID
+	ctx, cancel := context.WithTimeout(parent, 10*time.Second)
 	defer cancel()
 	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	resp, err := http.DefaultClient.Do(req)
@@ -2329,6 +2920,7 @@ func fetchLPByID(poolID string) (Liquidity, error) {
 		}
 		// stellar.expert returns amounts in stroops (always 7 decimals)
 		data.Locked[i] = formatLPAmount(api.Assets[i].Amount)
+		data.LockedR[i] = stroopsRat(api.Assets[i].Amount)
 	}
 	for _, ef := range api.EarnedFees {
 		code := strings.Split(ef.Asset, "-")[0]
@@ -2336,6 +2928,8 @@ func fetchLPByID(poolID string) (Liquidity, error) {
 		if idx >= 0 {
 			data.Fees1d[idx] = parseFlexNumberWithDecimals(ef.D1, data.Decimals[idx])
 			data.Fees7d[idx] = parseFlexNumberWithDecimals(ef.D7, data.Decimals[idx])
+			data.Fees1dR[idx] = parseFlexStroops(ef.D1)
+			data.Fees7dR[idx] = parseFlexStroops(ef.D7)
 		}
 	}
 	for _, v := range api.Volume {
@@ -2344,8 +2938,14 @@ func fetchLPByID(poolID string) (Liquidity, error) {
 		if idx >= 0 {
 			data.Vol1d[idx] = parseFlexNumberWithDecimals(v.D1, data.Decimals[idx])
 			data.Vol7d[idx] = parseFlexNumberWithDecimals(v.D7, data.Decimals[idx])
+			data.Vol1dR[idx] = parseFlexStroops(v.D1)
+			data.Vol7dR[idx] = parseFlexStroops(v.D7)
 		}
 	}
+	if len(api.Shares) > 0 {
+		data.Shares = parseFlexNumberWithDecimals(api.Shares, 7)
+	}
+	data.FeeBP = api.Fee
 	return data, nil
 }
 
@@ -2373,6 +2973,30 @@ func parseFlexNumberWithDecimals(raw json.RawMessage, decimals int) string {
 	return "0.00"
 }
 
+// parseFlexStroops reads a stellar.expert stroop amount sent either as a
+// number or a string, nil when it is neither
+func parseFlexStroops(raw json.RawMessage) *big.Rat {
+	var intVal int64
+	if err := json.Unmarshal(raw, &intVal); err == nil {
+		return big.NewRat(intVal, 10000000)
+	}
+	var strVal string
+	if err := json.Unmarshal(raw, &strVal); err == nil {
+		return stroopsRat(strVal)
+	}
+	return nil
+}
+
+// stroopsRat converts a stroop count to whole units, nil when s is not a
+// number
+func stroopsRat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return nil
+	}
+	return r.Quo(r, big.NewRat(10000000, 1))
+}
+
 func trimLPTo2Decimals(s string) string {
 	// Trim a formatted LP amount to 2 decimals
 	// Input format: "8 927 467.4437965" or similar
@@ -2590,29 +3214,35 @@ func getDebugLogs() []string {
 }
 
 // fetchBaseExposureCmd fetches all liquidity pools containing the base asset
-func fetchBaseExposureCmd(client *horizonclient.Client, asset txnbuild.Asset) tea.Cmd {
+func fetchBaseExposureCmd(sc fetchScope, asset txnbuild.Asset) tea.Cmd {
 	return func() tea.Msg {
 		if asset == nil {
-			return baseExposureDataMsg{pools: []Liquidity{}}
+			return baseExposureDataMsg{gen: sc.gen, pools: []Liquidity{}}
+		}
+		pools := fetchExposurePools(sc.ctx, sc.client, asset)
+		if sc.stale() {
+			return nil
 		}
-		pools := fetchExposurePools(asset)
-		return baseExposureDataMsg{pools: pools}
+		return baseExposureDataMsg{gen: sc.gen, pools: pools}
 	}
 }
 
 // fetchQuoteExposureCmd fetches all liquidity pools containing the quote asset
-func fetchQuoteExposureCmd(client *horizonclient.Client, asset txnbuild.Asset) tea.Cmd {
+func fetchQuoteExposureCmd(sc fetchScope, asset txnbuild.Asset) tea.Cmd {
 	return func() tea.Msg {
 		if asset == nil {
-			return quoteExposureDataMsg{pools: []Liquidity{}}
+			return quoteExposureDataMsg{gen: sc.gen, pools: []Liquidity{}}
+		}
+		pools := fetchExposurePools(sc.ctx, sc.client, asset)
+		if sc.stale() {
+			return nil
 		}
-		pools := fetchExposurePools(asset)
-		return quoteExposureDataMsg{pools: pools}
+		return quoteExposureDataMsg{gen: sc.gen, pools: pools}
 	}
 }
 
 // fetchExposurePools is the shared logic for fetching exposure pools
-func fetchExposurePools(asset txnbuild.Asset) []Liquidity {
+func fetchExposurePools(ctx context.Context, client *horizonclient.Client, asset txnbuild.Asset) []Liquidity {
 	assetCode := assetShort(asset)
 	var poolIDs []string
 
@@ -2640,7 +3270,10 @@ func fetchExposurePools(asset txnbuild.Asset) []Liquidity {
 	// Fetch all pools
 	var pools []Liquidity
 	for _, poolID := range poolIDs {
-		data, err := fetchLPByID(poolID)
+		if ctx.Err() != nil {
+			break
+		}
+		data, err := fetchLP(ctx, client, poolID)
 		if err != nil {
 			log.Printf("Failed to fetch pool %s: %v", poolID, err)
 			continue
@@ -2688,7 +3321,7 @@ func fetchExposureCmd(client *horizonclient.Client, asset txnbuild.Asset) tea.Cm
 		// Fetch all pools
 		var pools []Liquidity
 		for _, poolID := range poolIDs {
-			data, err := fetchLPByID(poolID)
+			data, err := fetchLP(context.Background(), client, poolID)
 			if err != nil {
 				// Log error but continue with other pools
 				log.Printf("Failed to fetch pool %s: %v", poolID, err)
@@ -2702,12 +3335,41 @@ func fetchExposureCmd(client *horizonclient.Client, asset txnbuild.Asset) tea.Cm
 }
 
 func main() {
+	networkFlag, os.Args = extractNetworkFlag(os.Args)
+	if networkFlag == "" {
+		networkFlag = os.Getenv("SDEXMON_NETWORK")
+	}
+
 	// Set git commit from build-time variable if available
 	if len(os.Args) > 1 && os.Args[1] == "--version" {
 		fmt.Printf("%s (build %s)\n", appVersion, gitCommit)
 		os.Exit(0)
 	}
 
+	// Headless subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "snapshot":
+			mustLoadConfiguration()
+			os.Exit(runSnapshot(os.Args[2:]))
+		case "serve":
+			mustLoadConfiguration()
+			os.Exit(runServe(os.Args[2:]))
+		case "record":
+			mustLoadConfiguration()
+			os.Exit(runRecord(os.Args[2:]))
+		case "replay":
+			mustLoadConfiguration()
+			os.Exit(runReplay(os.Args[2:]))
+		case "sla":
+			mustLoadConfiguration()
+			os.Exit(runSLA(os.Args[2:]))
+		}
+	}
+
+	// Load configuration from YAML
+	mustLoadConfiguration()
+
 	// Check for updates before starting
 	fmt.Println("Checking for updates...")
 	updateRequired, latestVersion, _, err := version.CheckForUpdate(appVersion)
@@ -2716,13 +3378,11 @@ func main() {
 		// Continue anyway - don't block startup on network issues
 	}
 
-	// Load configuration from YAML
-	if err := loadConfiguration(); err != nil {
-		log.Printf("Warning: Failed to load config: %v, using fallback data", err)
-	}
-
 	log.SetFlags(log.Ltime | log.Lmicroseconds)
 	client := newClient()
+	defer openTradeStore(client)()
+	openSLAStore()
+	openStatsStore()
 
 	// optional defaults via env
 	var base, quote txnbuild.Asset
@@ -2742,6 +3402,7 @@ func main() {
 	}
 
 	m := initialModel(client, base, quote)
+	m.stream = newMarketStreamer(client)
 	if updateRequired {
 		m.updateRequired = true
 		m.latestVersion = latestVersion
@@ -2749,6 +3410,7 @@ func main() {
 	}
 
 	p := tea.NewProgram(m, tea.WithAltScreen())
+	m.stream.send = p.Send
 	if _, err := p.Run(); err != nil {
 		log.Fatal(err)
 	}
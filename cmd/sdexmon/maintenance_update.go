@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -23,11 +24,82 @@ func initMaintenanceState() models.MaintenanceState {
 	domainB.Prompt = "Domain > "
 	domainB.CharLimit = 100
 
+	label := textinput.New()
+	label.Prompt = "Label > "
+	label.CharLimit = 40
+
 	return models.MaintenanceState{
 		Screen:       models.MaintenanceMenu,
 		DomainInputA: domainA,
 		DomainInputB: domainB,
+		LabelInput:   label,
+	}
+}
+
+// openMaintenance enters maintenance mode, remembering the screen to return to
+func (m model) openMaintenance() (model, tea.Cmd) {
+	m.maintenanceFrom = m.currentScreen
+	m.currentScreen = screenMaintenance
+	m.maintenanceState = initMaintenanceState()
+	return m, nil
+}
+
+// closeMaintenance leaves maintenance mode for the screen it was opened from
+func (m model) closeMaintenance() model {
+	m.currentScreen = m.maintenanceFrom
+	if m.currentScreen == screenPairInfo && (m.base == nil || m.quote == nil) {
+		m.currentScreen = screenLanding
+	}
+	m.maintenanceState = initMaintenanceState()
+	return m
+}
+
+// editablePairs returns the pairs the pair manager works on. A pubnet config
+// without pairs shows the curated pairs the selector falls back to, so the
+// first edit does not make them disappear.
+func editablePairs() []config.Pair {
+	if appConfig == nil {
+		return nil
+	}
+	if !onPubnet() || len(appConfig.Pairs) > 0 {
+		return appConfig.ActivePairs()
+	}
+	return curatedConfigPairs()
+}
+
+// curatedConfigPairs lists the curated pairs as config pairs
+func curatedConfigPairs() []config.Pair {
+	var pairs []config.Pair
+	for _, p := range curatedPairs {
+		base, quote, ok := p.assets()
+		if !ok {
+			continue
+		}
+		pairs = append(pairs, config.Pair{
+			Name:  p.label(),
+			Base:  getAssetName(base),
+			Quote: getAssetName(quote),
+			LP:    fallbackLiquidityPoolIDs[p.Base+"-"+p.Quote],
+		})
+	}
+	return pairs
+}
+
+// editableConfig returns a copy of the loaded config holding editablePairs.
+// Edits go to the copy, which only replaces the loaded config once it has
+// been saved.
+func editableConfig() (*config.Config, error) {
+	if appConfig == nil {
+		return nil, fmt.Errorf("config could not be loaded from %s", config.GetConfigPath())
+	}
+	cfg, err := appConfig.Clone()
+	if err != nil {
+		return nil, err
 	}
+	if onPubnet() && len(cfg.Pairs) == 0 {
+		cfg.Pairs = editablePairs()
+	}
+	return cfg, nil
 }
 
 func handleMaintenanceUpdate(m model, msg tea.Msg) (model, tea.Cmd) {
@@ -46,6 +118,10 @@ func handleMaintenanceUpdate(m model, msg tea.Msg) (model, tea.Cmd) {
 			return handleAssetBSelectionKeys(m, msg)
 		case models.PairConfirmation:
 			return handleConfirmationKeys(m, msg)
+		case models.ManagePairs:
+			return handleManagePairsKeys(m, msg)
+		case models.EditPairLabel:
+			return handleEditPairLabelKeys(m, msg)
 		}
 
 	case models.AssetSearchResultsMsg:
@@ -80,8 +156,17 @@ func handleMaintenanceUpdate(m model, msg tea.Msg) (model, tea.Cmd) {
 func handleMaintenanceMenuKeys(m model, msg tea.KeyMsg) (model, tea.Cmd) {
 	switch msg.String() {
 	case "esc", "q":
-		m.currentScreen = screenLanding
-		m.maintenanceState = initMaintenanceState()
+		return m.closeMaintenance(), nil
+	case "2":
+		// Manage configured pairs
+		if appConfig == nil {
+			m.maintenanceState.ErrorMessage = fmt.Sprintf("config could not be loaded from %s", config.GetConfigPath())
+			return m, nil
+		}
+		m.maintenanceState.Screen = models.ManagePairs
+		m.maintenanceState.PairCursor = 0
+		m.maintenanceState.ConfirmRemove = false
+		m.maintenanceState.ErrorMessage = ""
 		return m, nil
 	case "1":
 		// Start add asset pair flow
@@ -217,29 +302,126 @@ func handleConfirmationKeys(m model, msg tea.KeyMsg) (model, tea.Cmd) {
 		m.maintenanceState.ErrorMessage = ""
 		return m, nil
 	case "enter":
-		if m.maintenanceState.ConfirmationData == nil {
+		if m.maintenanceState.ConfirmationData == nil || m.maintenanceState.LoadingMessage != "" {
 			return m, nil
 		}
 
-		// Save the pair to config
+		// Save the pair to config; maintenance closes once it is saved
 		cd := m.maintenanceState.ConfirmationData
-		err := config.AddCustomPair(cd.AssetA, cd.AssetB)
+		cfg, err := editableConfig()
 		if err != nil {
 			m.maintenanceState.ErrorMessage = fmt.Sprintf("Failed to save: %v", err)
 			return m, nil
 		}
+		m.maintenanceState.LoadingMessage = "Saving..."
+		m.maintenanceState.ErrorMessage = ""
+		note := fmt.Sprintf("Added pair %s/%s", cd.AssetA.GetCode(), cd.AssetB.GetCode())
+		return m, saveConfigCmd(cfg, func(c *config.Config) error {
+			return config.AddCustomPair(c, cd.AssetA, cd.AssetB)
+		}, note, 0)
+	}
+	return m, nil
+}
 
-		// Success! Reload config and return to landing
-		m.maintenanceState = initMaintenanceState()
-		m.currentScreen = screenLanding
-		m.status = fmt.Sprintf("Added pair %s/%s", cd.AssetA.GetCode(), cd.AssetB.GetCode())
+func handleManagePairsKeys(m model, msg tea.KeyMsg) (model, tea.Cmd) {
+	st := &m.maintenanceState
+	pairs := editablePairs()
 
-		// Reload configured pairs to include the new pair
-		return m, reloadConfigCmd()
+	// A pending removal is confirmed with y; any other key cancels it
+	if st.ConfirmRemove {
+		st.ConfirmRemove = false
+		if msg.String() != "y" {
+			st.StatusMessage = "Removal cancelled"
+			return m, nil
+		}
+		index := st.PairCursor
+		cursor := index
+		if cursor >= len(pairs)-1 {
+			cursor = max(0, len(pairs)-2)
+		}
+		return m.savePairEdit(func(c *config.Config) error {
+			return config.RemovePair(c, index)
+		}, fmt.Sprintf("Removed %s", pairs[index].Name), cursor)
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		st.Screen = models.MaintenanceMenu
+		st.ErrorMessage = ""
+		st.StatusMessage = ""
+		return m, nil
+	case "up", "k":
+		if st.PairCursor > 0 {
+			st.PairCursor--
+		}
+		return m, nil
+	case "down", "j":
+		if st.PairCursor < len(pairs)-1 {
+			st.PairCursor++
+		}
+		return m, nil
+	case "K", "shift+up", "J", "shift+down":
+		delta := 1
+		if msg.String() == "K" || msg.String() == "shift+up" {
+			delta = -1
+		}
+		from, to := st.PairCursor, st.PairCursor+delta
+		if to < 0 || to >= len(pairs) {
+			return m, nil
+		}
+		return m.savePairEdit(func(c *config.Config) error {
+			return config.MovePair(c, from, delta)
+		}, "", to)
+	case "x", "delete":
+		if len(pairs) == 0 || st.LoadingMessage != "" {
+			return m, nil
+		}
+		st.ConfirmRemove = true
+		st.ErrorMessage = ""
+		st.StatusMessage = ""
+		return m, nil
+	case "e", "enter":
+		if len(pairs) == 0 || st.LoadingMessage != "" {
+			return m, nil
+		}
+		st.LabelInput.SetValue(pairs[st.PairCursor].Name)
+		st.LabelInput.CursorEnd()
+		st.LabelInput.Focus()
+		st.Screen = models.EditPairLabel
+		st.ErrorMessage = ""
+		st.StatusMessage = ""
+		return m, nil
 	}
 	return m, nil
 }
 
+func handleEditPairLabelKeys(m model, msg tea.KeyMsg) (model, tea.Cmd) {
+	st := &m.maintenanceState
+	switch msg.String() {
+	case "esc":
+		st.LabelInput.Blur()
+		st.Screen = models.ManagePairs
+		st.ErrorMessage = ""
+		return m, nil
+	case "enter":
+		name := strings.TrimSpace(st.LabelInput.Value())
+		if name == "" {
+			st.ErrorMessage = "label cannot be empty"
+			return m, nil
+		}
+		st.LabelInput.Blur()
+		st.Screen = models.ManagePairs
+		index := st.PairCursor
+		return m.savePairEdit(func(c *config.Config) error {
+			return config.RenamePair(c, index, name)
+		}, fmt.Sprintf("Renamed to %s", name), index)
+	}
+
+	var cmd tea.Cmd
+	st.LabelInput, cmd = st.LabelInput.Update(msg)
+	return m, cmd
+}
+
 // Commands
 
 func searchAssetsCmd(domain string) tea.Cmd {
@@ -254,7 +436,8 @@ func searchAssetsCmd(domain string) tea.Cmd {
 
 func fetchConfirmationDataCmd(client *horizonclient.Client, assetA, assetB txnbuild.Asset) tea.Cmd {
 	return func() tea.Msg {
-		data, err := stellar.FetchPairConfirmationData(client, assetA, assetB, liquidityPoolIDs)
+		poolID, _ := poolResolver.Resolve(client, assetA, assetB)
+		data, err := stellar.FetchPairConfirmationData(client, assetA, assetB, poolID)
 		if err != nil {
 			return models.MaintenanceErrMsg{Err: err}
 		}
@@ -262,11 +445,59 @@ func fetchConfirmationDataCmd(client *horizonclient.Client, assetA, assetB txnbu
 	}
 }
 
-func reloadConfigCmd() tea.Cmd {
+// savePairEdit saves a pair manager edit made to a copy of the config. Only
+// one save runs at a time, so every edit starts from the last saved config.
+func (m model) savePairEdit(edit func(*config.Config) error, note string, cursor int) (model, tea.Cmd) {
+	st := &m.maintenanceState
+	if st.LoadingMessage != "" {
+		return m, nil
+	}
+	cfg, err := editableConfig()
+	if err != nil {
+		st.ErrorMessage = err.Error()
+		return m, nil
+	}
+	st.LoadingMessage = "Saving..."
+	st.ErrorMessage = ""
+	st.StatusMessage = ""
+	return m, saveConfigCmd(cfg, edit, note, cursor)
+}
+
+// configSaved finishes a maintenance edit once its config has been applied
+func (m model) configSaved(msg configReloadedMsg) model {
+	if m.currentScreen != screenMaintenance || msg.cfg == nil {
+		return m
+	}
+	st := &m.maintenanceState
+	st.LoadingMessage = ""
+	if st.Screen == models.PairConfirmation {
+		m = m.closeMaintenance()
+		m.status = msg.note
+		return m
+	}
+	st.StatusMessage = msg.note
+	st.PairCursor = min(msg.cursor, max(0, len(editablePairs())-1))
+	return m
+}
+
+// configReloadedMsg asks Update to rebuild the configured pairs, from cfg
+// when an edit has just been saved and from disk otherwise
+type configReloadedMsg struct {
+	cfg    *config.Config
+	note   string // status line for the saved edit
+	cursor int    // pair manager cursor after the edit
+}
+
+// configSaveFailedMsg reports an edit that could not be saved. The loaded
+// config is left as it was.
+type configSaveFailedMsg struct{ err error }
+
+// saveConfigCmd applies edit, which saves, to cfg off the UI goroutine
+func saveConfigCmd(cfg *config.Config, edit func(*config.Config) error, note string, cursor int) tea.Cmd {
 	return func() tea.Msg {
-		// Reload config to pick up new pairs
-		// For now, this is a no-op since we need to restart
-		// In the future, we could dynamically reload
-		return nil
+		if err := edit(cfg); err != nil {
+			return configSaveFailedMsg{err: err}
+		}
+		return configReloadedMsg{cfg: cfg, note: note, cursor: cursor}
 	}
 }
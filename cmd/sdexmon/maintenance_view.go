@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/sdexmon/sdexmon/internal/models"
+)
+
+// maintenanceView renders the pair-management wizard
+func maintenanceView(m model) string {
+	st := m.maintenanceState
+
+	var title string
+	var body []string
+	switch st.Screen {
+	case models.MaintenanceMenu:
+		title = "Maintenance"
+		body = []string{
+			"1  Add asset pair",
+			"2  Manage pairs (remove, reorder, edit label)",
+		}
+	case models.AssetADomainInput:
+		title = "Add Pair - First Asset"
+		body = []string{
+			dimStyle.Render("Enter the home domain of the first asset's issuer."),
+			"",
+			st.DomainInputA.View(),
+		}
+	case models.AssetASelection:
+		title = "Add Pair - First Asset"
+		body = assetSelectionLines(st.SearchResultsA, st.AssetCursorA)
+	case models.AssetBDomainInput:
+		title = "Add Pair - Second Asset"
+		body = []string{
+			dimStyle.Render(fmt.Sprintf("First asset: %s", st.SelectedAssetA.Code)),
+			dimStyle.Render("Enter the home domain of the second asset's issuer."),
+			"",
+			st.DomainInputB.View(),
+		}
+	case models.AssetBSelection:
+		title = "Add Pair - Second Asset"
+		body = assetSelectionLines(st.SearchResultsB, st.AssetCursorB)
+	case models.PairConfirmation:
+		title = "Add Pair - Confirm"
+		body = confirmationLines(st.ConfirmationData)
+	case models.ManagePairs:
+		title = "Manage Pairs"
+		body = managePairsLines(st)
+	case models.EditPairLabel:
+		title = "Edit Pair Label"
+		body = []string{st.LabelInput.View()}
+	}
+
+	lines := []string{
+		renderVersionInfo(),
+		"",
+		renderHeader(),
+		renderSubtitle(title),
+		"",
+	}
+	lines = append(lines, body...)
+	if st.LoadingMessage != "" {
+		lines = append(lines, "", dimStyle.Render(st.LoadingMessage))
+	}
+	if st.StatusMessage != "" {
+		lines = append(lines, "", greenStyle.Render(st.StatusMessage))
+	}
+	if st.ErrorMessage != "" {
+		lines = append(lines, "", errorStyle.Render(st.ErrorMessage))
+	}
+
+	content := strings.Join(lines, "\n")
+	contentHeight := lipgloss.Height(content)
+	targetHeight := 60
+	if m.height > 0 {
+		targetHeight = m.height
+	}
+	paddingLines := targetHeight - contentHeight - 2
+	if paddingLines < 0 {
+		paddingLines = 0
+	}
+	padding := strings.Repeat("\n", paddingLines)
+
+	return lipgloss.JoinVertical(lipgloss.Left, content, padding, m.bottomLine())
+}
+
+func assetSelectionLines(assets []models.StellarExpertAsset, cursor int) []string {
+	if len(assets) == 0 {
+		return []string{dimStyle.Render("No assets found for this domain")}
+	}
+	lines := make([]string, 0, len(assets))
+	for i, a := range assets {
+		label := fmt.Sprintf("%-12s %s  %d trustlines", a.Code, truncateMiddle(a.Issuer, 16), a.Trustlines)
+		if a.Name != "" {
+			label += "  " + a.Name
+		}
+		if i == cursor {
+			lines = append(lines, selectedStyle.Render("> "+label))
+		} else {
+			lines = append(lines, pairItemStyle.Render("  "+label))
+		}
+	}
+	return lines
+}
+
+func confirmationLines(cd *models.PairConfirmationData) []string {
+	if cd == nil {
+		return nil
+	}
+	a, b := assetShort(cd.AssetA), assetShort(cd.AssetB)
+	lines := []string{
+		fmt.Sprintf("Pair:      %s/%s", a, b),
+		fmt.Sprintf("Base:      %s", assetString(cd.AssetA)),
+		fmt.Sprintf("Quote:     %s", assetString(cd.AssetB)),
+		fmt.Sprintf("Best bid:  %s", firstNonEmpty(cd.BestBid, "-")),
+		fmt.Sprintf("Best ask:  %s", firstNonEmpty(cd.BestAsk, "-")),
+	}
+	if cd.LPPoolID != "" {
+		lines = append(lines,
+			fmt.Sprintf("LP pool:   %s", truncateMiddle(cd.LPPoolID, 24)),
+			fmt.Sprintf("LP locked: %s %s / %s %s", cd.LPLockedA, a, cd.LPLockedB, b),
+		)
+	}
+	return append(lines, "", dimStyle.Render("Press enter to add this pair to your config."))
+}
+
+func managePairsLines(st models.MaintenanceState) []string {
+	pairs := editablePairs()
+	if len(pairs) == 0 {
+		return []string{dimStyle.Render("No pairs configured")}
+	}
+	lines := make([]string, 0, len(pairs)+2)
+	for i, p := range pairs {
+		label := fmt.Sprintf("%-20s %s / %s", p.Name, truncateMiddle(p.Base, 24), truncateMiddle(p.Quote, 24))
+		if i == st.PairCursor {
+			lines = append(lines, selectedStyle.Render("> "+label))
+		} else {
+			lines = append(lines, pairItemStyle.Render("  "+label))
+		}
+	}
+	if st.ConfirmRemove {
+		lines = append(lines, "", errorStyle.Render(fmt.Sprintf("Remove %s? y: confirm  any other key: cancel", pairs[st.PairCursor].Name)))
+	}
+	return lines
+}
+
+// maintenanceShortcuts returns the footer shortcuts for the current wizard step
+func maintenanceShortcuts(st models.MaintenanceState) string {
+	switch st.Screen {
+	case models.MaintenanceMenu:
+		return "1: add pair  2: manage pairs  esc: back  ctrl+c: quit"
+	case models.AssetADomainInput, models.AssetBDomainInput:
+		return "enter: search  esc: back  ctrl+c: quit"
+	case models.AssetASelection, models.AssetBSelection:
+		return "↑/↓: navigate  enter: select  esc: back  ctrl+c: quit"
+	case models.PairConfirmation:
+		return "enter: add pair  esc: back  ctrl+c: quit"
+	case models.ManagePairs:
+		return "↑/↓: navigate  K/J: move up/down  e: edit label  x: remove  esc: back  ctrl+c: quit"
+	case models.EditPairLabel:
+		return "enter: save  esc: cancel  ctrl+c: quit"
+	}
+	return "esc: back  ctrl+c: quit"
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+
+	"github.com/sdexmon/sdexmon/internal/config"
+	"github.com/sdexmon/sdexmon/internal/makers"
+)
+
+const (
+	// makerInterval relists every account's offers; streamed updates arrive
+	// in between, but a stream does not report offers that were removed
+	makerInterval   = 10 * time.Second
+	makerPageSize   = 200
+	makerMaxPages   = 10
+	makerOfferLimit = makerPageSize * makerMaxPages
+)
+
+// makerBands are the distances from mid, in percent, the share panel reports
+var makerBands = []float64{0.1, 0.25, 0.5, 1, 2, 5}
+
+// makerMarker flags book levels holding our offers
+var makerMarker = selectedStyle.Render("◆")
+
+type (
+	makerTickMsg   struct{ gen uint64 }
+	makerOffersMsg struct {
+		gen     uint64
+		account string
+		offers  []hProtocol.Offer
+		err     error
+	}
+	makerOfferMsg struct {
+		gen   uint64
+		offer hProtocol.Offer
+	}
+)
+
+func makerTick(gen uint64) tea.Cmd {
+	return tea.Tick(makerInterval, func(time.Time) tea.Msg { return makerTickMsg{gen: gen} })
+}
+
+func marketMakers() []config.MarketMaker {
+	if appConfig == nil {
+		return nil
+	}
+	return appConfig.MarketMakers
+}
+
+// makerFeedCmds lists our accounts' offers for the pair generation and keeps
+// them current, streaming updates when the pair feeds stream
+func (m model) makerFeedCmds(sc fetchScope) []tea.Cmd {
+	mms := marketMakers()
+	if len(mms) == 0 {
+		return nil
+	}
+	cmds := []tea.Cmd{m.fetchMakerOffers(sc), makerTick(sc.gen)}
+	if m.stream != nil && streamingEnabled() {
+		for _, mm := range mms {
+			cmds = append(cmds, streamMakerOffersCmd(m.stream, sc, mm.Account))
+		}
+	}
+	return cmds
+}
+
+func (m model) fetchMakerOffers(sc fetchScope) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, mm := range marketMakers() {
+		cmds = append(cmds, fetchAccountOffersCmd(sc, mm.Account))
+	}
+	return tea.Batch(cmds...)
+}
+
+// fetchAccountOffersCmd lists all of an account's open offers; Horizon
+// cannot filter an account's offers by pair
+func fetchAccountOffersCmd(sc fetchScope, account string) tea.Cmd {
+	return func() tea.Msg {
+		var all []hProtocol.Offer
+		cursor := ""
+		for page := 0; page < makerMaxPages; page++ {
+			resp, err := sc.client.Offers(horizonclient.OfferRequest{ForAccount: account, Cursor: cursor,
+				Limit: makerPageSize, Order: horizonclient.OrderAsc})
+			if err != nil {
+				return makerOffersMsg{gen: sc.gen, account: account, err: err}
+			}
+			recs := resp.Embedded.Records
+			all = append(all, recs...)
+			if len(recs) < makerPageSize {
+				break
+			}
+			cursor = recs[len(recs)-1].PagingToken()
+		}
+		return makerOffersMsg{gen: sc.gen, account: account, offers: all}
+	}
+}
+
+// streamMakerOffersCmd streams an account's offer updates until the pair
+// generation ends, reconnecting with backoff. Relisting keeps the panel
+// right if the stream stays down, so it never gives up.
+func streamMakerOffersCmd(s *marketStreamer, sc fetchScope, account string) tea.Cmd {
+	return func() tea.Msg {
+		go func() {
+			backoff := streamRetryMin
+			for {
+				err := s.client.StreamOffers(sc.ctx, horizonclient.OfferRequest{ForAccount: account},
+					func(o hProtocol.Offer) {
+						backoff = streamRetryMin
+						if sc.ctx.Err() == nil && s.send != nil {
+							s.send(makerOfferMsg{gen: sc.gen, offer: o})
+						}
+					})
+				if sc.ctx.Err() != nil {
+					return
+				}
+				log.Printf("Stream offers %s interrupted: %v", truncateMiddle(account, 12), err)
+				select {
+				case <-sc.ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+				backoff *= 2
+				if backoff > streamRetryMax {
+					backoff = streamRetryMax
+				}
+			}
+		}()
+		return nil
+	}
+}
+
+// pairOffers keeps the offers on the current pair
+func (m model) pairOffers(list []hProtocol.Offer) []makers.Offer {
+	base, quote := getAssetName(m.base), getAssetName(m.quote)
+	var out []makers.Offer
+	for _, o := range list {
+		if mo, ok := makers.FromHorizon(o, base, quote); ok {
+			out = append(out, mo)
+		}
+	}
+	return out
+}
+
+func (m model) toggleMakers() (tea.Model, tea.Cmd) {
+	m.showMakers = !m.showMakers
+	return m, nil
+}
+
+// makerMark is the marker column of a book row, given our amount there;
+// the column is left out when no accounts are configured
+func makerMark(ours *big.Rat) string {
+	if len(marketMakers()) == 0 {
+		return ""
+	}
+	if ours == nil {
+		return "  "
+	}
+	return " " + makerMarker
+}
+
+// renderMakers shows our share of the best prices and of the depth around
+// mid
+func (m model) renderMakers() string {
+	rows := []string{boldStyle.Render("OUR OFFERS")}
+	mms := marketMakers()
+	if len(mms) == 0 {
+		return strings.Join(append(rows, dimStyle.Render("no accounts configured; add market_makers to config.yaml")), "\n")
+	}
+	if m.makerNote != "" {
+		rows = append(rows, errorStyle.Render(m.makerNote))
+	}
+
+	counts := map[string][2]int{}
+	for _, o := range m.makerOffers {
+		c := counts[o.Account]
+		c[o.Side]++
+		counts[o.Account] = c
+	}
+	var accounts []string
+	for _, mm := range mms {
+		c := counts[mm.Account]
+		accounts = append(accounts, fmt.Sprintf("%s %d bids %d asks", firstNonEmpty(mm.Name, truncateMiddle(mm.Account, 12)), c[makers.Bid], c[makers.Ask]))
+	}
+	rows = append(rows, dimStyle.Render(strings.Join(accounts, "  ·  ")))
+
+	r := makers.Measure(m.orderbook, m.makerOffers, makerBands)
+	unit := assetShort(m.base)
+	share := func(s makers.Share) string {
+		return fmt.Sprintf("%14s %14s %6.1f%%", formatPrice(s.Ours), formatPrice(s.Total), s.Fraction()*100)
+	}
+	rows = append(rows, dimStyle.Render(fmt.Sprintf("%-12s %14s %14s %7s   %14s %14s %7s", "("+unit+")",
+		"BID OURS", "BID TOTAL", "SHARE", "ASK OURS", "ASK TOTAL", "SHARE")))
+	rows = append(rows, fmt.Sprintf("%-12s %s   %s", "best price", share(r.TopBid), share(r.TopAsk)))
+	for _, b := range r.Bands {
+		rows = append(rows, fmt.Sprintf("%-12s %s   %s", fmt.Sprintf("mid ±%g%%", b.Pct), share(b.Bid), share(b.Ask)))
+	}
+	if len(r.Bands) == 0 {
+		rows = append(rows, dimStyle.Render("depth bands need both sides of the book"))
+	}
+	return lipgloss.NewStyle().Render(strings.Join(rows, "\n"))
+}
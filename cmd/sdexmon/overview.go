@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+)
+
+const (
+	overviewInterval = lpInterval
+	overviewWorkers  = 4 // pairs fetched at once, like serve
+	overviewTimeout  = 20 * time.Second
+)
+
+// fetchSlots bounds the background multi-pair fetches in flight (overview
+// rows, peg observations, alert and SLA polls, issuer supplies)
+var fetchSlots = make(chan struct{}, overviewWorkers)
+
+// takeFetchSlot waits for a fetch slot. It gives up, reporting false, once
+// ctx ends, including when the fetch was superseded while it queued.
+func takeFetchSlot(ctx context.Context) (release func(), ok bool) {
+	select {
+	case fetchSlots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, false
+	}
+	if ctx.Err() != nil {
+		<-fetchSlots
+		return nil, false
+	}
+	return func() { <-fetchSlots }, true
+}
+
+// fanOut runs fetch for each of n items on the fetch slots and returns the
+// results by index. Items still queued when ctx ends are not fetched and
+// keep the zero value.
+func fanOut[T any](ctx context.Context, n int, fetch func(i int) T) []T {
+	out := make([]T, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, ok := takeFetchSlot(ctx)
+			if !ok {
+				return
+			}
+			defer release()
+			out[i] = fetch(i)
+		}()
+	}
+	wg.Wait()
+	return out
+}
+
+// overviewPending counts the overview rows still being fetched
+var overviewPending atomic.Int32
+
+// Overview columns, in display order; the number keys sort by them
+const (
+	colPair = iota
+	colBid
+	colAsk
+	colSpread
+	colLast
+	colChange
+	colVolume
+	colTVL
+	overviewColumns
+)
+
+var overviewHeaders = [overviewColumns]string{"PAIR", "BID", "ASK", "SPREAD %", "LAST", "24H %", "24H VOL", "LP TVL"}
+
+// overviewRow is one pair's summary. Unknown values are NaN.
+type overviewRow struct {
+	pair                   pairOption
+	bid, ask, spread, last float64
+	change, volume, tvl    float64
+	err                    error
+	updated                time.Time
+}
+
+type overviewRowMsg struct {
+	gen uint64
+	row overviewRow
+}
+
+// openOverview shows the overview and starts a refresh. Each visit is a new
+// generation, so ticks from an earlier visit stop.
+func (m model) openOverview() (tea.Model, tea.Cmd) {
+	m.showRefreshing(screenOverview)
+	// the pairs may have been edited since the last visit; keep what is
+	// still listed
+	previous := map[string]overviewRow{}
+	for _, r := range m.overview {
+		previous[r.pair.label()] = r
+	}
+	m.overview = make([]overviewRow, len(configuredPairs))
+	for i, p := range configuredPairs {
+		if r, ok := previous[p.label()]; ok {
+			m.overview[i] = r
+		} else {
+			m.overview[i] = emptyOverviewRow(p)
+		}
+	}
+	if m.overviewIndex >= len(m.overview) {
+		m.overviewIndex = 0
+	}
+	return m, m.startRefreshing()
+}
+
+func emptyOverviewRow(p pairOption) overviewRow {
+	nan := math.NaN()
+	return overviewRow{pair: p, bid: nan, ask: nan, spread: nan, last: nan, change: nan, volume: nan, tvl: nan}
+}
+
+// refreshOverview fetches every pair, overviewWorkers at a time; rows update
+// as their fetches finish. A refresh still running is not started again.
+func (m model) refreshOverview() tea.Cmd {
+	if overviewPending.Load() > 0 {
+		return nil
+	}
+	overviewPending.Add(int32(len(m.overview)))
+	ctx := screenContext(screenOverview, m.overviewGen)
+	cmds := make([]tea.Cmd, 0, len(m.overview))
+	for _, row := range m.overview {
+		cmds = append(cmds, fetchOverviewRowCmd(ctx, m.client, m.overviewGen, row.pair))
+	}
+	return tea.Batch(cmds...)
+}
+
+// fetchOverviewRowCmd fetches a row unless the visit has ended by the time
+// a fetch slot is free
+func fetchOverviewRowCmd(visit context.Context, client *horizonclient.Client, gen uint64, p pairOption) tea.Cmd {
+	return func() tea.Msg {
+		defer overviewPending.Add(-1)
+		release, ok := takeFetchSlot(visit)
+		if !ok {
+			return nil
+		}
+		defer release()
+		ctx, cancel := context.WithTimeout(visit, overviewTimeout)
+		defer cancel()
+		return overviewRowMsg{gen: gen, row: fetchOverviewRow(ctx, scopedClient(client, ctx), p)}
+	}
+}
+
+func fetchOverviewRow(ctx context.Context, client *horizonclient.Client, p pairOption) overviewRow {
+	row := emptyOverviewRow(p)
+	row.updated = time.Now()
+	base, quote, ok := p.assets()
+	if !ok {
+		row.err = fmt.Errorf("unknown asset")
+		return row
+	}
+
+	ob, err := fetchMergedOrderbook(client, base, quote)
+	if err != nil {
+		row.err = err
+		return row
+	}
+	row.bid = ratOrNaN(ob.BestBid())
+	row.ask = ratOrNaN(ob.BestAsk())
+	row.spread = ratOrNaN(ob.SpreadPercent())
+
+	if row.last, row.change, row.volume, err = fetchDayStats(client, base, quote); err != nil {
+		row.err = err
+	}
+
+	if poolID, _ := resolvePoolID(client, base, quote); poolID != "" {
+		if lp, err := fetchLP(ctx, client, poolID); err == nil {
+			price := row.last
+			if mid := ob.Mid(); mid != nil {
+				price = orderbook.Float(mid)
+			}
+			row.tvl = poolTVL(lp, assetShort(base), price)
+		}
+	}
+	return row
+}
+
+// fetchDayStats returns the last trade price and the 24h change (percent) and
+// base volume, from hourly trade aggregations
+func fetchDayStats(client *horizonclient.Client, base, quote txnbuild.Asset) (last, change, volume float64, err error) {
+	last, change, volume = math.NaN(), math.NaN(), 0
+	now := time.Now()
+	req := horizonclient.TradeAggregationRequest{
+		StartTime:  now.Add(-24 * time.Hour).Truncate(time.Hour),
+		EndTime:    now,
+		Resolution: time.Hour,
+		Order:      horizonclient.OrderAsc,
+		Limit:      25,
+	}
+	applyAggregationAssets(&req, base, quote)
+	page, err := client.TradeAggregations(req)
+	if err != nil {
+		return last, change, math.NaN(), err
+	}
+	recs := page.Embedded.Records
+	for _, r := range recs {
+		volume += candleOf(r).Volume
+	}
+	if len(recs) > 0 {
+		first, final := candleOf(recs[0]), candleOf(recs[len(recs)-1])
+		last = final.Close
+		if first.Open > 0 {
+			change = (final.Close - first.Open) / first.Open * 100
+		}
+		return last, change, volume, nil
+	}
+
+	// no trades in 24h: the last price is older
+	treq := horizonclient.TradeRequest{Order: horizonclient.OrderDesc, Limit: 1}
+	applyBaseAsset(&treq, base)
+	applyCounterAsset(&treq, quote)
+	tpage, err := client.Trades(treq)
+	if err != nil {
+		return last, change, volume, err
+	}
+	if t := tpage.Embedded.Records; len(t) > 0 {
+		last, _ = strconv.ParseFloat(tradePriceString(t[0].Price), 64)
+	}
+	return last, change, volume, nil
+}
+
+// poolTVL values both reserves in the quote asset at price (quote per base)
+func poolTVL(lp Liquidity, baseCode string, price float64) float64 {
+	base, quote, ok := poolReserves(lp, baseCode)
+	if !ok {
+		return math.NaN()
+	}
+	return base*price + quote
+}
+
+// poolReserves returns the pool's reserves as base and quote amounts
+func poolReserves(lp Liquidity, baseCode string) (base, quote float64, ok bool) {
+	var amounts [2]float64
+	for i := range amounts {
+		if lp.LockedR[i] == nil {
+			return 0, 0, false
+		}
+		amounts[i] = orderbook.Float(lp.LockedR[i])
+	}
+	if lp.Codes[1] == baseCode {
+		return amounts[1], amounts[0], true
+	}
+	return amounts[0], amounts[1], true
+}
+
+func ratOrNaN(v *big.Rat) float64 {
+	if v == nil {
+		return math.NaN()
+	}
+	return orderbook.Float(v)
+}
+
+// sortedOverview returns the rows in display order. Unknown values sort
+// last either way.
+func (m model) sortedOverview() []overviewRow {
+	rows := append([]overviewRow(nil), m.overview...)
+	col, desc := m.overviewSort, m.overviewDesc
+	sort.SliceStable(rows, func(i, j int) bool {
+		if col == colPair {
+			a, b := rows[i].pair.label(), rows[j].pair.label()
+			if desc {
+				return a > b
+			}
+			return a < b
+		}
+		a, b := rows[i].value(col), rows[j].value(col)
+		switch {
+		case math.IsNaN(a) || math.IsNaN(b):
+			return !math.IsNaN(a) && math.IsNaN(b)
+		case desc:
+			return a > b
+		default:
+			return a < b
+		}
+	})
+	return rows
+}
+
+func (r overviewRow) value(col int) float64 {
+	switch col {
+	case colBid:
+		return r.bid
+	case colAsk:
+		return r.ask
+	case colSpread:
+		return r.spread
+	case colLast:
+		return r.last
+	case colChange:
+		return r.change
+	case colVolume:
+		return r.volume
+	case colTVL:
+		return r.tvl
+	}
+	return math.NaN()
+}
+
+func (m model) handleOverviewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m, cmd, ok := m.handleRefreshingKeys(msg); ok {
+		return m, cmd
+	}
+	switch key := msg.String(); key {
+	case "up", "k":
+		if m.overviewIndex > 0 {
+			m.overviewIndex--
+		}
+		return m, nil
+	case "down", "j":
+		if m.overviewIndex < len(m.overview)-1 {
+			m.overviewIndex++
+		}
+		return m, nil
+	case "enter":
+		rows := m.sortedOverview()
+		if m.overviewIndex >= len(rows) {
+			return m, nil
+		}
+		base, quote, ok := rows[m.overviewIndex].pair.assets()
+		if !ok {
+			return m, nil
+		}
+		m.base, m.quote = base, quote
+		m.leaveRefreshing()
+		m.status = "pair selected"
+		return m, m.startPairFeeds()
+	default:
+		// 1-8 sort by that column; again reverses
+		if n, err := strconv.Atoi(key); err == nil && n >= 1 && n <= overviewColumns {
+			if m.overviewSort == n-1 {
+				m.overviewDesc = !m.overviewDesc
+			} else {
+				m.overviewSort, m.overviewDesc = n-1, n-1 != colPair
+			}
+		}
+		return m, nil
+	}
+}
+
+func overviewView(m model) string {
+	widths := [overviewColumns]int{16, 12, 12, 9, 12, 8, 14, 14}
+	cell := func(col int, s string) string {
+		if col == colPair {
+			return padRightVis(s, widths[col])
+		}
+		return padLeftVis(s, widths[col])
+	}
+
+	header := make([]string, overviewColumns)
+	for c, h := range overviewHeaders {
+		label := fmt.Sprintf("%d:%s", c+1, h)
+		if c == m.overviewSort {
+			label += map[bool]string{true: "▼", false: "▲"}[m.overviewDesc]
+		}
+		header[c] = cell(c, label)
+	}
+	lines := []string{dimStyle.Render(strings.Join(header, " "))}
+
+	for i, r := range m.sortedOverview() {
+		cols := make([]string, overviewColumns)
+		cols[colPair] = cell(colPair, truncateMiddle(r.pair.label(), widths[colPair]))
+		for c := colBid; c < overviewColumns; c++ {
+			cols[c] = cell(c, r.format(c))
+		}
+		line := strings.Join(cols, " ")
+		if r.err != nil && math.IsNaN(r.bid) {
+			// nothing fetched, so show why
+			line = cols[colPair] + " " + errorStyle.Render(truncateMiddle(r.err.Error(), 80))
+		} else if r.updated.IsZero() {
+			line = dimStyle.Render(line)
+		}
+		marker := "  "
+		if i == m.overviewIndex {
+			marker = selectedStyle.Render("▶ ")
+		}
+		lines = append(lines, marker+line)
+	}
+	if len(m.overview) == 0 {
+		lines = append(lines, dimStyle.Render("no configured pairs; press m on the landing screen to add some"))
+	}
+
+	table := panelStyle.Render(strings.Join(lines, "\n"))
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		renderVersionInfo(),
+		"",
+		renderHeader(),
+		renderSubtitle(fmt.Sprintf("Market Overview - %d pairs (refresh every %s)", len(m.overview), overviewInterval)),
+		table,
+	)
+	targetHeight := 60
+	if m.height > 0 {
+		targetHeight = m.height
+	}
+	padding := strings.Repeat("\n", max(0, targetHeight-lipgloss.Height(content)-2))
+	return lipgloss.JoinVertical(lipgloss.Left, content, padding, m.bottomLine())
+}
+
+// format renders one numeric column, or "-" when unknown
+func (r overviewRow) format(col int) string {
+	v := r.value(col)
+	if math.IsNaN(v) {
+		return "-"
+	}
+	switch col {
+	case colSpread:
+		return fmt.Sprintf("%.2f", v)
+	case colChange:
+		s := fmt.Sprintf("%+.2f", v)
+		if v > 0 {
+			return greenStyle.Render(s)
+		}
+		if v < 0 {
+			return redStyle.Render(s)
+		}
+		return s
+	case colVolume, colTVL:
+		return trimDecimalsKeepMin2(strconv.FormatFloat(v, 'f', 2, 64))
+	}
+	return trimDecimalsKeepMin2(strconv.FormatFloat(v, 'f', 7, 64))
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+	"github.com/sdexmon/sdexmon/internal/slippage"
+)
+
+// maxPathRoutes is how many routes the path panel lists
+const maxPathRoutes = 5
+
+// pathRoute is one route Horizon found between the pair's assets. Price is
+// the implied price in quote per base.
+type pathRoute struct {
+	Hops        []string
+	Source      *big.Rat
+	Destination *big.Rat
+	Price       *big.Rat
+}
+
+// pathsDataMsg delivers the routes for one path query
+type pathsDataMsg struct {
+	gen     uint64
+	receive bool
+	routes  []pathRoute
+	err     error
+}
+
+func newPathsInput() textinput.Model {
+	in := textinput.New()
+	in.Placeholder = "e.g. 10k or 0.5"
+	in.Prompt = "AMOUNT > "
+	in.CharLimit = 24
+	return in
+}
+
+// handlePathsKeys drives the path quote panel while its amount input has
+// focus: enter queries Horizon, tab switches strict-send and strict-receive
+func (m model) handlePathsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.showPaths = false
+		m.pathsInput.Blur()
+		return m, nil
+	case "tab":
+		m.pathsReceive = !m.pathsReceive
+		m.paths = nil
+		m.pathsNote = ""
+		return m, nil
+	case "enter":
+		amount, err := slippage.ParseSize(m.pathsInput.Value())
+		if err != nil {
+			m.pathsNote = err.Error()
+			return m, nil
+		}
+		m.paths = nil
+		m.pathsNote = "querying routes..."
+		return m, fetchPathsCmd(m.scope(), m.base, m.quote, amount, m.pathsReceive)
+	}
+	var cmd tea.Cmd
+	m.pathsInput, cmd = m.pathsInput.Update(msg)
+	return m, cmd
+}
+
+// fetchPathsCmd asks Horizon for routes that sell amount of base for quote
+// (strict-send) or that buy amount of base with quote (strict-receive)
+func fetchPathsCmd(sc fetchScope, base, quote txnbuild.Asset, amount *big.Rat, receive bool) tea.Cmd {
+	return func() tea.Msg {
+		routes, err := fetchPaths(sc.client, base, quote, amount, receive)
+		if sc.stale() {
+			return nil
+		}
+		return pathsDataMsg{gen: sc.gen, receive: receive, routes: routes, err: err}
+	}
+}
+
+func fetchPaths(client *horizonclient.Client, base, quote txnbuild.Asset, amount *big.Rat, receive bool) ([]pathRoute, error) {
+	if client == nil || base == nil || quote == nil {
+		return nil, fmt.Errorf("not configured")
+	}
+	var page hProtocol.PathsPage
+	var err error
+	if receive {
+		page, err = client.StrictReceivePaths(horizonclient.StrictReceivePathsRequest{
+			SourceAssets:           pathAssetParam(quote),
+			DestinationAssetType:   assetTypeEnum(base),
+			DestinationAssetCode:   codeOf(base),
+			DestinationAssetIssuer: issuerOf(base),
+			DestinationAmount:      amount.FloatString(7),
+		})
+	} else {
+		page, err = client.StrictSendPaths(horizonclient.StrictSendPathsRequest{
+			DestinationAssets: pathAssetParam(quote),
+			SourceAssetType:   assetTypeEnum(base),
+			SourceAssetCode:   codeOf(base),
+			SourceAssetIssuer: issuerOf(base),
+			SourceAmount:      amount.FloatString(7),
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]pathRoute, 0, len(page.Embedded.Records))
+	for _, p := range page.Embedded.Records {
+		if r, ok := routeOf(p, receive); ok {
+			routes = append(routes, r)
+		}
+	}
+	// best first: most quote received when sending, least quote paid when receiving
+	sort.SliceStable(routes, func(i, j int) bool {
+		if receive {
+			return routes[i].Source.Cmp(routes[j].Source) < 0
+		}
+		return routes[i].Destination.Cmp(routes[j].Destination) > 0
+	})
+	if len(routes) > maxPathRoutes {
+		routes = routes[:maxPathRoutes]
+	}
+	return routes, nil
+}
+
+func routeOf(p hProtocol.Path, receive bool) (pathRoute, bool) {
+	src, ok1 := new(big.Rat).SetString(p.SourceAmount)
+	dst, ok2 := new(big.Rat).SetString(p.DestinationAmount)
+	if !ok1 || !ok2 || src.Sign() <= 0 || dst.Sign() <= 0 {
+		return pathRoute{}, false
+	}
+	hops := []string{pathAssetCode(p.SourceAssetType, p.SourceAssetCode)}
+	for _, a := range p.Path {
+		hops = append(hops, pathAssetCode(a.Type, a.Code))
+	}
+	hops = append(hops, pathAssetCode(p.DestinationAssetType, p.DestinationAssetCode))
+
+	// strict-send sells base (source) for quote; strict-receive buys base
+	// (destination) with quote
+	price := new(big.Rat).Quo(dst, src)
+	if receive {
+		price = new(big.Rat).Quo(src, dst)
+	}
+	return pathRoute{Hops: hops, Source: src, Destination: dst, Price: price}, true
+}
+
+func pathAssetCode(assetType, code string) string {
+	if assetType == "native" {
+		return "XLM"
+	}
+	return code
+}
+
+// pathAssetParam formats an asset for the source_assets and
+// destination_assets query parameters
+func pathAssetParam(a txnbuild.Asset) string {
+	if a.IsNative() {
+		return "native"
+	}
+	return a.GetCode() + ":" + a.GetIssuer()
+}
+
+func codeOf(a txnbuild.Asset) string {
+	if a.IsNative() {
+		return ""
+	}
+	return a.GetCode()
+}
+
+func issuerOf(a txnbuild.Asset) string {
+	if a.IsNative() {
+		return ""
+	}
+	return a.GetIssuer()
+}
+
+// renderPaths lists the best routes with their implied price against mid
+func (m model) renderPaths() string {
+	bc, qc := assetShort(m.base), assetShort(m.quote)
+
+	var title strings.Builder
+	title.WriteString(boldStyle.Render("PATH QUOTES"))
+	title.WriteString("  ")
+	modes := []struct {
+		label   string
+		receive bool
+	}{
+		{"strict-send: sell " + bc, false},
+		{"strict-receive: buy " + bc, true},
+	}
+	for _, mode := range modes {
+		if mode.receive == m.pathsReceive {
+			title.WriteString(selectedStyle.Render("[" + mode.label + "]"))
+		} else {
+			title.WriteString(dimStyle.Render(" " + mode.label + " "))
+		}
+		title.WriteString(" ")
+	}
+	lines := []string{title.String(), m.pathsInput.View() + dimStyle.Render("  "+bc)}
+
+	if len(m.paths) == 0 {
+		note := firstNonEmpty(m.pathsNote, "Enter an amount of "+bc+" and press enter")
+		return strings.Join(append(lines, dimStyle.Render(note)), "\n")
+	}
+
+	mid := m.orderbook.Mid()
+	amountLabel := "RECEIVE (" + qc + ")"
+	if m.pathsReceive {
+		amountLabel = "PAY (" + qc + ")"
+	}
+	lines = append(lines, padRightVis(dimStyle.Render("ROUTE"), 40)+
+		padLeftVis(dimStyle.Render(amountLabel), 20)+
+		padLeftVis(dimStyle.Render("PRICE"), 16)+
+		padLeftVis(dimStyle.Render("VS MID"), 10))
+	for _, r := range m.paths {
+		amount := r.Destination
+		if m.pathsReceive {
+			amount = r.Source
+		}
+		vsMid := "-"
+		if mid != nil && mid.Sign() > 0 {
+			d := new(big.Rat).Sub(r.Price, mid)
+			d.Quo(d, mid)
+			d.Mul(d, big.NewRat(100, 1))
+			vsMid = orderbook.Format(d, 3) + "%"
+			if d.Sign() >= 0 {
+				vsMid = "+" + vsMid
+			}
+		}
+		lines = append(lines, padRightVis(strings.Join(r.Hops, "→"), 40)+
+			padLeftVis(formatRatWithDecimals(amount, 7, 0), 20)+
+			padLeftVis(orderbook.Format(r.Price, 7), 16)+
+			padLeftVis(vsMid, 10))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/sdexmon/sdexmon/internal/config"
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+	"github.com/sdexmon/sdexmon/internal/peg"
+)
+
+const pegInterval = lpInterval
+
+var warnStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+
+// defaultPegs watches the curated Z assets on pubnet: USDZ against USDC, the
+// rest against USDZ, on the pairs the curated list already has
+var defaultPegs = []config.PegAsset{
+	{Asset: "USDZ", Peg: "USD", Against: "USDC", AgainstPeg: "USD"},
+	{Asset: "EURZ", Peg: "EUR", Against: "USDZ", AgainstPeg: "USD"},
+	{Asset: "ZARZ", Peg: "ZAR", Against: "USDZ", AgainstPeg: "USD"},
+	{Asset: "XAUZ", Peg: "XAU", Against: "USDZ", AgainstPeg: "USD"},
+	{Asset: "BTCZ", Peg: "BTC", Against: "USDZ", AgainstPeg: "USD"},
+}
+
+// pegTarget is a peg with its assets resolved
+type pegTarget struct {
+	peg            peg.Peg
+	asset, against txnbuild.Asset
+}
+
+type pegDataMsg struct {
+	gen      uint64
+	readings []peg.Reading
+	rates    peg.Rates
+	source   string
+	err      error
+}
+
+// pegSettings returns the configured peg section, with the curated pegs on
+// pubnet when none are listed
+func pegSettings() config.PegConfig {
+	var pc config.PegConfig
+	if appConfig != nil {
+		pc = appConfig.Peg
+	}
+	if len(pc.Assets) == 0 && onPubnet() {
+		pc.Assets = defaultPegs
+	}
+	return pc
+}
+
+// pegTargets resolves the configured pegs; ones with unknown assets are
+// reported and skipped
+func pegTargets(pc config.PegConfig) ([]pegTarget, []string) {
+	var targets []pegTarget
+	var problems []string
+	for _, a := range pc.Assets {
+		asset, err1 := resolveAssetArg(a.Asset)
+		against, err2 := resolveAssetArg(a.Against)
+		if err1 != nil || err2 != nil || a.Peg == "" || a.AgainstPeg == "" {
+			problems = append(problems, fmt.Sprintf("peg %s/%s: needs asset, peg, against and against_peg", a.Asset, a.Against))
+			continue
+		}
+		targets = append(targets, pegTarget{
+			peg: peg.Peg{Asset: assetShort(asset), Ref: strings.ToUpper(a.Peg),
+				Against: assetShort(against), AgainstRef: strings.ToUpper(a.AgainstPeg),
+				WarnBps: a.WarnBps, BreachBps: a.BreachBps},
+			asset:   asset,
+			against: against,
+		})
+	}
+	return targets, problems
+}
+
+func pegSource(pc config.PegConfig) (peg.Source, error) {
+	location := pc.Path
+	if strings.EqualFold(pc.Source, peg.KindHTTP) {
+		location = pc.URL
+	}
+	return peg.NewSource(pc.Source, location, peg.Rates{Base: firstNonEmpty(pc.Base, "USD"), Rates: pc.Rates})
+}
+
+func pegThresholds(pc config.PegConfig) peg.Thresholds {
+	th := peg.DefaultThresholds
+	if pc.WarnBps > 0 {
+		th.WarnBps = pc.WarnBps
+	}
+	if pc.BreachBps > 0 {
+		th.BreachBps = pc.BreachBps
+	}
+	return th
+}
+
+// openPegMonitor shows the peg monitor and starts refreshing it
+func (m model) openPegMonitor() (tea.Model, tea.Cmd) {
+	m.showRefreshing(screenPeg)
+	m.pegNote = ""
+	pc := pegSettings()
+	targets, problems := pegTargets(pc)
+	if len(problems) > 0 {
+		m.pegNote = strings.Join(problems, "; ")
+	}
+	if len(targets) == 0 {
+		m.pegNote = firstNonEmpty(m.pegNote, "no pegs configured; add peg.assets to config.yaml")
+		return m, nil
+	}
+	return m, m.startRefreshing()
+}
+
+func (m model) refreshPegs() tea.Cmd {
+	pc := pegSettings()
+	targets, _ := pegTargets(pc)
+	src, err := pegSource(pc)
+	client, gen := m.client, m.pegGen
+	visit := screenContext(screenPeg, gen)
+	return func() tea.Msg {
+		if err != nil {
+			return pegDataMsg{gen: gen, err: err}
+		}
+		ctx, cancel := context.WithTimeout(visit, overviewTimeout)
+		defer cancel()
+		rates, err := src.Rates(ctx)
+		obs := observePegs(ctx, scopedClient(client, ctx), targets)
+		pegs := make([]peg.Peg, len(targets))
+		for i, t := range targets {
+			pegs[i] = t.peg
+		}
+		return pegDataMsg{gen: gen, readings: peg.Evaluate(pegs, rates, obs, pegThresholds(pc)),
+			rates: rates, source: src.Name(), err: err}
+	}
+}
+
+// observePegs prices every peg's asset on its book and pool, sharing the
+// background fetch slots
+func observePegs(ctx context.Context, client *horizonclient.Client, targets []pegTarget) map[string]peg.Observation {
+	got := fanOut(ctx, len(targets), func(i int) peg.Observation {
+		t := targets[i]
+		var o peg.Observation
+		if ob, err := fetchMergedOrderbook(client, t.asset, t.against); err == nil {
+			if mid := ob.Mid(); mid != nil {
+				o.Book = orderbook.Float(mid)
+			}
+		}
+		if poolID, _ := resolvePoolID(client, t.asset, t.against); poolID != "" {
+			if lp, err := fetchLP(ctx, client, poolID); err == nil {
+				o.Pool = poolPrice(lp, assetShort(t.asset))
+			}
+		}
+		return o
+	})
+	obs := make(map[string]peg.Observation, len(targets))
+	for i, t := range targets {
+		obs[t.peg.Asset] = got[i]
+	}
+	return obs
+}
+
+// poolPrice is the pool's price of the base asset in the other asset, from
+// the reserve ratio; 0 when unknown
+func poolPrice(lp Liquidity, baseCode string) float64 {
+	base, quote, ok := poolReserves(lp, baseCode)
+	if !ok || base <= 0 {
+		return 0
+	}
+	return quote / base
+}
+
+func (m model) handlePegKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m, cmd, _ := m.handleRefreshingKeys(msg, "g")
+	return m, cmd
+}
+
+func pegView(m model) string {
+	widths := []int{8, 5, 8, 5, 14, 14, 9, 14, 9, 7}
+	header := []string{"ASSET", "PEG", "AGAINST", "REF", "EXPECTED", "BOOK MID", "BOOK BP", "POOL", "POOL BP", "STATUS"}
+	row := func(cols []string) string {
+		out := make([]string, len(cols))
+		for i, c := range cols {
+			if i < 4 {
+				out[i] = padRightVis(c, widths[i])
+			} else {
+				out[i] = padLeftVis(c, widths[i])
+			}
+		}
+		return strings.Join(out, " ")
+	}
+
+	lines := []string{dimStyle.Render(row(header))}
+	for _, r := range m.pegReadings {
+		status := r.Level.String()
+		switch r.Level {
+		case peg.Breach:
+			status = errorStyle.Render(strings.ToUpper(status))
+		case peg.Warn:
+			status = warnStyle.Render(status)
+		case peg.OK:
+			status = greenStyle.Render(status)
+		default:
+			status = dimStyle.Render(status)
+		}
+		lines = append(lines, row([]string{r.Asset, r.Ref, r.Against, r.AgainstRef,
+			pegPrice(r.Expected), pegPrice(r.Book), pegBps(r.BookBps), pegPrice(r.Pool), pegBps(r.PoolBps), status}))
+	}
+	if len(m.pegReadings) == 0 && m.pegNote == "" {
+		lines = append(lines, dimStyle.Render("loading..."))
+	}
+
+	th := pegThresholds(pegSettings())
+	info := fmt.Sprintf("warn at %.0fbp, breach at %.0fbp", th.WarnBps, th.BreachBps)
+	if m.pegSource != "" {
+		info = fmt.Sprintf("rates: %s (base %s, as of %s)  %s", m.pegSource, m.pegRates.Base,
+			m.pegRates.At.Local().Format("15:04:05"), info)
+	}
+	lines = append(lines, "", dimStyle.Render(info))
+	if m.pegNote != "" {
+		lines = append(lines, errorStyle.Render(m.pegNote))
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		renderVersionInfo(),
+		"",
+		renderHeader(),
+		renderSubtitle("Peg Monitor - deviation of on-chain prices from reference rates"),
+		panelStyle.Render(strings.Join(lines, "\n")),
+	)
+	targetHeight := 60
+	if m.height > 0 {
+		targetHeight = m.height
+	}
+	padding := strings.Repeat("\n", max(0, targetHeight-lipgloss.Height(content)-2))
+	return lipgloss.JoinVertical(lipgloss.Left, content, padding, m.bottomLine())
+}
+
+func pegPrice(v float64) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return trimDecimalsKeepMin2(strconv.FormatFloat(v, 'f', 7, 64))
+}
+
+func pegBps(v float64) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f", v)
+}
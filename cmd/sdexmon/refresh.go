@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// refreshingScreen is a screen that refreshes itself on a timer while it is
+// shown. Every visit is a new generation of the screen, so ticks and results
+// from an earlier visit are dropped, and leaving cancels the visit's fetches.
+type refreshingScreen struct {
+	interval time.Duration
+	gen      func(m *model) *uint64
+	refresh  func(m model) tea.Cmd
+}
+
+var refreshingScreens = map[screenState]refreshingScreen{
+	screenOverview: {overviewInterval, func(m *model) *uint64 { return &m.overviewGen }, model.refreshOverview},
+	screenPeg:      {pegInterval, func(m *model) *uint64 { return &m.pegGen }, model.refreshPegs},
+	screenArb:      {arbInterval, func(m *model) *uint64 { return &m.arbGen }, model.refreshArb},
+	screenSLA:      {slaInterval, func(m *model) *uint64 { return &m.slaGen }, model.refreshSLA},
+}
+
+// screenScheds hand out the visits of each refreshing screen
+var screenScheds = map[screenState]*pollScheduler{
+	screenOverview: newPollScheduler(),
+	screenPeg:      newPollScheduler(),
+	screenArb:      newPollScheduler(),
+	screenSLA:      newPollScheduler(),
+}
+
+// screenTickMsg asks a refreshing screen to refresh; gen is the screen's
+// generation when the tick was scheduled
+type screenTickMsg struct {
+	screen screenState
+	gen    uint64
+}
+
+// showRefreshing switches to a refreshing screen as a new generation,
+// without starting the refresh yet
+func (m *model) showRefreshing(screen screenState) {
+	m.currentScreen = screen
+	m.showPairPopup = false
+	*refreshingScreens[screen].gen(m), _ = screenScheds[screen].next()
+}
+
+// screenContext is the context of a visit to a refreshing screen,
+// cancelled once the screen is left
+func screenContext(screen screenState, gen uint64) context.Context {
+	return screenScheds[screen].context(gen)
+}
+
+// startRefreshing refreshes the current screen now and schedules the next
+// tick
+func (m model) startRefreshing() tea.Cmd {
+	rs := refreshingScreens[m.currentScreen]
+	tick := screenTickMsg{screen: m.currentScreen, gen: *rs.gen(&m)}
+	return tea.Batch(rs.refresh(m), tea.Tick(rs.interval, func(time.Time) tea.Msg { return tick }))
+}
+
+// refreshTick refreshes the screen a tick was scheduled for, unless it has
+// been left since
+func (m model) refreshTick(msg screenTickMsg) tea.Cmd {
+	rs, ok := refreshingScreens[msg.screen]
+	if !ok || m.currentScreen != msg.screen || msg.gen != *rs.gen(&m) {
+		return nil
+	}
+	return m.startRefreshing()
+}
+
+// leaveRefreshing stops the current screen's refresh and goes back
+func (m *model) leaveRefreshing() {
+	if rs, ok := refreshingScreens[m.currentScreen]; ok {
+		*rs.gen(m), _ = screenScheds[m.currentScreen].next()
+	}
+	m.backToPair()
+}
+
+// backToPair shows the selected pair, or the landing screen without one
+func (m *model) backToPair() {
+	if m.base != nil && m.quote != nil {
+		m.currentScreen = screenPairInfo
+	} else {
+		m.currentScreen = screenLanding
+	}
+}
+
+// handleRefreshingKeys handles the keys all refreshing screens share: esc, b
+// and the screen's own exit keys leave it, r refreshes now. It reports
+// whether it used the key.
+func (m model) handleRefreshingKeys(msg tea.KeyMsg, exitKeys ...string) (model, tea.Cmd, bool) {
+	switch k := msg.String(); {
+	case k == "esc" || k == "b" || slices.Contains(exitKeys, k):
+		m.leaveRefreshing()
+		return m, nil, true
+	case k == "r":
+		return m, refreshingScreens[m.currentScreen].refresh(m), true
+	}
+	return m, nil, false
+}
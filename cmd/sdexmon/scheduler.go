@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stellar/go/clients/horizonclient"
+)
+
+// pollScheduler scopes pair data fetches to a generation. Every pair switch
+// starts a new generation, which cancels the previous generation's context
+// (aborting its in-flight HTTP requests and streams) so that Update can drop
+// any late ticks or responses still tagged with the old generation.
+type pollScheduler struct {
+	mu     sync.Mutex
+	gen    uint64
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newPollScheduler() *pollScheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &pollScheduler{ctx: ctx, cancel: cancel}
+}
+
+// next cancels the current generation and returns a fresh one
+func (s *pollScheduler) next() (uint64, context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancel()
+	s.gen++
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s.gen, s.ctx
+}
+
+// context returns the context for gen, already cancelled if gen is stale
+func (s *pollScheduler) context(gen uint64) context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if gen != s.gen {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		return ctx
+	}
+	return s.ctx
+}
+
+// fetchScope carries the generation a fetch belongs to and a Horizon client
+// whose requests are cancelled together with that generation
+type fetchScope struct {
+	gen    uint64
+	ctx    context.Context
+	client *horizonclient.Client
+}
+
+// scope returns the fetch scope for the model's current generation
+func (m model) scope() fetchScope {
+	ctx := m.sched.context(m.gen)
+	return fetchScope{gen: m.gen, ctx: ctx, client: scopedClient(m.client, ctx)}
+}
+
+// stale reports whether the scope's generation has been superseded
+func (sc fetchScope) stale() bool {
+	return sc.ctx.Err() != nil
+}
+
+// scopedClient clones client so every request it sends is bound to ctx
+func scopedClient(client *horizonclient.Client, ctx context.Context) *horizonclient.Client {
+	if client == nil {
+		return nil
+	}
+	var base horizonclient.HTTP = http.DefaultClient
+	if client.HTTP != nil {
+		base = client.HTTP
+	}
+	return &horizonclient.Client{
+		HorizonURL: client.HorizonURL,
+		HTTP:       contextHTTP{ctx: ctx, base: base},
+		AppName:    client.AppName,
+		AppVersion: client.AppVersion,
+	}
+}
+
+// contextHTTP attaches a context to every request sent through base
+type contextHTTP struct {
+	ctx  context.Context
+	base horizonclient.HTTP
+}
+
+// Do sends req on a context that ends with either the request's own
+// context, which carries the client's timeout, or the scope's
+func (c contextHTTP) Do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	stop := context.AfterFunc(c.ctx, cancel)
+	done := func() {
+		stop()
+		cancel()
+	}
+	resp, err := c.base.Do(req.WithContext(ctx))
+	if err != nil {
+		done()
+		return nil, err
+	}
+	// the body is read after Do returns, so the context ends with it
+	resp.Body = &doneBody{ReadCloser: resp.Body, done: done}
+	return resp, nil
+}
+
+// doneBody runs done once the body is closed
+type doneBody struct {
+	io.ReadCloser
+	done func()
+	once sync.Once
+}
+
+func (b *doneBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
+
+func (c contextHTTP) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.base.Do(req)
+}
+
+func (c contextHTTP) PostForm(url string, data url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodPost, url, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.base.Do(req)
+}
+
+// Generation-tagged ticks
+
+func orderbookTick(gen uint64) tea.Cmd {
+	return tea.Tick(orderbookInterval, func(time.Time) tea.Msg { return orderbookTickMsg{gen: gen} })
+}
+
+func tradesTick(gen uint64) tea.Cmd {
+	return tea.Tick(tradesInterval, func(time.Time) tea.Msg { return tradesTickMsg{gen: gen} })
+}
+
+func lpTick(gen uint64) tea.Cmd {
+	return tea.Tick(lpInterval, func(time.Time) tea.Msg { return lpTickMsg{gen: gen} })
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/sdexmon/sdexmon/internal/config"
+	"github.com/sdexmon/sdexmon/internal/metrics"
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+)
+
+const (
+	serveMaxConcurrent = 4                // pairs polled at once, to stay polite with Horizon
+	servePairTimeout   = 20 * time.Second // deadline for one pair's poll of a feed
+)
+
+// depthBands are the distances from mid, in percent, exported as book depth
+var depthBands = []*big.Rat{big.NewRat(1, 1), big.NewRat(2, 1)}
+
+// runServe implements `sdexmon serve`: it polls every configured pair in the
+// background and exposes the market view as Prometheus gauges
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("metrics", ":9109", "address to serve /metrics on")
+	interval := fs.Duration("interval", 15*time.Second, "order book and trades poll interval")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	ex := newExporter(newClient())
+	for _, opt := range configuredPairs {
+		base, quote, ok := opt.assets()
+		if !ok {
+			log.Printf("serve: skipping pair %s/%s: unknown asset", opt.Base, opt.Quote)
+			continue
+		}
+		ex.pairs = append(ex.pairs, servedPair{name: assetString(base) + "/" + assetString(quote), base: base, quote: quote})
+	}
+	if len(ex.pairs) == 0 {
+		fmt.Fprintln(os.Stderr, "serve: no pairs to poll")
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", ex.reg.Handler())
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, "sdexmon %s exporter, %d pairs\nmetrics at /metrics\n", appVersion, len(ex.pairs))
+	})
+	srv := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go ex.run(ctx, *interval)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+	log.Printf("serve: polling %d %s pairs every %s via %s, metrics on %s", len(ex.pairs), config.ActiveNetwork().Name, *interval, ex.client.HorizonURL, *addr)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "serve: %v\n", err)
+			return 1
+		}
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}
+	return 0
+}
+
+type servedPair struct {
+	name        string // pair label; code:issuer, so same-code assets stay apart
+	base, quote txnbuild.Asset
+}
+
+// exporter polls pairs and network stats into a metrics registry
+type exporter struct {
+	client *horizonclient.Client
+	reg    *metrics.Registry
+	pairs  []servedPair
+}
+
+func newExporter(client *horizonclient.Client) *exporter {
+	reg := metrics.NewRegistry()
+	reg.NewGauge("sdexmon_best_bid", "Best bid price of the merged order book.")
+	reg.NewGauge("sdexmon_best_ask", "Best ask price of the merged order book.")
+	reg.NewGauge("sdexmon_mid_price", "Mid price between best bid and best ask.")
+	reg.NewGauge("sdexmon_spread_percent", "Bid/ask spread as a percentage of mid.")
+	reg.NewGauge("sdexmon_depth_amount", "Base amount resting within the given percentage of mid.")
+	reg.NewGauge("sdexmon_last_trade_price", "Price of the most recent trade.")
+	reg.NewGauge("sdexmon_last_trade_timestamp_seconds", "Ledger close time of the most recent trade.")
+	reg.NewGauge("sdexmon_last_trade_age_seconds", "Seconds since the most recent trade, as of the last poll.")
+	reg.NewGauge("sdexmon_lp_locked", "Amount of each asset locked in the pair's liquidity pool.")
+	reg.NewGauge("sdexmon_network_capacity_usage", "Ledger capacity usage reported by Horizon fee_stats (0-1).")
+	reg.NewGauge("sdexmon_last_poll_success", "Whether the last poll of a feed succeeded (1) or failed (0).")
+	reg.NewCounter("sdexmon_poll_errors_total", "Failed polls by feed.")
+	return &exporter{client: client, reg: reg}
+}
+
+// run polls until ctx is cancelled, using the same cadences as the TUI for
+// liquidity pools and network stats
+func (ex *exporter) run(ctx context.Context, interval time.Duration) {
+	// Pool fetches can be slow, so they run beside the loop rather than in
+	// it, one round at a time
+	poolsIdle := make(chan struct{}, 1)
+	poolsIdle <- struct{}{}
+	pollPools := func() {
+		select {
+		case <-poolsIdle:
+		default:
+			return // the previous round is still running
+		}
+		go func() {
+			defer func() { poolsIdle <- struct{}{} }()
+			ex.pollPools(ctx)
+		}()
+	}
+
+	pollPools()
+	ex.pollMarkets(ctx)
+	ex.pollNetwork(ctx)
+
+	marketTicker := time.NewTicker(interval)
+	lpTicker := time.NewTicker(lpInterval)
+	networkTicker := time.NewTicker(networkInterval)
+	defer marketTicker.Stop()
+	defer lpTicker.Stop()
+	defer networkTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-marketTicker.C:
+			ex.pollMarkets(ctx)
+		case <-lpTicker.C:
+			pollPools()
+		case <-networkTicker.C:
+			ex.pollNetwork(ctx)
+		}
+	}
+}
+
+// forEachPair runs fn for every pair, at most serveMaxConcurrent at a time.
+// Each pair gets its own deadline and a client bound to it, so one slow pair
+// does not use up the time of the others.
+func (ex *exporter) forEachPair(ctx context.Context, fn func(ctx context.Context, client *horizonclient.Client, p servedPair)) {
+	sem := make(chan struct{}, serveMaxConcurrent)
+	var wg sync.WaitGroup
+	for _, p := range ex.pairs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(p servedPair) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			pctx, cancel := context.WithTimeout(ctx, servePairTimeout)
+			defer cancel()
+			fn(pctx, scopedClient(ex.client, pctx), p)
+		}(p)
+	}
+	wg.Wait()
+}
+
+func (ex *exporter) pollMarkets(ctx context.Context) {
+	ex.forEachPair(ctx, func(_ context.Context, client *horizonclient.Client, p servedPair) {
+		ob, err := fetchMergedOrderbook(client, p.base, p.quote)
+		if ex.record(p, "orderbook", err) {
+			ex.setOrderbook(p, ob)
+		}
+		trades, err := fetchTradesSince(client, p.base, p.quote, "")
+		if ex.record(p, "trades", err) {
+			ex.setLastTrade(p, trades)
+		}
+	})
+}
+
+func (ex *exporter) pollPools(ctx context.Context) {
+	ex.forEachPair(ctx, func(ctx context.Context, client *horizonclient.Client, p servedPair) {
+		poolID, _ := resolvePoolID(client, p.base, p.quote)
+		if poolID == "" {
+			return
+		}
+		data, err := fetchLP(ctx, client, poolID)
+		if !ex.record(p, "liquidity_pool", err) {
+			return
+		}
+		for i := 0; i < 2; i++ {
+			v := data.LockedR[i]
+			if data.Codes[i] == "" || v == nil {
+				continue
+			}
+			ex.reg.Set("sdexmon_lp_locked", metrics.Labels{"pair": p.name, "pool": poolID, "asset": data.Codes[i]}, orderbook.Float(v))
+		}
+	})
+}
+
+func (ex *exporter) pollNetwork(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	capacity, err := fetchNetworkCapacity(ctx, ex.client)
+	labels := metrics.Labels{"feed": "network"}
+	if err != nil {
+		log.Printf("serve: network stats: %v", err)
+		ex.reg.Set("sdexmon_last_poll_success", labels, 0)
+		ex.reg.Add("sdexmon_poll_errors_total", labels, 1)
+		return
+	}
+	ex.reg.Set("sdexmon_last_poll_success", labels, 1)
+	ex.reg.Set("sdexmon_network_capacity_usage", nil, capacity)
+}
+
+// record updates the poll health series and reports whether err was nil
+func (ex *exporter) record(p servedPair, feed string, err error) bool {
+	labels := metrics.Labels{"pair": p.name, "feed": feed}
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			log.Printf("serve: %s %s: %v", p.name, feed, err)
+		}
+		ex.reg.Set("sdexmon_last_poll_success", labels, 0)
+		ex.reg.Add("sdexmon_poll_errors_total", labels, 1)
+		return false
+	}
+	ex.reg.Set("sdexmon_last_poll_success", labels, 1)
+	return true
+}
+
+func (ex *exporter) setOrderbook(p servedPair, ob orderbook.Book) {
+	labels := metrics.Labels{"pair": p.name}
+	ex.setOrDelete("sdexmon_best_bid", labels, ob.BestBid())
+	ex.setOrDelete("sdexmon_best_ask", labels, ob.BestAsk())
+	ex.setOrDelete("sdexmon_mid_price", labels, ob.Mid())
+	ex.setOrDelete("sdexmon_spread_percent", labels, ob.SpreadPercent())
+
+	for _, band := range depthBands {
+		within := band.FloatString(0) + "%"
+		bidLabels := metrics.Labels{"pair": p.name, "side": "bid", "within": within}
+		askLabels := metrics.Labels{"pair": p.name, "side": "ask", "within": within}
+		if ob.Mid() == nil {
+			ex.reg.Delete("sdexmon_depth_amount", bidLabels)
+			ex.reg.Delete("sdexmon_depth_amount", askLabels)
+			continue
+		}
+		bidDepth, askDepth := ob.DepthWithin(band)
+		ex.reg.Set("sdexmon_depth_amount", bidLabels, orderbook.Float(bidDepth))
+		ex.reg.Set("sdexmon_depth_amount", askLabels, orderbook.Float(askDepth))
+	}
+}
+
+func (ex *exporter) setLastTrade(p servedPair, trades []hProtocol.Trade) {
+	if len(trades) == 0 {
+		return
+	}
+	labels := metrics.Labels{"pair": p.name}
+	last := trades[len(trades)-1]
+	if price, err := strconv.ParseFloat(tradePriceString(last.Price), 64); err == nil {
+		ex.reg.Set("sdexmon_last_trade_price", labels, price)
+	}
+	closed := time.Time(last.LedgerCloseTime)
+	ex.reg.Set("sdexmon_last_trade_timestamp_seconds", labels, float64(closed.Unix()))
+	ex.reg.Set("sdexmon_last_trade_age_seconds", labels, time.Since(closed).Seconds())
+}
+
+// setOrDelete exports v and drops the series when it is unknown, so an empty
+// book does not report a zero price
+func (ex *exporter) setOrDelete(name string, labels metrics.Labels, v *big.Rat) {
+	if v == nil {
+		ex.reg.Delete(name, labels)
+		return
+	}
+	ex.reg.Set(name, labels, orderbook.Float(v))
+}
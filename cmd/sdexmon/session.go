@@ -0,0 +1,424 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/sdexmon/sdexmon/internal/config"
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+	"github.com/sdexmon/sdexmon/internal/session"
+)
+
+// Session event kinds
+const (
+	eventStart     = "start"
+	eventPair      = "pair"
+	eventOrderbook = "orderbook"
+	eventTrades    = "trades"
+	eventLP        = "lp"
+	eventNetwork   = "network"
+)
+
+const (
+	replayTickInterval = 100 * time.Millisecond
+	replaySeekStep     = 10 * time.Second
+	replaySeekLongStep = time.Minute
+)
+
+type sessionStart struct {
+	Version string `json:"version"`
+	Network string `json:"network"`
+}
+
+type sessionPair struct {
+	Base  string `json:"base"`
+	Quote string `json:"quote"`
+}
+
+type sessionLP struct {
+	PoolID string    `json:"pool_id"`
+	Data   Liquidity `json:"data"`
+}
+
+// runRecord implements `sdexmon record`: the normal TUI, with every order
+// book, trades, pool and network update it applies written to a session file
+func runRecord(args []string) int {
+	fs := flag.NewFlagSet("record", flag.ContinueOnError)
+	out := fs.String("out", "", "session file to write (JSON Lines)")
+	baseArg := fs.String("base", "", "start on this pair: base asset (curated code, CODE:ISSUER or native)")
+	quoteArg := fs.String("quote", "", "start on this pair: quote asset")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *out == "" {
+		fmt.Fprintln(os.Stderr, "record: --out is required")
+		fs.Usage()
+		return 2
+	}
+	var base, quote txnbuild.Asset
+	if *baseArg != "" || *quoteArg != "" {
+		var err1, err2 error
+		base, err1 = resolveAssetArg(*baseArg)
+		quote, err2 = resolveAssetArg(*quoteArg)
+		if err1 != nil || err2 != nil {
+			fmt.Fprintf(os.Stderr, "record: --base and --quote must both be valid assets\n")
+			return 2
+		}
+	}
+
+	rec, err := session.Create(*out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "record: %v\n", err)
+		return 1
+	}
+	defer rec.Close()
+	if err := rec.Record(eventStart, time.Now(), sessionStart{Version: appVersion, Network: config.ActiveNetwork().Name}); err != nil {
+		fmt.Fprintf(os.Stderr, "record: %v\n", err)
+		return 1
+	}
+
+	log.SetFlags(log.Ltime | log.Lmicroseconds)
+	client := newClient()
+	defer openTradeStore(client)()
+	openSLAStore()
+	openStatsStore()
+	m := initialModel(client, base, quote)
+	m.stream = newMarketStreamer(client)
+	var startCmd tea.Cmd
+	if base != nil && quote != nil {
+		m.currentScreen = screenPairInfo
+		m.status = "recording to " + filepath.Base(*out)
+		startCmd = m.startPairFeeds()
+	}
+
+	p := tea.NewProgram(recordingModel{model: m, rec: rec, startCmd: startCmd}, tea.WithAltScreen())
+	m.stream.send = p.Send
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "record: %v\n", err)
+		return 1
+	}
+	fmt.Print("\033[2J\033[H")
+	return 0
+}
+
+// recordingModel runs the TUI unchanged and writes the data updates the
+// model applied to the session
+type recordingModel struct {
+	model
+	rec      *session.Recorder
+	startCmd tea.Cmd
+	pairGen  uint64 // generation of the last recorded pair event
+	failed   bool
+}
+
+func (r recordingModel) Init() tea.Cmd {
+	return tea.Batch(r.model.Init(), r.startCmd)
+}
+
+func (r recordingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := r.model.Update(msg)
+	nm, ok := next.(model)
+	if !ok {
+		return next, cmd
+	}
+	r.model = nm
+	r.capture(msg)
+	return r, cmd
+}
+
+func (r *recordingModel) capture(msg tea.Msg) {
+	now := time.Now()
+	m := r.model
+	if m.gen != r.pairGen && m.base != nil && m.quote != nil {
+		r.pairGen = m.gen
+		r.write(eventPair, now, sessionPair{Base: getAssetName(m.base), Quote: getAssetName(m.quote)})
+	}
+	switch msg := msg.(type) {
+	case orderbookDataMsg:
+		if msg.gen == m.gen {
+			r.write(eventOrderbook, now, msg.ob)
+		}
+	case tradesDataMsg:
+		if msg.gen == m.gen && len(msg.list) > 0 {
+			r.write(eventTrades, now, msg.list)
+		}
+	case lpDataMsg:
+		if msg.gen == m.gen {
+			r.write(eventLP, now, sessionLP{PoolID: msg.poolID, Data: msg.data})
+		}
+	case networkStatsMsg:
+		r.write(eventNetwork, now, msg.capacityUsage)
+	}
+}
+
+func (r *recordingModel) write(kind string, at time.Time, v any) {
+	if err := r.rec.Record(kind, at, v); err != nil && !r.failed {
+		r.failed = true
+		log.Printf("Recording stopped: %v", err)
+		r.model.status = "recording failed: " + err.Error()
+	}
+}
+
+// runReplay implements `sdexmon replay`: it feeds a recorded session back
+// through the TUI on a virtual clock, without touching the network
+func runReplay(args []string) int {
+	path := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		path, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	speedArg := fs.String("speed", "1x", "playback speed, e.g. 4x or 0.5x")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if path == "" {
+		path = fs.Arg(0)
+	}
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "usage: sdexmon replay SESSION.jsonl [--speed 4x]")
+		return 2
+	}
+	speed, err := session.ParseSpeed(*speedArg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		return 2
+	}
+	events, err := session.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		return 1
+	}
+	if len(events) == 0 {
+		fmt.Fprintf(os.Stderr, "replay: %s has no events\n", path)
+		return 1
+	}
+
+	fresh := initialModel(nil, nil, nil)
+	fresh.status = "replaying " + filepath.Base(path)
+	r := replayModel{
+		model:  fresh,
+		fresh:  fresh,
+		player: session.NewPlayer(events, speed),
+		name:   filepath.Base(path),
+	}
+	if _, err := tea.NewProgram(r, tea.WithAltScreen()).Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		return 1
+	}
+	fmt.Print("\033[2J\033[H")
+	return 0
+}
+
+type replayTickMsg time.Time
+
+func replayTick() tea.Cmd {
+	return tea.Tick(replayTickInterval, func(t time.Time) tea.Msg { return replayTickMsg(t) })
+}
+
+// replayModel drives the TUI model from a session. Commands the model
+// returns are dropped, since they would fetch live data.
+type replayModel struct {
+	model
+	fresh  model // state to rebuild from when seeking backward
+	player *session.Player
+	name   string
+	last   time.Time
+	err    error
+}
+
+func (r replayModel) Init() tea.Cmd {
+	return replayTick()
+}
+
+func (r replayModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case replayTickMsg:
+		now := time.Time(msg)
+		elapsed := time.Duration(0)
+		if !r.last.IsZero() {
+			elapsed = now.Sub(r.last)
+		}
+		r.last = now
+		r.apply(r.player.Advance(elapsed))
+		return r, replayTick()
+	case tea.WindowSizeMsg:
+		// keep a line for the replay bar
+		msg.Height--
+		r.forward(msg)
+		return r, nil
+	case tea.KeyMsg:
+		if r.handleKey(msg) {
+			return r, tea.Quit
+		}
+		return r, nil
+	}
+	r.forward(msg)
+	return r, nil
+}
+
+// handleKey applies the replay controls and passes other keys to the model.
+// It reports whether to quit.
+func (r *replayModel) handleKey(msg tea.KeyMsg) bool {
+	typing := r.showImpact || r.showPaths || r.searchMode || r.currentScreen == screenMaintenance
+	switch msg.String() {
+	case "ctrl+c":
+		return true
+	case "q":
+		if !typing {
+			return true
+		}
+	case " ":
+		r.player.Paused = !r.player.Paused
+		return false
+	case "left", "right", "shift+left", "shift+right":
+		step := replaySeekStep
+		if strings.HasPrefix(msg.String(), "shift+") {
+			step = replaySeekLongStep
+		}
+		if strings.HasSuffix(msg.String(), "left") {
+			step = -step
+		}
+		r.seek(r.player.Position() + step)
+		return false
+	case "+", "=":
+		r.player.Speed = math.Min(64, r.player.Speed*2)
+		return false
+	case "-":
+		r.player.Speed = math.Max(0.125, r.player.Speed/2)
+		return false
+	}
+	r.forward(msg)
+	return false
+}
+
+func (r *replayModel) seek(to time.Duration) {
+	events, rewound := r.player.Seek(to)
+	if rewound {
+		fresh := r.fresh
+		fresh.width, fresh.height = r.width, r.height
+		fresh.showChart, fresh.showImpact, fresh.showPaths = r.showChart, r.showImpact, r.showPaths
+		fresh.impactInput, fresh.pathsInput = r.impactInput, r.pathsInput
+		fresh.trades = nil
+		r.model = fresh
+	}
+	r.apply(events)
+}
+
+// forward passes a message to the model, dropping its commands
+func (r *replayModel) forward(msg tea.Msg) {
+	if next, _ := r.model.Update(msg); next != nil {
+		if nm, ok := next.(model); ok {
+			r.model = nm
+		}
+	}
+}
+
+// apply turns session events back into the messages the model recorded
+func (r *replayModel) apply(events []session.Event) {
+	for _, e := range events {
+		if err := r.applyEvent(e); err != nil && r.err == nil {
+			r.err = fmt.Errorf("%s event at %s: %w", e.Kind, e.At.Format(time.RFC3339), err)
+		}
+	}
+}
+
+func (r *replayModel) applyEvent(e session.Event) error {
+	switch e.Kind {
+	case eventPair:
+		var p sessionPair
+		if err := json.Unmarshal(e.Data, &p); err != nil {
+			return err
+		}
+		base, err := config.ParseAsset(p.Base)
+		if err != nil {
+			return err
+		}
+		quote, err := config.ParseAsset(p.Quote)
+		if err != nil {
+			return err
+		}
+		r.base, r.quote = base, quote
+		r.currentScreen = screenPairInfo
+		r.showPairPopup = false
+		r.resetPairState()
+	case eventOrderbook:
+		var ob orderbook.Book
+		if err := json.Unmarshal(e.Data, &ob); err != nil {
+			return err
+		}
+		r.forward(orderbookDataMsg{gen: r.gen, ob: ob})
+	case eventTrades:
+		var list []hProtocol.Trade
+		if err := json.Unmarshal(e.Data, &list); err != nil {
+			return err
+		}
+		r.forward(tradesDataMsg{gen: r.gen, list: list})
+	case eventLP:
+		var lp sessionLP
+		if err := json.Unmarshal(e.Data, &lp); err != nil {
+			return err
+		}
+		r.forward(lpDataMsg{gen: r.gen, poolID: lp.PoolID, data: lp.Data})
+	case eventNetwork:
+		var capacity float64
+		if err := json.Unmarshal(e.Data, &capacity); err != nil {
+			return err
+		}
+		r.forward(networkStatsMsg{capacityUsage: capacity})
+	}
+	// unknown kinds, including start, carry nothing to show
+	return nil
+}
+
+func (r replayModel) View() string {
+	return r.model.View() + "\n" + r.replayBar()
+}
+
+// replayBar shows the playback state and controls under the TUI
+func (r replayModel) replayBar() string {
+	state := "▶"
+	switch {
+	case r.player.Done():
+		state = "■"
+	case r.player.Paused:
+		state = "⏸"
+	}
+	pos := r.player.Position()
+	left := fmt.Sprintf(" REPLAY %s %s  %s / %s  %s  %gx", state, r.name,
+		formatOffset(pos), formatOffset(r.player.Duration()),
+		r.player.Start().Add(pos).Local().Format("2006-01-02 15:04:05"), r.player.Speed)
+	if r.err != nil {
+		left += "  " + r.err.Error()
+	}
+	right := "space: pause  ←/→: seek 10s  shift+←/→: 1m  +/-: speed "
+
+	width := r.width
+	if width <= 0 {
+		width = 140 // no size reported yet
+	}
+	// On narrow terminals the controls go first, then the end of the state
+	if len([]rune(left))+1+len([]rune(right)) > width {
+		right = ""
+	}
+	if l := []rune(left); len(l) > width {
+		left = string(l[:width])
+	}
+	gap := max(0, width-len([]rune(left))-len([]rune(right)))
+	return inverseStyle.Render(left + strings.Repeat(" ", gap) + right)
+}
+
+func formatOffset(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
@@ -0,0 +1,526 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/sdexmon/sdexmon/internal/config"
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+	"github.com/sdexmon/sdexmon/internal/sla"
+)
+
+const (
+	slaInterval  = 30 * time.Second // background sampling of pairs with obligations
+	slaLiveEvery = 10 * time.Second // sampling of the pair on screen, from its feed
+	// slaMaxGap is how long a sample stands for; longer gaps, such as while
+	// sdexmon is not running, are left out of the uptime
+	slaMaxGap     = 2 * slaInterval
+	slaBreachRows = 15
+)
+
+// slaWindows are the periods the SLA screen reports, chosen with 1-4
+var slaWindows = []struct {
+	label string
+	d     time.Duration
+}{{"1h", time.Hour}, {"24h", 24 * time.Hour}, {"7d", 7 * 24 * time.Hour}, {"30d", 30 * 24 * time.Hour}}
+
+// slaStore persists samples next to the config; nil when it cannot be opened
+var slaStore *sla.Store
+
+func openSLAStore() {
+	dir := filepath.Join(filepath.Dir(config.GetConfigPath()), "sla")
+	s, err := sla.Open(dir)
+	if err != nil {
+		log.Printf("SLA sampling disabled: %v", err)
+		return
+	}
+	slaStore = s
+}
+
+// slaTarget is an obligation with its pair resolved
+type slaTarget struct {
+	ob          sla.Obligation
+	base, quote txnbuild.Asset
+}
+
+// slaTargets resolves the configured obligations; ones with unknown assets
+// are reported and skipped
+func slaTargets() ([]slaTarget, []string) {
+	if appConfig == nil {
+		return nil, nil
+	}
+	var targets []slaTarget
+	var problems []string
+	for _, c := range appConfig.SLA {
+		codes := strings.SplitN(c.Pair, "/", 2)
+		var base, quote txnbuild.Asset
+		var err1, err2 error
+		if len(codes) == 2 {
+			base, err1 = resolveAssetArg(codes[0])
+			quote, err2 = resolveAssetArg(codes[1])
+		}
+		if len(codes) != 2 || err1 != nil || err2 != nil {
+			problems = append(problems, fmt.Sprintf("sla %s: unknown pair", c.Pair))
+			continue
+		}
+		ob := sla.Obligation{Pair: tradeStoreKey(base, quote), Label: c.Pair, MaxSpreadBps: c.MaxSpreadBps,
+			MinDepth: c.MinDepth, DepthAsset: assetShort(quote), DepthInQuote: true, DepthPct: c.DepthPct}
+		switch {
+		case c.DepthAsset == "" || strings.EqualFold(c.DepthAsset, assetShort(quote)):
+		case strings.EqualFold(c.DepthAsset, assetShort(base)):
+			ob.DepthAsset, ob.DepthInQuote = assetShort(base), false
+		default:
+			problems = append(problems, fmt.Sprintf("sla %s: depth_asset %s is neither side of the pair", c.Pair, c.DepthAsset))
+			continue
+		}
+		if ob.MaxSpreadBps <= 0 && ob.MinDepth <= 0 {
+			problems = append(problems, fmt.Sprintf("sla %s: needs max_spread_bps or min_depth", c.Pair))
+			continue
+		}
+		targets = append(targets, slaTarget{ob: ob, base: base, quote: quote})
+	}
+	return targets, problems
+}
+
+type (
+	slaTickMsg   struct{}
+	slaReportMsg struct {
+		gen     uint64
+		reports []slaReport
+		err     error
+	}
+)
+
+// slaReport is an obligation's report with its latest sample
+type slaReport struct {
+	sla.Report
+	last    sla.Sample
+	hasLast bool
+}
+
+func slaTick() tea.Cmd {
+	return tea.Tick(slaInterval, func(time.Time) tea.Msg { return slaTickMsg{} })
+}
+
+// storeSLACmd appends samples off the UI goroutine
+func storeSLACmd(samples []sla.Sample) tea.Cmd {
+	if slaStore == nil || len(samples) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := slaStore.Append(samples...); err != nil {
+			log.Printf("SLA samples: %v", err)
+		}
+		return nil
+	}
+}
+
+// sampleLiveSLA measures the pair on screen from its feed, at most every
+// slaLiveEvery
+func (m *model) sampleLiveSLA(ob orderbook.Book, at time.Time) tea.Cmd {
+	if slaStore == nil || m.base == nil || m.quote == nil {
+		return nil
+	}
+	key := tradeStoreKey(m.base, m.quote)
+	if key == m.slaLiveKey && at.Sub(m.slaLiveAt) < slaLiveEvery {
+		return nil
+	}
+	targets, _ := slaTargets()
+	for _, t := range targets {
+		if t.ob.Pair == key {
+			m.slaLiveAt, m.slaLiveKey = at, key
+			return storeSLACmd([]sla.Sample{t.ob.Measure(ob, at)})
+		}
+	}
+	return nil
+}
+
+// pollSLA samples every obligated pair. The pair on screen is left to its
+// feed while that keeps sampling it; a quiet or failed feed sends no
+// updates, so the pair is then fetched like the others.
+func (m model) pollSLA() tea.Cmd {
+	if slaStore == nil {
+		return nil
+	}
+	live := ""
+	if m.base != nil && m.quote != nil && time.Since(m.slaLiveAt) < slaInterval {
+		if key := tradeStoreKey(m.base, m.quote); key == m.slaLiveKey {
+			live = key
+		}
+	}
+	targets, _ := slaTargets()
+	client := m.client
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), overviewTimeout)
+		defer cancel()
+		c := scopedClient(client, ctx)
+		var polled []slaTarget
+		for _, t := range targets {
+			if t.ob.Pair != live {
+				polled = append(polled, t)
+			}
+		}
+		var samples []sla.Sample
+		for _, got := range fanOut(ctx, len(polled), func(i int) []sla.Sample {
+			t := polled[i]
+			ob, err := fetchMergedOrderbook(c, t.base, t.quote)
+			if err != nil {
+				// unmeasured rather than a breach: the book was not seen
+				return nil
+			}
+			return []sla.Sample{t.ob.Measure(ob, time.Now())}
+		}) {
+			samples = append(samples, got...)
+		}
+		if err := slaStore.Append(samples...); err != nil {
+			log.Printf("SLA samples: %v", err)
+		}
+		return nil
+	}
+}
+
+// buildSLAReports evaluates every obligation over [from, to)
+func buildSLAReports(targets []slaTarget, from, to time.Time) ([]slaReport, error) {
+	if slaStore == nil {
+		return nil, fmt.Errorf("no sample store")
+	}
+	samples, err := slaStore.Load("", from, to)
+	if err != nil {
+		return nil, err
+	}
+	byPair := map[string][]sla.Sample{}
+	for _, s := range samples {
+		byPair[s.Pair] = append(byPair[s.Pair], s)
+	}
+	out := make([]slaReport, len(targets))
+	for i, t := range targets {
+		pairSamples := byPair[t.ob.Pair]
+		out[i].Report = sla.Evaluate(t.ob, pairSamples, from, to, slaMaxGap)
+		for _, s := range pairSamples {
+			if !out[i].hasLast || s.At.After(out[i].last.At) {
+				out[i].last, out[i].hasLast = s, true
+			}
+		}
+	}
+	return out, nil
+}
+
+func (m model) openSLA() (tea.Model, tea.Cmd) {
+	m.showRefreshing(screenSLA)
+	m.slaNote = ""
+	return m, m.startRefreshing()
+}
+
+func (m model) refreshSLA() tea.Cmd {
+	gen, window := m.slaGen, slaWindows[m.slaWindow].d
+	targets, _ := slaTargets()
+	return func() tea.Msg {
+		to := time.Now()
+		reports, err := buildSLAReports(targets, to.Add(-window), to)
+		return slaReportMsg{gen: gen, reports: reports, err: err}
+	}
+}
+
+func (m model) handleSLAKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m, cmd, ok := m.handleRefreshingKeys(msg, "l"); ok {
+		return m, cmd
+	}
+	switch k := msg.String(); k {
+	case "1", "2", "3", "4":
+		m.slaWindow = int(k[0] - '1')
+		return m, m.refreshSLA()
+	}
+	return m, nil
+}
+
+func slaPct(v float64) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", v*100)
+}
+
+func slaBps(v float64) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", v)
+}
+
+func slaObligationText(o sla.Obligation) string {
+	var parts []string
+	if o.MaxSpreadBps > 0 {
+		parts = append(parts, fmt.Sprintf("spread ≤ %gbp", o.MaxSpreadBps))
+	}
+	if o.MinDepth > 0 {
+		pct := o.DepthPct
+		if pct <= 0 {
+			pct = sla.DefaultDepthPct
+		}
+		parts = append(parts, fmt.Sprintf("%s %s each side within %g%%", formatPrice(o.MinDepth), o.DepthAsset, pct))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func slaView(m model) string {
+	var lines []string
+	targets, problems := slaTargets()
+	window := slaWindows[m.slaWindow]
+	switch {
+	case len(targets) == 0:
+		lines = append(lines, dimStyle.Render("no obligations configured; add sla to config.yaml"))
+	case m.slaReports == nil && m.slaNote == "":
+		lines = append(lines, dimStyle.Render("loading..."))
+	default:
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("%-14s %-44s %8s %9s %8s %10s  %s",
+			"PAIR", "OBLIGATION", "UPTIME", "MEASURED", "BREACHES", "WORST BP", "NOW")))
+		var breaches []struct {
+			label string
+			iv    sla.Interval
+		}
+		for _, r := range m.slaReports {
+			now := dimStyle.Render("no samples")
+			if r.hasLast {
+				if reasons := r.Obligation.Check(r.last); len(reasons) > 0 {
+					now = errorStyle.Render("BREACH " + sla.ReasonText(reasons))
+				} else {
+					now = greenStyle.Render("ok")
+				}
+				now += dimStyle.Render(" " + r.last.At.Local().Format("15:04:05"))
+			}
+			uptime := slaPct(r.Uptime())
+			if u := r.Uptime(); !math.IsNaN(u) && u < 1 {
+				uptime = warnStyle.Render(uptime)
+			}
+			lines = append(lines, fmt.Sprintf("%-14s %-44s %8s %9s %8d %10s  %s",
+				truncateMiddle(r.Obligation.Label, 14), truncateMiddle(slaObligationText(r.Obligation), 44),
+				uptime, humanElapsedShort(r.Measured), len(r.Breaches), slaBps(r.WorstSpreadBps), now))
+			for _, b := range r.Breaches {
+				breaches = append(breaches, struct {
+					label string
+					iv    sla.Interval
+				}{r.Obligation.Label, b})
+			}
+		}
+		sort.Slice(breaches, func(i, j int) bool { return breaches[i].iv.From.After(breaches[j].iv.From) })
+		if len(breaches) > 0 {
+			lines = append(lines, "", boldStyle.Render("BREACHES"), dimStyle.Render(fmt.Sprintf("%-19s %8s  %-14s %10s  %s",
+				"FROM", "LASTED", "PAIR", "WORST BP", "WHY")))
+			for i, b := range breaches {
+				if i == slaBreachRows {
+					lines = append(lines, dimStyle.Render(fmt.Sprintf("… %d more", len(breaches)-slaBreachRows)))
+					break
+				}
+				lines = append(lines, fmt.Sprintf("%-19s %8s  %-14s %10s  %s", b.iv.From.Local().Format("2006-01-02 15:04:05"),
+					humanElapsedShort(b.iv.To.Sub(b.iv.From)), truncateMiddle(b.label, 14), slaBps(b.iv.WorstSpreadBps),
+					sla.ReasonText(b.iv.Reasons)))
+			}
+		}
+	}
+	info := fmt.Sprintf("last %s; sampled every %s while sdexmon runs, gaps over %s are not counted",
+		window.label, slaInterval, slaMaxGap)
+	lines = append(lines, "", dimStyle.Render(info))
+	if m.slaNote != "" {
+		problems = append(problems, m.slaNote)
+	}
+	if len(problems) > 0 {
+		lines = append(lines, errorStyle.Render(strings.Join(problems, "; ")))
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		renderVersionInfo(),
+		"",
+		renderHeader(),
+		renderSubtitle("SLA - spread and depth obligations"),
+		panelStyle.Render(strings.Join(lines, "\n")),
+	)
+	targetHeight := 60
+	if m.height > 0 {
+		targetHeight = m.height
+	}
+	padding := strings.Repeat("\n", max(0, targetHeight-lipgloss.Height(content)-2))
+	return lipgloss.JoinVertical(lipgloss.Left, content, padding, m.bottomLine())
+}
+
+// slaReportDoc is the JSON printed by `sdexmon sla report`; unknown values
+// are null
+type slaReportDoc struct {
+	Pair           string          `json:"pair"`
+	Obligation     string          `json:"obligation"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	Samples        int             `json:"samples"`
+	MeasuredSecs   float64         `json:"measured_seconds"`
+	UptimePct      *float64        `json:"uptime_pct"`
+	WorstSpreadBps *float64        `json:"worst_spread_bps"`
+	WorstAt        *time.Time      `json:"worst_at,omitempty"`
+	Breaches       []slaBreachJSON `json:"breaches"`
+}
+
+type slaBreachJSON struct {
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	Reasons        []string  `json:"reasons"`
+	WorstSpreadBps *float64  `json:"worst_spread_bps"`
+}
+
+func finiteOrNil(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
+
+// runSLA implements `sdexmon sla report`
+func runSLA(args []string) int {
+	if len(args) == 0 || args[0] != "report" {
+		fmt.Fprintln(os.Stderr, "usage: sdexmon sla report [--from T] [--to T] [--pair BASE/QUOTE] [--format table|json]")
+		return 2
+	}
+	fs := flag.NewFlagSet("sla report", flag.ContinueOnError)
+	fromArg := fs.String("from", "24h", "start: RFC3339 time, YYYY-MM-DD, or a duration ago such as 24h or 7d")
+	toArg := fs.String("to", "now", "end, in the same forms as --from")
+	pairArg := fs.String("pair", "", "only this pair, as written in the config")
+	format := fs.String("format", "table", "output format: table or json")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	now := time.Now()
+	from, err := parseWhen(*fromArg, now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sla: --from: %v\n", err)
+		return 2
+	}
+	to, err := parseWhen(*toArg, now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sla: --to: %v\n", err)
+		return 2
+	}
+	if !from.Before(to) {
+		fmt.Fprintln(os.Stderr, "sla: --from must be before --to")
+		return 2
+	}
+
+	targets, problems := slaTargets()
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "sla: %s\n", p)
+	}
+	if *pairArg != "" {
+		var kept []slaTarget
+		for _, t := range targets {
+			if strings.EqualFold(t.ob.Label, *pairArg) {
+				kept = append(kept, t)
+			}
+		}
+		targets = kept
+	}
+	if len(targets) == 0 {
+		fmt.Fprintln(os.Stderr, "sla: no obligations configured for that; add sla to config.yaml")
+		return 1
+	}
+	openSLAStore()
+	reports, err := buildSLAReports(targets, from, to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sla: %v\n", err)
+		return 1
+	}
+
+	switch *format {
+	case "json":
+		docs := make([]slaReportDoc, len(reports))
+		for i, r := range reports {
+			d := slaReportDoc{Pair: r.Obligation.Label, Obligation: slaObligationText(r.Obligation), From: from, To: to,
+				Samples: r.Samples, MeasuredSecs: r.Measured.Seconds(), WorstSpreadBps: finiteOrNil(r.WorstSpreadBps),
+				Breaches: []slaBreachJSON{}}
+			if u := finiteOrNil(r.Uptime()); u != nil {
+				pct := *u * 100
+				d.UptimePct = &pct
+			}
+			if !r.WorstAt.IsZero() {
+				at := r.WorstAt
+				d.WorstAt = &at
+			}
+			for _, b := range r.Breaches {
+				d.Breaches = append(d.Breaches, slaBreachJSON{From: b.From, To: b.To, Reasons: b.Reasons,
+					WorstSpreadBps: finiteOrNil(b.WorstSpreadBps)})
+			}
+			docs[i] = d
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(docs); err != nil {
+			fmt.Fprintf(os.Stderr, "sla: %v\n", err)
+			return 1
+		}
+	case "table":
+		writeSLATable(os.Stdout, reports, from, to)
+	default:
+		fmt.Fprintf(os.Stderr, "sla: unknown format %q\n", *format)
+		return 2
+	}
+	return 0
+}
+
+func writeSLATable(w io.Writer, reports []slaReport, from, to time.Time) {
+	fmt.Fprintf(w, "SLA report %s to %s\n", from.Local().Format(time.RFC3339), to.Local().Format(time.RFC3339))
+	for _, r := range reports {
+		fmt.Fprintf(w, "\n%s  (%s)\n", r.Obligation.Label, slaObligationText(r.Obligation))
+		fmt.Fprintf(w, "uptime %s over %s measured, %d samples, worst spread %sbp",
+			slaPct(r.Uptime()), humanElapsedShort(r.Measured), r.Samples, slaBps(r.WorstSpreadBps))
+		if !r.WorstAt.IsZero() {
+			fmt.Fprintf(w, " at %s", r.WorstAt.Local().Format(time.RFC3339))
+		}
+		fmt.Fprintln(w)
+		if len(r.Breaches) == 0 {
+			fmt.Fprintln(w, "no breaches")
+			continue
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "FROM\tTO\tLASTED\tWORST BP\tWHY")
+		for _, b := range r.Breaches {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", b.From.Local().Format(time.RFC3339), b.To.Local().Format(time.RFC3339),
+				humanElapsedShort(b.To.Sub(b.From)), slaBps(b.WorstSpreadBps), sla.ReasonText(b.Reasons))
+		}
+		tw.Flush()
+	}
+}
+
+// parseWhen reads "now", an RFC3339 time, a local date, or a duration ago
+// such as 90m, 24h or 7d
+func parseWhen(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "now" {
+		return now, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time, date or duration", s)
+}
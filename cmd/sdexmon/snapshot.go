@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/sdexmon/sdexmon/internal/config"
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+)
+
+// snapshotDoc is the JSON document printed by `sdexmon snapshot`
+type snapshotDoc struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	Network     string            `json:"network"`
+	Horizon     string            `json:"horizon"`
+	Pair        snapshotPair      `json:"pair"`
+	Orderbook   snapshotOrderbook `json:"orderbook"`
+	Liquidity   *snapshotLP       `json:"liquidity_pool,omitempty"`
+	Trades      []snapshotTrade   `json:"trades"`
+	Errors      []string          `json:"errors,omitempty"`
+}
+
+type snapshotPair struct {
+	Name  string `json:"name"`
+	Base  string `json:"base"`
+	Quote string `json:"quote"`
+}
+
+type snapshotOrderbook struct {
+	BestBid   string          `json:"best_bid"`
+	BestAsk   string          `json:"best_ask"`
+	Mid       string          `json:"mid"`
+	SpreadPct string          `json:"spread_pct"`
+	Bids      []snapshotLevel `json:"bids"`
+	Asks      []snapshotLevel `json:"asks"`
+}
+
+type snapshotLevel struct {
+	Price  string `json:"price"`
+	Amount string `json:"amount"`
+}
+
+type snapshotLP struct {
+	ID       string              `json:"id"`
+	Reserves []snapshotLPReserve `json:"reserves,omitempty"`
+	Note     string              `json:"note,omitempty"`
+}
+
+type snapshotLPReserve struct {
+	Code     string `json:"code"`
+	Locked   string `json:"locked"`
+	Fees1d   string `json:"fees_1d"`
+	Fees7d   string `json:"fees_7d"`
+	Volume1d string `json:"volume_1d"`
+	Volume7d string `json:"volume_7d"`
+}
+
+type snapshotTrade struct {
+	Time          time.Time `json:"time"`
+	Price         string    `json:"price"`
+	BaseAmount    string    `json:"base_amount"`
+	CounterAmount string    `json:"counter_amount"`
+	Side          string    `json:"side"` // "sell" when the base side sold, as colored in the TUI
+}
+
+// runSnapshot implements `sdexmon snapshot`: it fetches the merged order
+// book, liquidity pool and recent trades for one pair and prints them
+func runSnapshot(args []string) int {
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	baseArg := fs.String("base", "", "base asset: curated code, CODE:ISSUER or native")
+	quoteArg := fs.String("quote", "", "quote asset: curated code, CODE:ISSUER or native")
+	depth := fs.Int("depth", 7, "order book levels per side")
+	tradeLimit := fs.Int("trades", 20, "number of recent trades")
+	format := fs.String("format", "json", "output format: json or table")
+	timeout := fs.Duration("timeout", 20*time.Second, "overall timeout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *baseArg == "" || *quoteArg == "" {
+		fmt.Fprintln(os.Stderr, "snapshot: --base and --quote are required")
+		fs.Usage()
+		return 2
+	}
+	base, err := resolveAssetArg(*baseArg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "snapshot: base asset: %v\n", err)
+		return 2
+	}
+	quote, err := resolveAssetArg(*quoteArg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "snapshot: quote asset: %v\n", err)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	doc := buildSnapshot(ctx, base, quote, *depth, *tradeLimit)
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(doc); err != nil {
+			fmt.Fprintf(os.Stderr, "snapshot: %v\n", err)
+			return 1
+		}
+	case "table":
+		writeSnapshotTable(os.Stdout, doc)
+	default:
+		fmt.Fprintf(os.Stderr, "snapshot: unknown format %q\n", *format)
+		return 2
+	}
+	if len(doc.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// resolveAssetArg accepts the code of an asset in the network's configured
+// pairs, or on pubnet a curated code, as well as the usual specs
+func resolveAssetArg(s string) (txnbuild.Asset, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, ":") {
+		code := strings.ToUpper(s)
+		for _, p := range configuredPairs {
+			base, quote, ok := p.assets()
+			if !ok {
+				continue
+			}
+			if assetShort(base) == code {
+				return base, nil
+			}
+			if assetShort(quote) == code {
+				return quote, nil
+			}
+		}
+		if a, ok := curatedAssets[code]; ok && onPubnet() {
+			return a, nil
+		}
+	}
+	return parseAsset(s)
+}
+
+func buildSnapshot(ctx context.Context, base, quote txnbuild.Asset, depth, tradeLimit int) snapshotDoc {
+	client := scopedClient(newClient(), ctx)
+	doc := snapshotDoc{
+		GeneratedAt: time.Now().UTC(),
+		Network:     config.ActiveNetwork().Name,
+		Horizon:     client.HorizonURL,
+		Pair: snapshotPair{
+			Name:  fmt.Sprintf("%s/%s", assetShort(base), assetShort(quote)),
+			Base:  getAssetName(base),
+			Quote: getAssetName(quote),
+		},
+		Trades: []snapshotTrade{},
+	}
+
+	ob, err := fetchMergedOrderbook(client, base, quote)
+	if err != nil {
+		doc.Errors = append(doc.Errors, fmt.Sprintf("orderbook: %v", err))
+	}
+	doc.Orderbook = snapshotOrderbookOf(ob, depth)
+
+	if poolID, note := resolvePoolID(client, base, quote); poolID == "" {
+		doc.Liquidity = &snapshotLP{Note: note}
+	} else {
+		doc.Liquidity = &snapshotLP{ID: poolID}
+		if data, err := fetchLP(ctx, client, poolID); err != nil {
+			doc.Liquidity.Note = fmt.Sprintf("Pool fetch error: %v", err)
+		} else {
+			for i := 0; i < 2; i++ {
+				doc.Liquidity.Reserves = append(doc.Liquidity.Reserves, snapshotLPReserve{
+					Code:     data.Codes[i],
+					Locked:   orderbook.Format(data.LockedR[i], 7),
+					Fees1d:   orderbook.Format(data.Fees1dR[i], 7),
+					Fees7d:   orderbook.Format(data.Fees7dR[i], 7),
+					Volume1d: orderbook.Format(data.Vol1dR[i], 7),
+					Volume7d: orderbook.Format(data.Vol7dR[i], 7),
+				})
+			}
+			if data.Truncated {
+				doc.Liquidity.Note = "trade history capped, volume and fees are low"
+			}
+		}
+	}
+
+	trades, err := fetchRecentTrades(client, base, quote, tradeLimit)
+	if err != nil {
+		doc.Errors = append(doc.Errors, fmt.Sprintf("trades: %v", err))
+	}
+	for _, t := range trades {
+		side := "buy"
+		if t.BaseIsSeller {
+			side = "sell"
+		}
+		doc.Trades = append(doc.Trades, snapshotTrade{
+			Time:          time.Time(t.LedgerCloseTime).UTC(),
+			Price:         tradePriceString(t.Price),
+			BaseAmount:    t.BaseAmount,
+			CounterAmount: t.CounterAmount,
+			Side:          side,
+		})
+	}
+	return doc
+}
+
+func snapshotOrderbookOf(ob orderbook.Book, depth int) snapshotOrderbook {
+	out := snapshotOrderbook{
+		BestBid: orderbook.Format(ob.BestBid(), 7),
+		BestAsk: orderbook.Format(ob.BestAsk(), 7),
+		Mid:     midPriceOf(ob),
+		Bids:    []snapshotLevel{},
+		Asks:    []snapshotLevel{},
+	}
+	if sp := spreadPercent(ob); sp != "-" {
+		out.SpreadPct = strings.TrimSuffix(sp, "%")
+	}
+	for i := 0; i < len(ob.Bids) && i < depth; i++ {
+		out.Bids = append(out.Bids, snapshotLevelOf(ob.Bids[i]))
+	}
+	for i := 0; i < len(ob.Asks) && i < depth; i++ {
+		out.Asks = append(out.Asks, snapshotLevelOf(ob.Asks[i]))
+	}
+	return out
+}
+
+func snapshotLevelOf(l orderbook.Level) snapshotLevel {
+	return snapshotLevel{Price: orderbook.Format(l.Price, 7), Amount: orderbook.Format(l.Amount, 7)}
+}
+
+func writeSnapshotTable(w io.Writer, doc snapshotDoc) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "%s  %s\n", doc.Pair.Name, doc.GeneratedAt.Format(time.RFC3339))
+	spread := "-"
+	if doc.Orderbook.SpreadPct != "" {
+		spread = doc.Orderbook.SpreadPct + "%"
+	}
+	fmt.Fprintf(w, "bid %s  ask %s  mid %s  spread %s\n\n",
+		firstNonEmpty(doc.Orderbook.BestBid, "-"), firstNonEmpty(doc.Orderbook.BestAsk, "-"),
+		firstNonEmpty(doc.Orderbook.Mid, "-"), spread)
+
+	fmt.Fprintln(tw, "SIDE\tPRICE\tAMOUNT\t")
+	for i := len(doc.Orderbook.Asks) - 1; i >= 0; i-- {
+		fmt.Fprintf(tw, "ask\t%s\t%s\t\n", doc.Orderbook.Asks[i].Price, doc.Orderbook.Asks[i].Amount)
+	}
+	for _, l := range doc.Orderbook.Bids {
+		fmt.Fprintf(tw, "bid\t%s\t%s\t\n", l.Price, l.Amount)
+	}
+	tw.Flush()
+
+	if lp := doc.Liquidity; lp != nil {
+		fmt.Fprintln(w)
+		if lp.Note != "" {
+			fmt.Fprintf(w, "pool: %s\n", lp.Note)
+		}
+		if len(lp.Reserves) > 0 {
+			fmt.Fprintln(tw, "CODE\tLOCKED\tFEES (1D)\tFEES (7D)\tVOLUME (1D)\tVOLUME (7D)\t")
+			for _, r := range lp.Reserves {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t\n", r.Code, r.Locked, r.Fees1d, r.Fees7d, r.Volume1d, r.Volume7d)
+			}
+			tw.Flush()
+		}
+	}
+
+	if len(doc.Trades) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(tw, "TIME\tSIDE\tPRICE\tAMOUNT\t")
+		for _, t := range doc.Trades {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", t.Time.Format("15:04:05"), t.Side, t.Price, t.BaseAmount)
+		}
+		tw.Flush()
+	}
+
+	for _, e := range doc.Errors {
+		fmt.Fprintf(w, "error: %s\n", e)
+	}
+}
+
+// fetchRecentTrades pages back through the pair's trades until it has
+// limit of them, newest first
+func fetchRecentTrades(client *horizonclient.Client, base, quote txnbuild.Asset, limit int) ([]hProtocol.Trade, error) {
+	var out []hProtocol.Trade
+	cursor := ""
+	for len(out) < limit {
+		req := horizonclient.TradeRequest{Order: horizonclient.OrderDesc, Cursor: cursor, Limit: uint(min(limit-len(out), 200))}
+		applyBaseAsset(&req, base)
+		applyCounterAsset(&req, quote)
+		page, err := client.Trades(req)
+		if err != nil {
+			return out, err
+		}
+		recs := page.Embedded.Records
+		out = append(out, recs...)
+		if len(recs) < int(req.Limit) {
+			break
+		}
+		cursor = recs[len(recs)-1].PagingToken()
+	}
+	return out, nil
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/sdexmon/sdexmon/internal/candles"
+	"github.com/sdexmon/sdexmon/internal/makers"
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+)
+
+const (
+	streamRetryMin    = 1 * time.Second
+	streamRetryMax    = 30 * time.Second
+	streamMaxFailures = 5 // consecutive failures before falling back to polling
+)
+
+// streamFailedMsg tells the model streaming gave up and polling should resume
+type streamFailedMsg struct {
+	gen uint64
+	err error
+}
+
+// streamingEnabled reports whether SSE streaming was requested via env or config
+func streamingEnabled() bool {
+	if v := os.Getenv("STREAMING"); v != "" {
+		return v == "true" || v == "1"
+	}
+	return appConfig != nil && appConfig.Preferences.Streaming
+}
+
+// marketStreamer feeds Horizon SSE order book and trade streams into the
+// running tea.Program. Only one pair is streamed at a time; starting a new
+// pair (or a new pair generation) cancels the previous streams.
+type marketStreamer struct {
+	client *horizonclient.Client
+	send   func(tea.Msg) // set to tea.Program.Send once the program exists
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+func newMarketStreamer(client *horizonclient.Client) *marketStreamer {
+	return &marketStreamer{client: client}
+}
+
+// start cancels any running streams and begins streaming the given pair
+// within the scope's generation. It runs in a command, and commands run in
+// no particular order, so a start for a superseded generation does nothing
+// rather than cancel the streams of a newer one.
+func (s *marketStreamer) start(sc fetchScope, base, quote txnbuild.Asset) {
+	s.mu.Lock()
+	if sc.stale() {
+		s.mu.Unlock()
+		return
+	}
+	if s.cancel != nil {
+		s.cancel()
+	}
+	ctx, cancel := context.WithCancel(sc.ctx)
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	sess := &streamSession{streamer: s, scope: sc, ctx: ctx, cancel: cancel}
+	go sess.runOrderbook(base, quote, false)
+	go sess.runOrderbook(quote, base, true)
+	go sess.runTrades(base, quote)
+}
+
+// stop cancels the running streams, if any
+func (s *marketStreamer) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+}
+
+// streamSession holds the state of one pair's streams
+type streamSession struct {
+	streamer *marketStreamer
+	scope    fetchScope
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	mu      sync.Mutex
+	direct  hProtocol.OrderBookSummary
+	reverse hProtocol.OrderBookSummary
+
+	failOnce sync.Once
+}
+
+// emit forwards a message to the program unless the session was cancelled
+func (ss *streamSession) emit(msg tea.Msg) {
+	if ss.ctx.Err() != nil || ss.streamer.send == nil {
+		return
+	}
+	ss.streamer.send(msg)
+}
+
+// fail stops the whole session and asks the model to fall back to polling
+func (ss *streamSession) fail(err error) {
+	ss.failOnce.Do(func() {
+		ss.emit(streamFailedMsg{gen: ss.scope.gen, err: err})
+		ss.cancel()
+	})
+}
+
+func (ss *streamSession) runOrderbook(selling, buying txnbuild.Asset, reverse bool) {
+	req := horizonclient.OrderBookRequest{}
+	applySellingAsset(&req, selling)
+	applyBuyingAsset(&req, buying)
+
+	name := fmt.Sprintf("orderbook %s/%s", assetShort(selling), assetShort(buying))
+	ss.retry(name, func(received *atomic.Bool) error {
+		return ss.streamer.client.StreamOrderBooks(ss.ctx, req, func(ob hProtocol.OrderBookSummary) {
+			received.Store(true)
+			ss.mu.Lock()
+			if reverse {
+				ss.reverse = ob
+			} else {
+				ss.direct = ob
+			}
+			merged := orderbook.Merge(ss.direct, ss.reverse)
+			ss.mu.Unlock()
+			ss.emit(orderbookDataMsg{gen: ss.scope.gen, ob: merged})
+		})
+	})
+}
+
+func (ss *streamSession) runTrades(base, quote txnbuild.Asset) {
+	// Bootstrap the tape over REST so the stream can resume from its last token
+	cursor := "now"
+	if msg, ok := fetchTradesCmd(ss.scope, base, quote, "", true)().(tradesDataMsg); ok {
+		ss.emit(msg)
+		if len(msg.list) > 0 {
+			cursor = msg.list[len(msg.list)-1].PagingToken()
+		}
+	}
+
+	name := fmt.Sprintf("trades %s/%s", assetShort(base), assetShort(quote))
+	ss.retry(name, func(received *atomic.Bool) error {
+		req := horizonclient.TradeRequest{Cursor: cursor}
+		applyBaseAsset(&req, base)
+		applyCounterAsset(&req, quote)
+		return ss.streamer.client.StreamTrades(ss.ctx, req, func(t hProtocol.Trade) {
+			received.Store(true)
+			cursor = t.PagingToken()
+			ss.emit(tradesDataMsg{gen: ss.scope.gen, list: []hProtocol.Trade{t}})
+		})
+	})
+}
+
+// retry keeps a stream connected with exponential backoff. Each reconnect
+// re-invokes open, so callers resume from their last paging token. After
+// streamMaxFailures attempts in a row without an event the session fails.
+func (ss *streamSession) retry(name string, open func(received *atomic.Bool) error) {
+	backoff := streamRetryMin
+	failures := 0
+	for {
+		var received atomic.Bool
+		err := open(&received)
+		if ss.ctx.Err() != nil {
+			return
+		}
+		if received.Load() {
+			failures = 0
+			backoff = streamRetryMin
+		}
+		if err == nil {
+			err = fmt.Errorf("stream closed")
+		}
+		failures++
+		log.Printf("Stream %s interrupted (%d/%d): %v", name, failures, streamMaxFailures, err)
+		if failures >= streamMaxFailures {
+			ss.fail(fmt.Errorf("%s: %w", name, err))
+			return
+		}
+
+		select {
+		case <-ss.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > streamRetryMax {
+			backoff = streamRetryMax
+		}
+	}
+}
+
+// startStreamCmd starts streaming the pair outside of Update
+func startStreamCmd(s *marketStreamer, sc fetchScope, base, quote txnbuild.Asset) tea.Cmd {
+	return func() tea.Msg {
+		s.start(sc, base, quote)
+		return nil
+	}
+}
+
+// pollFeedsCmd fetches the order book and trades once and schedules polling
+// for the model's current pair generation
+func pollFeedsCmd(m model) tea.Cmd {
+	sc := m.scope()
+	return tea.Batch(
+		fetchOrderbookCmd(sc, m.base, m.quote),
+		fetchTradesCmd(sc, m.base, m.quote, m.tradeCursor, true),
+		orderbookTick(sc.gen),
+		tradesTick(sc.gen),
+	)
+}
+
+// startPairFeeds starts a new pair generation, which cancels everything the
+// previous pair had in flight, and kicks off all data feeds for the newly
+// selected pair: streaming the order book and trades when enabled and
+// polling otherwise
+func (m *model) startPairFeeds() tea.Cmd {
+	m.resetPairState()
+
+	sc := m.scope()
+	cmds := []tea.Cmd{
+		resolveAndFetchLPCmd(sc, m.base, m.quote),
+		fetchBaseExposureCmd(sc, m.base),
+		fetchQuoteExposureCmd(sc, m.quote),
+	}
+	cmds = append(cmds, m.makerFeedCmds(sc)...)
+	if m.showChart {
+		cmds = append(cmds, m.reloadChartCmd())
+	} else {
+		m.candles = candles.Series{}
+	}
+	if m.stream != nil && streamingEnabled() {
+		m.streaming = true
+		cmds = append(cmds, startStreamCmd(m.stream, sc, m.base, m.quote))
+	} else {
+		m.streaming = false
+		if m.stream != nil {
+			m.stream.stop()
+		}
+		cmds = append(cmds, pollFeedsCmd(*m))
+	}
+	return tea.Batch(cmds...)
+}
+
+// resetPairState starts a new pair generation and clears the data shown for
+// the previous pair
+func (m *model) resetPairState() {
+	m.gen, _ = m.sched.next()
+	m.orderbook = orderbook.Book{}
+	m.trades = m.trades[:0]
+	m.tradeCursor = ""
+	m.tradeOffset, m.tradeHistory = 0, nil
+	m.showVolume, m.volumes, m.volumeNote = false, nil, ""
+	m.makerOffers, m.makerNote = makers.Set{}, ""
+	m.statsSide, m.statsSnap, m.statsHist, m.statsNote = statsOff, nil, nil, ""
+	m.statsGen++
+	m.lp = Liquidity{}
+	m.lpMessage = ""
+	m.paths = nil
+	m.pathsNote = ""
+}
+
+// feedMode describes how the order book and trades are being refreshed
+func (m model) feedMode() string {
+	if m.streaming {
+		return "streaming"
+	}
+	return "polling"
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+
+	"github.com/sdexmon/sdexmon/internal/config"
+	"github.com/sdexmon/sdexmon/internal/tradestore"
+)
+
+const (
+	defaultTradeHistoryDays = 7
+	tradeHistoryPage        = 15 // rows in the trades panel
+	volumeWindow            = 24 * time.Hour
+	volumeRows              = 10
+)
+
+// tradeStore keeps every trade seen, per network and pair. Nil when the
+// database could not be opened; everything here then does nothing.
+var tradeStore *tradestore.Store
+
+type (
+	tradeHistoryMsg struct {
+		gen    uint64
+		offset int
+		list   []hProtocol.Trade // newest first
+		err    error
+	}
+	volumeDataMsg struct {
+		gen  uint64
+		vols []tradestore.AccountVolume
+		err  error
+	}
+)
+
+// openTradeStore opens trades.db next to the config file and starts
+// backfilling the configured pairs. The returned func closes the store.
+func openTradeStore(client *horizonclient.Client) func() {
+	path := filepath.Join(filepath.Dir(config.GetConfigPath()), "trades.db")
+	s, err := tradestore.Open(path)
+	if err != nil {
+		log.Printf("Trade history disabled: %v", err)
+		return func() {}
+	}
+	tradeStore = s
+	ctx, cancel := context.WithCancel(context.Background())
+	go backfillPairs(ctx, client, append([]pairOption(nil), configuredPairs...))
+	return func() {
+		cancel()
+		s.Close()
+	}
+}
+
+// tradeStoreKey names a pair's bucket; the network keeps testnet trades apart
+// from pubnet ones
+func tradeStoreKey(base, quote txnbuild.Asset) string {
+	return config.ActiveNetwork().Name + " " + getAssetName(base) + "/" + getAssetName(quote)
+}
+
+func tradeHistoryDays() int {
+	if appConfig != nil && appConfig.Preferences.TradeHistoryDays > 0 {
+		return appConfig.Preferences.TradeHistoryDays
+	}
+	return defaultTradeHistoryDays
+}
+
+// backfillPairs fetches each pair's history, one pair at a time so startup
+// does not crowd out the live feeds
+func backfillPairs(ctx context.Context, client *horizonclient.Client, pairs []pairOption) {
+	since := time.Now().Add(-time.Duration(tradeHistoryDays()) * 24 * time.Hour)
+	for _, p := range pairs {
+		base, quote, ok := p.assets()
+		if !ok {
+			continue
+		}
+		req := horizonclient.TradeRequest{}
+		applyBaseAsset(&req, base)
+		applyCounterAsset(&req, quote)
+		n, err := tradeStore.Backfill(ctx, client, tradeStoreKey(base, quote), req, since)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("Trade history %s: %v", p.label(), err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("Trade history %s: %d trades backfilled", p.label(), n)
+		}
+	}
+}
+
+// storeTradesCmd saves trades the feeds delivered
+func storeTradesCmd(base, quote txnbuild.Asset, list []hProtocol.Trade) tea.Cmd {
+	if tradeStore == nil || base == nil || quote == nil || len(list) == 0 {
+		return nil
+	}
+	key := tradeStoreKey(base, quote)
+	trades := make([]tradestore.Trade, len(list))
+	for i, t := range list {
+		trades[i] = tradestore.FromHorizon(t)
+	}
+	return func() tea.Msg {
+		if _, err := tradeStore.Add(key, trades); err != nil {
+			log.Printf("Trade history: %v", err)
+		}
+		return nil
+	}
+}
+
+// loadTradeHistoryCmd reads a page of stored trades, offset trades back from
+// the newest
+func loadTradeHistoryCmd(gen uint64, base, quote txnbuild.Asset, offset int) tea.Cmd {
+	return func() tea.Msg {
+		stored, err := tradeStore.Latest(tradeStoreKey(base, quote), offset, tradeHistoryPage)
+		list := make([]hProtocol.Trade, len(stored))
+		for i, t := range stored {
+			list[i] = t.Horizon()
+		}
+		return tradeHistoryMsg{gen: gen, offset: offset, list: list, err: err}
+	}
+}
+
+func loadVolumeCmd(gen uint64, base, quote txnbuild.Asset) tea.Cmd {
+	return func() tea.Msg {
+		vols, err := tradeStore.VolumeByAccount(tradeStoreKey(base, quote), time.Now().Add(-volumeWindow))
+		return volumeDataMsg{gen: gen, vols: vols, err: err}
+	}
+}
+
+// scrollTrades pages the trades panel back (older) or forward through the
+// stored history. Offset 0 is the live view.
+func (m model) scrollTrades(older bool) (tea.Model, tea.Cmd) {
+	if tradeStore == nil {
+		m.status = "trade history unavailable"
+		return m, nil
+	}
+	offset := m.tradeOffset
+	if older {
+		if m.tradeOffset > 0 && len(m.tradeHistory) < tradeHistoryPage {
+			return m, nil // already at the oldest stored trade
+		}
+		offset += tradeHistoryPage
+	} else {
+		offset = max(0, offset-tradeHistoryPage)
+	}
+	if offset == 0 {
+		m.tradeOffset, m.tradeHistory = 0, nil
+		return m, nil
+	}
+	return m, loadTradeHistoryCmd(m.gen, m.base, m.quote, offset)
+}
+
+func (m model) toggleVolume() (tea.Model, tea.Cmd) {
+	m.showVolume = !m.showVolume
+	m.volumes, m.volumeNote = nil, ""
+	if !m.showVolume {
+		return m, nil
+	}
+	if tradeStore == nil {
+		m.volumeNote = "trade history unavailable"
+		return m, nil
+	}
+	m.volumeNote = "loading..."
+	return m, loadVolumeCmd(m.gen, m.base, m.quote)
+}
+
+// renderVolume lists the accounts and pools that traded most on the pair in
+// the last 24h, from the stored history
+func (m model) renderVolume() string {
+	rows := []string{boldStyle.Render("VOLUME BY ACCOUNT (24h)")}
+	if m.volumeNote != "" {
+		return strings.Join(append(rows, dimStyle.Render(m.volumeNote)), "\n")
+	}
+	if len(m.volumes) == 0 {
+		return strings.Join(append(rows, dimStyle.Render("no stored trades in the last 24h")), "\n")
+	}
+	rows = append(rows, dimStyle.Render(fmt.Sprintf("%-58s %6s  %16s  %16s", "ACCOUNT / POOL", "TRADES",
+		assetShort(m.base), assetShort(m.quote))))
+	for i, v := range m.volumes {
+		if i == volumeRows {
+			rows = append(rows, dimStyle.Render(fmt.Sprintf("… %d more", len(m.volumes)-volumeRows)))
+			break
+		}
+		rows = append(rows, fmt.Sprintf("%-58s %6d  %16s  %16s", truncateMiddle(v.Account, 58), v.Trades,
+			trimDecimalsKeepMin2(v.Base.FloatString(2)), trimDecimalsKeepMin2(v.Counter.FloatString(2))))
+	}
+	return lipgloss.NewStyle().Render(strings.Join(rows, "\n"))
+}
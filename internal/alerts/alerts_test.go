@@ -0,0 +1,136 @@
+package alerts
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func mustParse(t *testing.T, name, pair, cond string, forDur time.Duration) Rule {
+	t.Helper()
+	r, err := Parse(name, pair, pair, cond, forDur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestParse(t *testing.T) {
+	r := mustParse(t, "lp", "USDZ/ZARZ", "lp_locked_change(usdz, 1h) <= -20%", 0)
+	if r.Metric != MetricLPLockedChange || r.Asset != "USDZ" || r.Window != time.Hour || r.Op != "<=" || r.Threshold != -20 {
+		t.Errorf("parsed %+v", r)
+	}
+	if r := mustParse(t, "quiet", "XLM/USDZ", "trade_age > 30m", 0); r.Threshold != 1800 {
+		t.Errorf("trade_age threshold %v", r.Threshold)
+	}
+	for _, bad := range []struct{ pair, cond string }{
+		{"A/B", "spread_bps = 50"},
+		{"A/B", "volume > 1"},
+		{"A/B", "lp_locked_change(USDZ) < -20%"},
+		{"", "spread_bps > 50"},
+		{"A/B", "spread_bps > lots"},
+	} {
+		if _, err := Parse("bad", bad.pair, bad.pair, bad.cond, 0); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("Parse(%q, %q) = %v", bad.pair, bad.cond, err)
+		}
+	}
+}
+
+func TestRuleMustHoldForItsDuration(t *testing.T) {
+	e := NewEngine([]Rule{mustParse(t, "wide", "USDZ/ZARZ", "spread_bps > 50", 2*time.Minute)})
+	spread := func(v float64, at time.Duration) []Event {
+		return e.Observe(Sample{Pair: "USDZ/ZARZ", Metric: MetricSpread, Value: v, At: t0.Add(at)})
+	}
+	if ev := spread(60, 0); len(ev) != 0 {
+		t.Fatalf("fired at once: %+v", ev)
+	}
+	spread(40, time.Minute) // dips back, restarting the clock
+	spread(70, 90*time.Second)
+	if ev := spread(70, 3*time.Minute); len(ev) != 0 {
+		t.Fatalf("fired after 90s: %+v", ev)
+	}
+	ev := spread(80, 3*time.Minute+30*time.Second)
+	if len(ev) != 1 || ev[0].Resolved || ev[0].Alert.Value != 80 || !ev[0].Alert.Since.Equal(t0.Add(90*time.Second)) {
+		t.Fatalf("firing: %+v", ev)
+	}
+	if ev := spread(90, 4*time.Minute); len(ev) != 0 {
+		t.Errorf("fired twice: %+v", ev)
+	}
+	if e.Unacked() != 1 {
+		t.Errorf("unacked %d", e.Unacked())
+	}
+	e.Ack(e.Active()[0].ID)
+	if e.Unacked() != 0 || !e.Active()[0].Acked {
+		t.Errorf("ack did not stick")
+	}
+	if ev := spread(10, 5*time.Minute); len(ev) != 1 || !ev[0].Resolved {
+		t.Errorf("resolve: %+v", ev)
+	}
+	if len(e.Active()) != 0 || len(e.History()) != 1 {
+		t.Errorf("active %d history %d", len(e.Active()), len(e.History()))
+	}
+}
+
+func TestTradeAgeFiresWithoutNewSamples(t *testing.T) {
+	e := NewEngine([]Rule{mustParse(t, "quiet", "XLM/USDZ", "trade_age > 30m", 0)})
+	e.Observe(LastTrade("XLM/USDZ", t0, t0.Add(time.Minute)))
+	if ev := e.Evaluate(t0.Add(29 * time.Minute)); len(ev) != 0 {
+		t.Fatalf("fired early: %+v", ev)
+	}
+	if ev := e.Evaluate(t0.Add(31 * time.Minute)); len(ev) != 1 {
+		t.Fatalf("did not fire: %+v", ev)
+	}
+	if ev := e.Observe(LastTrade("XLM/USDZ", t0.Add(32*time.Minute), t0.Add(32*time.Minute))); len(ev) != 1 || !ev[0].Resolved {
+		t.Errorf("a new trade should resolve: %+v", ev)
+	}
+}
+
+func TestLPLockedChangeNeedsAFullWindow(t *testing.T) {
+	e := NewEngine([]Rule{mustParse(t, "drain", "USDZ/ZARZ", "lp_locked_change(USDZ, 1h) < -20%", 0)})
+	locked := func(v float64, at time.Duration) []Event {
+		return e.Observe(Sample{Pair: "USDZ/ZARZ", Metric: MetricLPLocked, Asset: "USDZ", Value: v, At: t0.Add(at)})
+	}
+	locked(1000, 0)
+	locked(1000, 30*time.Minute)
+	if ev := locked(700, 50*time.Minute); len(ev) != 0 {
+		t.Fatalf("fired before an hour of history: %+v", ev)
+	}
+	ev := locked(750, 61*time.Minute)
+	if len(ev) != 1 || ev[0].Alert.Value != -25 {
+		t.Fatalf("want -25%% against the 0m point: %+v", ev)
+	}
+	// an hour later the baseline has moved to the 50m point
+	if ev := locked(700, 111*time.Minute); len(ev) != 1 || !ev[0].Resolved {
+		t.Errorf("should resolve against the 50m baseline: %+v", ev)
+	}
+}
+
+func TestAdoptKeepsUnchangedRulesFiring(t *testing.T) {
+	wide := mustParse(t, "wide", "USDZ/ZARZ", "spread_bps > 50", 0)
+	mid := mustParse(t, "mid", "USDZ/ZARZ", "mid_price > 2", 0)
+	old := NewEngine([]Rule{wide, mid})
+	if ev := old.Observe(
+		Sample{Pair: "USDZ/ZARZ", Metric: MetricSpread, Value: 60, At: t0},
+		Sample{Pair: "USDZ/ZARZ", Metric: MetricMid, Value: 3, At: t0},
+	); len(ev) != 2 {
+		t.Fatalf("firing: %+v", ev)
+	}
+
+	// "mid" is edited, "wide" stays as it was
+	e := NewEngine([]Rule{wide, mustParse(t, "mid", "USDZ/ZARZ", "mid_price > 5", 0)})
+	ev := e.Adopt(old, t0.Add(time.Minute))
+	if len(ev) != 1 || !ev[0].Resolved || ev[0].Alert.Rule != "mid" {
+		t.Fatalf("adopt should resolve the edited rule only: %+v", ev)
+	}
+	if ev := e.Evaluate(t0.Add(time.Minute)); len(ev) != 0 {
+		t.Errorf("the unchanged rule fired again: %+v", ev)
+	}
+	if active := e.Active(); len(active) != 1 || active[0].Rule != "wide" {
+		t.Errorf("active after adopt: %+v", active)
+	}
+	if ev := e.Observe(Sample{Pair: "USDZ/ZARZ", Metric: MetricSpread, Value: 10, At: t0.Add(2 * time.Minute)}); len(ev) != 1 || !ev[0].Resolved {
+		t.Errorf("the adopted alert should resolve: %+v", ev)
+	}
+}
@@ -0,0 +1,317 @@
+package alerts
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxHistory is how many alerts the engine remembers
+const maxHistory = 200
+
+// Sample is one observation of a market value
+type Sample struct {
+	Pair   string // pair key; empty for network samples
+	Metric string // spread_bps, mid_price, lp_locked, network_capacity, or last_trade via LastTrade
+	Asset  string // lp_locked
+	Value  float64
+	At     time.Time
+}
+
+// LastTrade is the sample trade_age rules are evaluated from
+func LastTrade(pair string, closed, at time.Time) Sample {
+	return Sample{Pair: pair, Metric: metricLastTrade, Value: float64(closed.Unix()), At: at}
+}
+
+// Alert is one firing of a rule
+type Alert struct {
+	ID         int
+	Rule       string
+	Pair       string // label
+	Condition  string
+	Severity   string
+	Value      float64   // metric value when it fired
+	Since      time.Time // when the condition started to hold
+	FiredAt    time.Time
+	ResolvedAt time.Time // zero while active
+	Acked      bool
+}
+
+// Active reports whether the alert has not resolved
+func (a Alert) Active() bool { return a.ResolvedAt.IsZero() }
+
+// Event is an alert firing or resolving
+type Event struct {
+	Alert    Alert
+	Resolved bool
+}
+
+// Sink delivers events somewhere outside the TUI
+type Sink interface {
+	Name() string
+	Notify(ctx context.Context, e Event) error
+}
+
+type point struct {
+	v  float64
+	at time.Time
+}
+
+type ruleState struct {
+	Rule
+	since  time.Time // zero while the condition does not hold
+	firing int       // index+1 into history while firing
+}
+
+// Engine evaluates rules against the samples it is fed. Safe for concurrent
+// use.
+type Engine struct {
+	mu      sync.Mutex
+	rules   []*ruleState
+	series  map[string][]point // newest last; only lp_locked keeps more than one
+	keep    time.Duration      // longest lp_locked_change window
+	history []Alert
+	nextID  int
+}
+
+// NewEngine returns an engine for rules
+func NewEngine(rules []Rule) *Engine {
+	e := &Engine{series: map[string][]point{}}
+	for _, r := range rules {
+		e.rules = append(e.rules, &ruleState{Rule: r})
+		if r.Window > e.keep {
+			e.keep = r.Window
+		}
+	}
+	return e
+}
+
+// Adopt takes over the samples, history and rule states of an engine this
+// one replaces, such as after the rules were edited. Rules that did not
+// change keep firing or counting towards their duration; alerts of rules
+// that changed or were removed resolve at now.
+func (e *Engine) Adopt(old *Engine, now time.Time) []Event {
+	if old == nil || old == e {
+		return nil
+	}
+	old.mu.Lock()
+	defer old.mu.Unlock()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.history = append([]Alert(nil), old.history...)
+	e.nextID = old.nextID
+	for k, pts := range old.series {
+		e.series[k] = append([]point(nil), pts...)
+	}
+	taken := make([]bool, len(e.rules))
+	var events []Event
+	for _, or := range old.rules {
+		match := -1
+		for i, r := range e.rules {
+			if !taken[i] && r.Rule == or.Rule {
+				match = i
+				break
+			}
+		}
+		if match >= 0 {
+			taken[match] = true
+			e.rules[match].since, e.rules[match].firing = or.since, or.firing
+			continue
+		}
+		if or.firing > 0 {
+			a := &e.history[or.firing-1]
+			a.ResolvedAt = now
+			events = append(events, Event{Alert: *a, Resolved: true})
+		}
+	}
+	return events
+}
+
+// Rules returns the engine's rules
+func (e *Engine) Rules() []Rule {
+	out := make([]Rule, len(e.rules))
+	for i, r := range e.rules {
+		out[i] = r.Rule
+	}
+	return out
+}
+
+func seriesKey(pair, metric, asset string) string {
+	return pair + "|" + metric + "|" + asset
+}
+
+// Observe records a sample and evaluates the rules at its time
+func (e *Engine) Observe(samples ...Sample) []Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var now time.Time
+	for _, s := range samples {
+		k := seriesKey(s.Pair, s.Metric, s.Asset)
+		pts := e.series[k]
+		if s.Metric == MetricLPLocked && e.keep > 0 {
+			// keep just enough history for the longest window: drop points
+			// once a newer one is already older than the window
+			for len(pts) > 1 && s.At.Sub(pts[1].at) >= e.keep {
+				pts = pts[1:]
+			}
+			pts = append(pts, point{s.Value, s.At})
+		} else {
+			pts = []point{{s.Value, s.At}}
+		}
+		e.series[k] = pts
+		if s.At.After(now) {
+			now = s.At
+		}
+	}
+	return e.evaluate(now)
+}
+
+// Evaluate re-checks every rule at now, for conditions such as trade_age
+// that change without new samples
+func (e *Engine) Evaluate(now time.Time) []Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.evaluate(now)
+}
+
+func (e *Engine) evaluate(now time.Time) []Event {
+	var events []Event
+	for _, r := range e.rules {
+		v, ok := e.value(r.Rule, now)
+		if !ok || !r.holds(v) {
+			r.since = time.Time{}
+			if r.firing > 0 {
+				a := &e.history[r.firing-1]
+				a.ResolvedAt = now
+				events = append(events, Event{Alert: *a, Resolved: true})
+				r.firing = 0
+			}
+			continue
+		}
+		if r.since.IsZero() {
+			r.since = now
+		}
+		if r.firing > 0 || now.Sub(r.since) < r.For {
+			continue
+		}
+		e.nextID++
+		a := Alert{ID: e.nextID, Rule: r.Name, Pair: r.PairLabel, Condition: r.Condition, Severity: r.Severity,
+			Value: v, Since: r.since, FiredAt: now}
+		e.history = append(e.history, a)
+		if len(e.history) > maxHistory {
+			e.trim()
+		}
+		r.firing = len(e.history)
+		events = append(events, Event{Alert: a})
+	}
+	return events
+}
+
+// trim drops the oldest resolved alerts, keeping firing indexes valid
+func (e *Engine) trim() {
+	drop := len(e.history) - maxHistory
+	kept := e.history[:0:0]
+	remap := map[int]int{}
+	for i, a := range e.history {
+		if drop > 0 && !a.Active() {
+			drop--
+			continue
+		}
+		remap[i+1] = len(kept) + 1
+		kept = append(kept, a)
+	}
+	e.history = kept
+	for _, r := range e.rules {
+		r.firing = remap[r.firing]
+	}
+}
+
+// value derives a rule's metric at now; ok is false while it is unknown
+func (e *Engine) value(r Rule, now time.Time) (float64, bool) {
+	latest := func(metric, asset string) (point, bool) {
+		pts := e.series[seriesKey(r.Pair, metric, asset)]
+		if len(pts) == 0 {
+			return point{}, false
+		}
+		return pts[len(pts)-1], true
+	}
+	switch r.Metric {
+	case MetricTradeAge:
+		p, ok := latest(metricLastTrade, "")
+		if !ok {
+			return 0, false
+		}
+		return now.Sub(time.Unix(int64(p.v), 0)).Seconds(), true
+	case MetricLPLocked:
+		p, ok := latest(MetricLPLocked, r.Asset)
+		return p.v, ok
+	case MetricLPLockedChange:
+		pts := e.series[seriesKey(r.Pair, MetricLPLocked, r.Asset)]
+		if len(pts) < 2 {
+			return 0, false
+		}
+		// the newest point at least a window old is the baseline
+		last := pts[len(pts)-1]
+		for i := len(pts) - 2; i >= 0; i-- {
+			if last.at.Sub(pts[i].at) >= r.Window {
+				if pts[i].v == 0 {
+					return 0, false
+				}
+				return (last.v - pts[i].v) / pts[i].v * 100, true
+			}
+		}
+		return 0, false
+	default:
+		p, ok := latest(r.Metric, "")
+		return p.v, ok
+	}
+}
+
+// Active returns the alerts still firing, newest first
+func (e *Engine) Active() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var out []Alert
+	for i := len(e.history) - 1; i >= 0; i-- {
+		if e.history[i].Active() {
+			out = append(out, e.history[i])
+		}
+	}
+	return out
+}
+
+// History returns every remembered alert, newest first
+func (e *Engine) History() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Alert, len(e.history))
+	for i, a := range e.history {
+		out[len(out)-1-i] = a
+	}
+	return out
+}
+
+// Unacked counts the firing alerts nobody has acknowledged
+func (e *Engine) Unacked() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	n := 0
+	for _, a := range e.history {
+		if a.Active() && !a.Acked {
+			n++
+		}
+	}
+	return n
+}
+
+// Ack acknowledges the alert with id, or every alert when id is 0
+func (e *Engine) Ack(id int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := range e.history {
+		if id == 0 || e.history[i].ID == id {
+			e.history[i].Acked = true
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Package alerts evaluates threshold rules against market samples and keeps
+// the history of the alerts they fire.
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metrics a rule condition can test
+const (
+	MetricSpread          = "spread_bps"       // bid/ask spread of a pair, in basis points
+	MetricMid             = "mid_price"        // mid price of a pair
+	MetricTradeAge        = "trade_age"        // time since a pair's last trade; thresholds may be durations
+	MetricLPLocked        = "lp_locked"        // lp_locked(ASSET): amount of ASSET in the pair's pool
+	MetricLPLockedChange  = "lp_locked_change" // lp_locked_change(ASSET, WINDOW): percent change over WINDOW
+	MetricNetworkCapacity = "network_capacity" // ledger capacity usage, in percent
+)
+
+// metricLastTrade is the sample behind trade_age: the last trade's close
+// time in Unix seconds
+const metricLastTrade = "last_trade"
+
+// ErrInvalidRule is wrapped by Parse errors
+var ErrInvalidRule = errors.New("invalid alert rule")
+
+// Rule fires when Condition has held for For
+type Rule struct {
+	Name      string
+	Pair      string // key of the pair samples are tagged with; empty for network metrics
+	PairLabel string // the pair as written in the config
+	Severity  string
+	Condition string // as written, e.g. "spread_bps > 50"
+
+	Metric    string
+	Asset     string        // lp_locked metrics
+	Window    time.Duration // lp_locked_change
+	Op        string        // >, >=, < or <=
+	Threshold float64
+	For       time.Duration
+}
+
+// Parse compiles a condition of the form "metric op value", where the
+// metric may take arguments, e.g. "lp_locked_change(USDZ, 1h) < -20%".
+// Values may end in % and, for trade_age, be durations such as 30m.
+func Parse(name, pairKey, pairLabel, condition string, forDur time.Duration) (Rule, error) {
+	r := Rule{Name: name, Pair: pairKey, PairLabel: pairLabel, Condition: strings.TrimSpace(condition), For: forDur}
+	fail := func(format string, args ...any) (Rule, error) {
+		return Rule{}, fmt.Errorf("%w %q: %s", ErrInvalidRule, name, fmt.Sprintf(format, args...))
+	}
+
+	expr := r.Condition
+	opAt := strings.IndexAny(expr, "<>")
+	if opAt < 0 {
+		return fail("condition %q needs one of > >= < <=", expr)
+	}
+	r.Op = expr[opAt : opAt+1]
+	rest := expr[opAt+1:]
+	if strings.HasPrefix(rest, "=") {
+		r.Op += "="
+		rest = rest[1:]
+	}
+	metric := strings.TrimSpace(expr[:opAt])
+	value := strings.TrimSpace(rest)
+
+	var args []string
+	if open := strings.Index(metric, "("); open >= 0 {
+		if !strings.HasSuffix(metric, ")") {
+			return fail("unbalanced parentheses in %q", metric)
+		}
+		for _, a := range strings.Split(metric[open+1:len(metric)-1], ",") {
+			args = append(args, strings.TrimSpace(a))
+		}
+		metric = strings.TrimSpace(metric[:open])
+	}
+	r.Metric = strings.ToLower(metric)
+
+	switch r.Metric {
+	case MetricSpread, MetricMid, MetricTradeAge:
+		if len(args) != 0 {
+			return fail("%s takes no arguments", r.Metric)
+		}
+	case MetricLPLocked:
+		if len(args) != 1 || args[0] == "" {
+			return fail("lp_locked needs an asset, e.g. lp_locked(USDZ)")
+		}
+		r.Asset = strings.ToUpper(args[0])
+	case MetricLPLockedChange:
+		if len(args) != 2 {
+			return fail("lp_locked_change needs an asset and a window, e.g. lp_locked_change(USDZ, 1h)")
+		}
+		r.Asset = strings.ToUpper(args[0])
+		w, err := time.ParseDuration(args[1])
+		if err != nil || w <= 0 {
+			return fail("bad window %q", args[1])
+		}
+		r.Window = w
+	case MetricNetworkCapacity:
+		if len(args) != 0 {
+			return fail("%s takes no arguments", r.Metric)
+		}
+	default:
+		return fail("unknown metric %q", metric)
+	}
+	if r.Metric != MetricNetworkCapacity && r.Pair == "" {
+		return fail("%s needs a pair", r.Metric)
+	}
+
+	v, err := parseValue(value, r.Metric == MetricTradeAge)
+	if err != nil {
+		return fail("bad value %q", value)
+	}
+	r.Threshold = v
+	return r, nil
+}
+
+// parseValue reads a number, a percentage (the number before %) or, when
+// durations are allowed, a duration in seconds
+func parseValue(s string, durations bool) (float64, error) {
+	if durations {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d.Seconds(), nil
+		}
+	}
+	return strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+}
+
+func (r Rule) holds(v float64) bool {
+	switch r.Op {
+	case ">":
+		return v > r.Threshold
+	case ">=":
+		return v >= r.Threshold
+	case "<":
+		return v < r.Threshold
+	case "<=":
+		return v <= r.Threshold
+	}
+	return false
+}
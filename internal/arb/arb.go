@@ -0,0 +1,114 @@
+// Package arb finds triangular arbitrage between order books: three trades
+// that turn an asset back into itself, taken at the top of each book.
+package arb
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Quote is the top of one pair's book. Prices are quote per base and sizes
+// are in base units. A side with no offers has a zero price.
+type Quote struct {
+	Base, Quote  string
+	Bid, BidSize float64
+	Ask, AskSize float64
+}
+
+// Leg is one conversion in a cycle
+type Leg struct {
+	From, To string
+	Pair     string  // the pair traded, BASE/QUOTE
+	Side     string  // "sell" hits the bid, "buy" takes the ask
+	Rate     float64 // units of To per unit of From, before fees
+	Capacity float64 // most From the top level takes
+}
+
+// Cycle is a round trip from Start through two other assets and back
+type Cycle struct {
+	Legs [3]Leg
+
+	// Return is the round-trip return after fees, as a fraction
+	Return float64
+	// Size is the largest Start amount every leg can fill at its top level,
+	// and Profit what it makes, both in Start units
+	Size, Profit float64
+}
+
+// Start is the asset the cycle begins and ends in
+func (c Cycle) Start() string { return c.Legs[0].From }
+
+// Path renders the cycle as A → B → C → A
+func (c Cycle) Path() string {
+	return strings.Join([]string{c.Legs[0].From, c.Legs[1].From, c.Legs[2].From, c.Legs[0].From}, " → ")
+}
+
+// ReturnBps is Return in basis points
+func (c Cycle) ReturnBps() float64 { return c.Return * 10000 }
+
+// Find returns every cycle whose return after feeBps per leg is above
+// minReturnBps, best first. Each cycle is reported once, starting from its
+// alphabetically first asset; the two directions around a triangle are
+// different cycles.
+func Find(quotes []Quote, feeBps, minReturnBps float64) []Cycle {
+	edges := map[string]map[string]Leg{} // from -> to -> best leg
+	addEdge := func(l Leg) {
+		if l.Rate <= 0 || l.Capacity <= 0 || math.IsInf(l.Rate, 0) || l.From == l.To {
+			return
+		}
+		if edges[l.From] == nil {
+			edges[l.From] = map[string]Leg{}
+		}
+		if cur, ok := edges[l.From][l.To]; !ok || l.Rate > cur.Rate {
+			edges[l.From][l.To] = l
+		}
+	}
+	for _, q := range quotes {
+		pair := q.Base + "/" + q.Quote
+		if q.Bid > 0 {
+			addEdge(Leg{From: q.Base, To: q.Quote, Pair: pair, Side: "sell", Rate: q.Bid, Capacity: q.BidSize})
+		}
+		if q.Ask > 0 {
+			addEdge(Leg{From: q.Quote, To: q.Base, Pair: pair, Side: "buy", Rate: 1 / q.Ask, Capacity: q.AskSize * q.Ask})
+		}
+	}
+
+	keep := 1 - feeBps/10000
+	var out []Cycle
+	for a, fromA := range edges {
+		for b, ab := range fromA {
+			if b <= a {
+				continue
+			}
+			for c, bc := range edges[b] {
+				if c <= a || c == b {
+					continue
+				}
+				ca, ok := edges[c][a]
+				if !ok {
+					continue
+				}
+				cyc := Cycle{Legs: [3]Leg{ab, bc, ca}}
+				gross := ab.Rate * bc.Rate * ca.Rate
+				cyc.Return = gross*keep*keep*keep - 1
+				if cyc.ReturnBps() <= minReturnBps {
+					continue
+				}
+				// each leg's capacity, in units of a
+				cyc.Size = math.Min(ab.Capacity, math.Min(
+					bc.Capacity/(ab.Rate*keep),
+					ca.Capacity/(ab.Rate*bc.Rate*keep*keep)))
+				cyc.Profit = cyc.Size * cyc.Return
+				out = append(out, cyc)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Return != out[j].Return {
+			return out[i].Return > out[j].Return
+		}
+		return out[i].Path() < out[j].Path()
+	})
+	return out
+}
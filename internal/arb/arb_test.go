@@ -0,0 +1,47 @@
+package arb
+
+import (
+	"math"
+	"testing"
+)
+
+// EURZ is cheap on the USDZ/EURZ book relative to the two ZARZ books
+var quotes = []Quote{
+	{Base: "USDZ", Quote: "ZARZ", Bid: 18, BidSize: 100, Ask: 18.05, AskSize: 100},
+	{Base: "EURZ", Quote: "ZARZ", Bid: 19.5, BidSize: 50, Ask: 19.55, AskSize: 50},
+	{Base: "USDZ", Quote: "EURZ", Bid: 0.89, BidSize: 1000, Ask: 0.9, AskSize: 1000},
+	{Base: "XLM", Quote: "USDZ", Bid: 0.1, BidSize: 10}, // no asks: no cycle through XLM
+}
+
+func TestFindReportsTheProfitableDirectionOnce(t *testing.T) {
+	cycles := Find(quotes, 0, 0)
+	if len(cycles) != 1 {
+		t.Fatalf("want 1 cycle, got %d: %+v", len(cycles), cycles)
+	}
+	c := cycles[0]
+	if c.Path() != "EURZ → USDZ → ZARZ → EURZ" {
+		t.Errorf("path %s", c.Path())
+	}
+	if want := 18 / 19.55 / 0.9; math.Abs(c.Return-(want-1)) > 1e-12 {
+		t.Errorf("return %v, want %v", c.Return, want-1)
+	}
+	// the EURZ/ZARZ ask (50 EURZ = 977.5 ZARZ) is the smallest leg: 48.875 EURZ
+	if math.Abs(c.Size-48.875) > 1e-9 || math.Abs(c.Profit-c.Size*c.Return) > 1e-12 {
+		t.Errorf("size %v profit %v", c.Size, c.Profit)
+	}
+	if l := c.Legs[0]; l.Pair != "USDZ/EURZ" || l.Side != "buy" {
+		t.Errorf("first leg %+v", l)
+	}
+}
+
+func TestFindAppliesFeesAndMinimum(t *testing.T) {
+	if got := Find(quotes, 80, 0); len(got) != 0 {
+		t.Errorf("80bp a leg should eat a 230bp edge: %+v", got)
+	}
+	if got := Find(quotes, 0, 250); len(got) != 0 {
+		t.Errorf("minimum return not applied: %+v", got)
+	}
+	if got := Find(quotes, 0, -1000); len(got) != 2 {
+		t.Errorf("both directions should be listed below zero, got %d", len(got))
+	}
+}
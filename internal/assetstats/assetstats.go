@@ -0,0 +1,167 @@
+// Package assetstats keeps snapshots of Horizon's /assets statistics for an
+// asset (holders, balances by authorization, claimable and pool balances,
+// issuer flags) and compares them over time.
+package assetstats
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+
+	"github.com/sdexmon/sdexmon/internal/jsonl"
+)
+
+// Snapshot is an asset's statistics at one time
+type Snapshot struct {
+	Asset string    `json:"asset"` // CODE:ISSUER
+	At    time.Time `json:"at"`
+
+	// trustlines by authorization
+	Accounts             int `json:"accounts"`
+	AccountsMaintain     int `json:"accounts_maintain"` // authorized to maintain liabilities only
+	AccountsUnauthorized int `json:"accounts_unauthorized"`
+
+	// balances held on those trustlines
+	Authorized   Amount `json:"authorized"`
+	Maintain     Amount `json:"maintain"`
+	Unauthorized Amount `json:"unauthorized"`
+
+	ClaimableBalances int    `json:"claimable_balances"`
+	Claimable         Amount `json:"claimable"`
+	Pools             int    `json:"pools"`
+	InPools           Amount `json:"in_pools"`
+	Contracts         int    `json:"contracts"`
+	InContracts       Amount `json:"in_contracts"`
+
+	AuthRequired  bool `json:"auth_required"`
+	AuthRevocable bool `json:"auth_revocable"`
+	AuthImmutable bool `json:"auth_immutable"`
+	AuthClawback  bool `json:"auth_clawback"`
+}
+
+// FromHorizon converts an /assets record
+func FromHorizon(s hProtocol.AssetStat, at time.Time) Snapshot {
+	return Snapshot{
+		Asset:                s.Code + ":" + s.Issuer,
+		At:                   at,
+		Accounts:             int(s.Accounts.Authorized),
+		AccountsMaintain:     int(s.Accounts.AuthorizedToMaintainLiabilities),
+		AccountsUnauthorized: int(s.Accounts.Unauthorized),
+		Authorized:           Amount(s.Balances.Authorized),
+		Maintain:             Amount(s.Balances.AuthorizedToMaintainLiabilities),
+		Unauthorized:         Amount(s.Balances.Unauthorized),
+		ClaimableBalances:    int(s.NumClaimableBalances),
+		Claimable:            Amount(s.ClaimableBalancesAmount),
+		Pools:                int(s.NumLiquidityPools),
+		InPools:              Amount(s.LiquidityPoolsAmount),
+		Contracts:            int(s.NumContracts),
+		InContracts:          Amount(s.ContractsAmount),
+		AuthRequired:         s.Flags.AuthRequired,
+		AuthRevocable:        s.Flags.AuthRevocable,
+		AuthImmutable:        s.Flags.AuthImmutable,
+		AuthClawback:         s.Flags.AuthClawbackEnabled,
+	}
+}
+
+// Amount is an exact decimal amount as Horizon writes it, e.g.
+// "1000.5000000". Older snapshots stored numbers, which still load.
+type Amount string
+
+// Rat is the amount; zero when it is empty or malformed
+func (a Amount) Rat() *big.Rat {
+	r, ok := new(big.Rat).SetString(string(a))
+	if !ok {
+		return new(big.Rat)
+	}
+	return r
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(a))
+}
+
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*a = Amount(s)
+		return nil
+	}
+	*a = Amount(b)
+	return nil
+}
+
+// Holders counts trustlines of every authorization
+func (s Snapshot) Holders() int {
+	return s.Accounts + s.AccountsMaintain + s.AccountsUnauthorized
+}
+
+// Supply is everything issued and not returned: trustline, claimable,
+// pool and contract balances
+func (s Snapshot) Supply() *big.Rat {
+	sum := new(big.Rat)
+	for _, a := range []Amount{s.Authorized, s.Maintain, s.Unauthorized, s.Claimable, s.InPools, s.InContracts} {
+		sum.Add(sum, a.Rat())
+	}
+	return sum
+}
+
+// Flags lists the issuer flags that are set
+func (s Snapshot) Flags() []string {
+	var out []string
+	for _, f := range []struct {
+		on   bool
+		name string
+	}{{s.AuthRequired, "auth_required"}, {s.AuthRevocable, "auth_revocable"},
+		{s.AuthImmutable, "auth_immutable"}, {s.AuthClawback, "auth_clawback_enabled"}} {
+		if f.on {
+			out = append(out, f.name)
+		}
+	}
+	return out
+}
+
+// Before returns the latest snapshot in history taken at or before t;
+// history is oldest first
+func Before(history []Snapshot, t time.Time) (Snapshot, bool) {
+	for i := len(history) - 1; i >= 0; i-- {
+		if !history[i].At.After(t) {
+			return history[i], true
+		}
+	}
+	return Snapshot{}, false
+}
+
+// Store keeps each asset's snapshots as JSON Lines in its own file
+type Store struct {
+	lines *jsonl.Store[Snapshot]
+}
+
+// Open returns a store in dir, creating it if needed
+func Open(dir string) (*Store, error) {
+	lines, err := jsonl.Open[Snapshot](dir)
+	if err != nil {
+		return nil, fmt.Errorf("asset stats store %s: %w", dir, err)
+	}
+	return &Store{lines: lines}, nil
+}
+
+func file(asset string) string {
+	return strings.ReplaceAll(asset, ":", "-") + ".jsonl"
+}
+
+// Append stores a snapshot
+func (s *Store) Append(snap Snapshot) error {
+	return s.lines.Append(func(snap Snapshot) string { return file(snap.Asset) }, snap)
+}
+
+// History returns an asset's snapshots taken since, oldest first
+func (s *Store) History(asset string, since time.Time) ([]Snapshot, error) {
+	return s.lines.Read(file(asset), func(snap Snapshot) bool { return !snap.At.Before(since) })
+}
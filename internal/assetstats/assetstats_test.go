@@ -0,0 +1,77 @@
+package assetstats
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+)
+
+func TestFromHorizon(t *testing.T) {
+	var rec hProtocol.AssetStat
+	rec.Asset = base.Asset{Type: "credit_alphanum4", Code: "USDZ", Issuer: "GISSUER"}
+	rec.Accounts = hProtocol.AssetStatAccounts{Authorized: 10, AuthorizedToMaintainLiabilities: 2, Unauthorized: 1}
+	rec.Balances = hProtocol.AssetStatBalances{Authorized: "1000.5", AuthorizedToMaintainLiabilities: "20", Unauthorized: "5"}
+	rec.ClaimableBalancesAmount, rec.NumClaimableBalances = "3", 1
+	rec.LiquidityPoolsAmount, rec.NumLiquidityPools = "200", 2
+	rec.Flags.AuthRevocable, rec.Flags.AuthClawbackEnabled = true, true
+
+	s := FromHorizon(rec, time.Unix(0, 0))
+	if s.Asset != "USDZ:GISSUER" || s.Holders() != 13 || s.Supply().Cmp(big.NewRat(12285, 10)) != 0 {
+		t.Errorf("snapshot = %+v, holders %d supply %v", s, s.Holders(), s.Supply())
+	}
+	if got := s.Flags(); !reflect.DeepEqual(got, []string{"auth_revocable", "auth_clawback_enabled"}) {
+		t.Errorf("Flags = %v", got)
+	}
+}
+
+func TestAmountsStayExact(t *testing.T) {
+	s := Snapshot{Authorized: "92233720368.5477580", Claimable: "0.0000001"}
+	if got := s.Supply().FloatString(7); got != "92233720368.5477581" {
+		t.Errorf("Supply = %s", got)
+	}
+
+	// older snapshots stored float64 numbers
+	var old Snapshot
+	if err := json.Unmarshal([]byte(`{"authorized": 1000.5, "in_pools": 20}`), &old); err != nil {
+		t.Fatal(err)
+	}
+	if old.Supply().Cmp(big.NewRat(20410, 20)) != 0 {
+		t.Errorf("old Supply = %s", old.Supply().FloatString(7))
+	}
+	b, _ := json.Marshal(s)
+	var back Snapshot
+	if err := json.Unmarshal(b, &back); err != nil || back.Authorized != s.Authorized {
+		t.Errorf("round trip = %+v, %v", back, err)
+	}
+}
+
+func TestStoreHistory(t *testing.T) {
+	st, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		if err := st.Append(Snapshot{Asset: "USDZ:G", At: t0.Add(time.Duration(i) * time.Hour), Accounts: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.Append(Snapshot{Asset: "EURZ:G", At: t0}); err != nil {
+		t.Fatal(err)
+	}
+	h, err := st.History("USDZ:G", t0.Add(time.Hour))
+	if err != nil || len(h) != 3 || h[0].Accounts != 1 {
+		t.Fatalf("History = %+v, %v", h, err)
+	}
+	if s, ok := Before(h, t0.Add(150*time.Minute)); !ok || s.Accounts != 2 {
+		t.Errorf("Before = %+v, %v", s, ok)
+	}
+	if _, ok := Before(h, t0); ok {
+		t.Error("Before the history found a snapshot")
+	}
+}
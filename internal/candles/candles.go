@@ -0,0 +1,136 @@
+package candles
+
+import (
+	"sort"
+	"time"
+)
+
+// MaxCandles caps how many candles a Series keeps
+const MaxCandles = 200
+
+// Resolutions supported by Horizon's /trade_aggregations, shortest first
+var Resolutions = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	time.Hour,
+	24 * time.Hour,
+}
+
+// Candle is one OHLCV bucket. Volume is in the base asset.
+type Candle struct {
+	Start  time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+	Trades int64
+}
+
+// Up reports whether the candle closed at or above its open
+func (c Candle) Up() bool {
+	return c.Close >= c.Open
+}
+
+// Series is a run of candles at one resolution, oldest first. Buckets are
+// aligned to UTC like Horizon's aggregations with a zero offset.
+type Series struct {
+	Resolution time.Duration
+	// Since is when the aggregations were fetched; trades that closed at or
+	// before it are assumed to be included already and are not added again
+	Since   time.Time
+	Candles []Candle
+}
+
+// NewSeries builds a series from candles in any order
+func NewSeries(res time.Duration, since time.Time, list []Candle) Series {
+	out := make([]Candle, len(list))
+	copy(out, list)
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	if len(out) > MaxCandles {
+		out = out[len(out)-MaxCandles:]
+	}
+	return Series{Resolution: res, Since: since, Candles: out}
+}
+
+// BucketStart returns the start of the bucket containing t
+func (s Series) BucketStart(t time.Time) time.Time {
+	return t.UTC().Truncate(s.Resolution)
+}
+
+// Add folds a trade into the series, extending the current candle or
+// opening a new one. It reports whether the trade was applied.
+func (s *Series) Add(at time.Time, price, volume float64) bool {
+	if s.Resolution <= 0 || price <= 0 || !at.After(s.Since) {
+		return false
+	}
+	start := s.BucketStart(at)
+	n := len(s.Candles)
+	if n == 0 || start.After(s.Candles[n-1].Start) {
+		s.Candles = append(s.Candles, Candle{
+			Start: start, Open: price, High: price, Low: price, Close: price,
+			Volume: volume, Trades: 1,
+		})
+		if len(s.Candles) > MaxCandles {
+			s.Candles = s.Candles[len(s.Candles)-MaxCandles:]
+		}
+		return true
+	}
+	// Late trade: update its bucket if we still have it
+	for i := n - 1; i >= 0; i-- {
+		c := &s.Candles[i]
+		if c.Start.Equal(start) {
+			if price > c.High {
+				c.High = price
+			}
+			if price < c.Low {
+				c.Low = price
+			}
+			if i == n-1 {
+				c.Close = price
+			}
+			c.Volume += volume
+			c.Trades++
+			return true
+		}
+		if c.Start.Before(start) {
+			break
+		}
+	}
+	return false
+}
+
+// Window returns n consecutive slots ending with the bucket containing end.
+// Buckets without trades are filled flat at the previous close with zero
+// volume and zero Trades; slots before the first known candle are zero.
+func (s Series) Window(end time.Time, n int) []Candle {
+	if n <= 0 || s.Resolution <= 0 {
+		return nil
+	}
+	last := s.BucketStart(end)
+	if k := len(s.Candles); k > 0 && s.Candles[k-1].Start.After(last) {
+		last = s.Candles[k-1].Start
+	}
+	first := last.Add(-time.Duration(n-1) * s.Resolution)
+
+	out := make([]Candle, n)
+	idx := 0
+	prevClose := 0.0
+	// carry the close of the last candle before the window into its gaps
+	for idx < len(s.Candles) && s.Candles[idx].Start.Before(first) {
+		prevClose = s.Candles[idx].Close
+		idx++
+	}
+	for i := 0; i < n; i++ {
+		slot := first.Add(time.Duration(i) * s.Resolution)
+		if idx < len(s.Candles) && s.Candles[idx].Start.Equal(slot) {
+			out[i] = s.Candles[idx]
+			prevClose = s.Candles[idx].Close
+			idx++
+			continue
+		}
+		out[i] = Candle{Start: slot, Open: prevClose, High: prevClose, Low: prevClose, Close: prevClose}
+	}
+	return out
+}
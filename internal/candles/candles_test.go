@@ -0,0 +1,64 @@
+package candles
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdd(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := NewSeries(time.Minute, t0, []Candle{
+		{Start: t0, Open: 1, High: 1.2, Low: 0.9, Close: 1.1, Volume: 10, Trades: 3},
+	})
+
+	if s.Add(t0.Add(30*time.Second).Add(-time.Minute), 5, 1) {
+		t.Errorf("trade before Since should be ignored")
+	}
+	if !s.Add(t0.Add(20*time.Second), 1.3, 2) {
+		t.Fatalf("trade in current bucket not applied")
+	}
+	c := s.Candles[0]
+	if c.High != 1.3 || c.Close != 1.3 || c.Volume != 12 || c.Trades != 4 || c.Open != 1 {
+		t.Errorf("unexpected candle after update: %+v", c)
+	}
+
+	if !s.Add(t0.Add(90*time.Second), 1.25, 1) {
+		t.Fatalf("trade in next bucket not applied")
+	}
+	if len(s.Candles) != 2 || !s.Candles[1].Start.Equal(t0.Add(time.Minute)) || s.Candles[1].Open != 1.25 {
+		t.Errorf("expected new candle, got %+v", s.Candles)
+	}
+
+	// late trade in the earlier bucket extends its range but not its close
+	if !s.Add(t0.Add(50*time.Second), 0.8, 1) {
+		t.Fatalf("late trade not applied")
+	}
+	if s.Candles[0].Low != 0.8 || s.Candles[0].Close != 1.3 {
+		t.Errorf("unexpected candle after late trade: %+v", s.Candles[0])
+	}
+}
+
+func TestWindowFillsGaps(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := NewSeries(time.Minute, time.Time{}, []Candle{
+		{Start: t0.Add(2 * time.Minute), Open: 2, High: 2, Low: 2, Close: 2.5, Trades: 1},
+		{Start: t0, Open: 1, High: 1, Low: 1, Close: 1.5, Trades: 1},
+	})
+
+	w := s.Window(t0.Add(3*time.Minute+10*time.Second), 5)
+	if len(w) != 5 {
+		t.Fatalf("expected 5 slots, got %d", len(w))
+	}
+	if !w[0].Start.Equal(t0.Add(-time.Minute)) || w[0].Close != 0 {
+		t.Errorf("slot before data should be empty: %+v", w[0])
+	}
+	if w[1].Close != 1.5 || w[1].Trades != 1 {
+		t.Errorf("unexpected first candle: %+v", w[1])
+	}
+	if w[2].Trades != 0 || w[2].Open != 1.5 || w[2].Close != 1.5 {
+		t.Errorf("gap should be flat at previous close: %+v", w[2])
+	}
+	if w[4].Close != 2.5 || w[4].Trades != 0 {
+		t.Errorf("trailing gap should carry last close: %+v", w[4])
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import "time"
+
+// AlertsConfig holds the alert rules and where firing alerts are sent
+type AlertsConfig struct {
+	Rules  []AlertRule `yaml:"rules,omitempty"`
+	Sinks  []AlertSink `yaml:"sinks,omitempty"`
+	DryRun bool        `yaml:"dry_run,omitempty"` // log what every sink would send instead
+}
+
+// AlertRule fires when When has held for For, e.g. pair USDZ/ZARZ,
+// when "spread_bps > 50", for 2m
+type AlertRule struct {
+	Name     string        `yaml:"name"`
+	Pair     string        `yaml:"pair,omitempty"` // BASE/QUOTE; not needed for network rules
+	When     string        `yaml:"when"`
+	For      time.Duration `yaml:"for,omitempty"`
+	Severity string        `yaml:"severity,omitempty"` // free text, e.g. warning or critical
+}
+
+// AlertSink is one notification target
+type AlertSink struct {
+	Name    string            `yaml:"name,omitempty"`
+	Type    string            `yaml:"type"`              // webhook, slack, discord, mattermost, email or exec
+	URL     string            `yaml:"url,omitempty"`     // webhook and chat types
+	Headers map[string]string `yaml:"headers,omitempty"` // webhook
+	SMTP    string            `yaml:"smtp,omitempty"`    // email: host:port
+	From    string            `yaml:"from,omitempty"`
+	To      []string          `yaml:"to,omitempty"`
+	User    string            `yaml:"username,omitempty"`
+	// PasswordEnv names the environment variable holding the SMTP password,
+	// so it stays out of the config file
+	PasswordEnv string   `yaml:"password_env,omitempty"`
+	Command     []string `yaml:"command,omitempty"` // exec: program and arguments, no shell
+
+	Limit   int           `yaml:"limit,omitempty"` // events per Per; 0 is unlimited
+	Per     time.Duration `yaml:"per,omitempty"`   // 1m when unset
+	Retries int           `yaml:"retries,omitempty"`
+	Backoff time.Duration `yaml:"backoff,omitempty"` // first retry delay, doubling; 1s when unset
+	DryRun  bool          `yaml:"dry_run,omitempty"`
+}
@@ -10,16 +10,31 @@ import (
 
 	"github.com/stellar/go/clients/horizonclient"
 	"github.com/stellar/go/txnbuild"
-	"gopkg.in/yaml.v3"
 )
 
-// HorizonURL returns the Horizon endpoint from environment or default
+// HorizonURL returns the primary Horizon endpoint from environment or the
+// active network profile
 func HorizonURL() string {
+	return HorizonURLs()[0]
+}
+
+// HorizonURLs returns the Horizon endpoints to fail over between, primary
+// first: the comma-separated HORIZON_URL, or the active network profile's
+// horizon_url followed by its horizon_urls
+func HorizonURLs() []string {
+	var urls []string
 	if v := os.Getenv("HORIZON_URL"); v != "" {
-		return v
+		for _, u := range strings.Split(v, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				urls = append(urls, u)
+			}
+		}
 	}
-	// Default to public Stellar Horizon mainnet
-	return "https://horizon.stellar.org"
+	if len(urls) == 0 {
+		n := ActiveNetwork()
+		urls = append([]string{n.HorizonURL}, n.HorizonURLs...)
+	}
+	return urls
 }
 
 // NewClient creates a new Horizon client
@@ -89,23 +104,44 @@ func GetDebugLogs() []string {
 
 // YAML Configuration Support
 
-// Config represents the YAML configuration structure
+// Config represents the YAML configuration structure. It is the only
+// document stored in config.yaml; see CurrentSchemaVersion for its history.
 type Config struct {
+	SchemaVersion int `yaml:"schema_version"`
+
 	App struct {
 		Version     string `yaml:"version"`
 		DefaultPair string `yaml:"default_pair"`
 	} `yaml:"app"`
 	
-	Pairs []Pair `yaml:"pairs"`
+	Pairs []Pair `yaml:"pairs"` // pubnet pairs; other networks keep theirs in Networks
+	
+	// DefaultNetwork names the profile used without --network, pubnet if empty
+	DefaultNetwork string              `yaml:"network,omitempty"`
+	Networks       map[string]*Network `yaml:"networks,omitempty"`
 	
 	Assets []Asset `yaml:"assets"`
 	
+	Peg          PegConfig       `yaml:"peg,omitempty"`
+	Alerts       AlertsConfig    `yaml:"alerts,omitempty"`
+	MarketMakers []MarketMaker   `yaml:"market_makers,omitempty"`
+	SLA          []SLAObligation `yaml:"sla,omitempty"`
+	
 	Preferences struct {
 		DefaultOrderBookDepth int  `yaml:"default_order_book_depth"`
 		DefaultLiquidityPools int  `yaml:"default_liquidity_pools"`
 		AutoRefresh           bool `yaml:"auto_refresh"`
 		RefreshIntervalMs     int  `yaml:"refresh_interval_ms"`
 		ShowDebug             bool `yaml:"show_debug"`
+		Streaming             bool `yaml:"streaming"`
+		// LPProvider selects where pool metrics come from: "stellar_expert"
+		// (default) or "horizon"
+		LPProvider string `yaml:"lp_provider,omitempty"`
+		// TradeHistoryDays is how far back trade history is backfilled into
+		// the local store (default 7)
+		TradeHistoryDays int `yaml:"trade_history_days,omitempty"`
+		// ArbFeeBps is the cost assumed per leg of an arbitrage cycle
+		ArbFeeBps float64 `yaml:"arb_fee_bps,omitempty"`
 	} `yaml:"preferences"`
 	
 	SystemSettings struct {
@@ -141,7 +177,9 @@ func GetConfigPath() string {
 	return filepath.Join(homeDir, ".config", "sdexmon", "config.yaml")
 }
 
-// LoadConfig loads the configuration from YAML file
+// LoadConfig loads the configuration from YAML file, upgrading older
+// documents to the current schema. An upgraded file is written back so the
+// migration only runs once; the original is kept as config.yaml.bak.
 func LoadConfig() (*Config, error) {
 	configPath := GetConfigPath()
 	
@@ -150,43 +188,79 @@ func LoadConfig() (*Config, error) {
 		return getDefaultConfig(), nil
 	}
 	
-	data, err := os.ReadFile(configPath)
+	config, migrated, err := readConfigFile(configPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
 	}
-	
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+	if migrated {
+		if err := writeConfigFile(configPath, config); err != nil {
+			log.Printf("Warning: Failed to save migrated config: %v", err)
+		}
 	}
 	
-	return &config, nil
+	return config, nil
 }
 
-// SaveConfig saves the configuration to YAML file
+// SaveConfig saves the configuration to YAML file atomically, keeping the
+// previous file as config.yaml.bak
 func SaveConfig(config *Config) error {
-	configPath := GetConfigPath()
-	
-	// Ensure config directory exists
-	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+	return writeConfigFile(GetConfigPath(), config)
+}
+
+// AddPair adds a new pair to the config and saves it
+func AddPair(config *Config, name, base, quote, lpID string) error {
+	newPair := Pair{
+		Name:         name,
+		Base:         base,
+		Quote:        quote,
+		LP:           lpID,
+		Favorite:     false,
+		ShowDecimals: pairDefaultDecimals(base, quote),
 	}
 	
-	data, err := yaml.Marshal(config)
-	if err != nil {
-		return fmt.Errorf("failed to marshal config to YAML: %w", err)
+	pairs := config.activePairsRef()
+	*pairs = append(*pairs, newPair)
+	return SaveConfig(config)
+}
+
+// RemovePair removes the pair at index and saves the config
+func RemovePair(config *Config, index int) error {
+	pairs := config.activePairsRef()
+	if index < 0 || index >= len(*pairs) {
+		return fmt.Errorf("no pair at index %d", index)
 	}
-	
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
+	*pairs = append((*pairs)[:index], (*pairs)[index+1:]...)
+	return SaveConfig(config)
+}
+
+// MovePair swaps the pair at index with its neighbour delta (-1 or 1)
+// positions away and saves the config
+func MovePair(config *Config, index, delta int) error {
+	pairs := config.ActivePairs()
+	to := index + delta
+	if index < 0 || index >= len(pairs) || to < 0 || to >= len(pairs) {
+		return fmt.Errorf("cannot move pair %d by %d", index, delta)
 	}
-	
-	return nil
+	pairs[index], pairs[to] = pairs[to], pairs[index]
+	return SaveConfig(config)
 }
 
-// AddPair adds a new pair to the config and saves it
-func AddPair(config *Config, name, base, quote, lpID string) error {
-	// Determine show_decimals based on base and quote assets
+// RenamePair sets the display name of the pair at index and saves the config
+func RenamePair(config *Config, index int, name string) error {
+	name = strings.TrimSpace(name)
+	pairs := config.ActivePairs()
+	if index < 0 || index >= len(pairs) {
+		return fmt.Errorf("no pair at index %d", index)
+	}
+	if name == "" {
+		return fmt.Errorf("label cannot be empty")
+	}
+	pairs[index].Name = name
+	return SaveConfig(config)
+}
+
+// pairDefaultDecimals determines show_decimals based on base and quote assets
+func pairDefaultDecimals(base, quote string) int {
 	showDecimals := 2 // default
 	
 	// Check if either base or quote contains BTCZ (should be 0 decimals)
@@ -197,25 +271,14 @@ func AddPair(config *Config, name, base, quote, lpID string) error {
 	if strings.Contains(base, "XAUZ") || strings.Contains(quote, "XAUZ") {
 		showDecimals = 7
 	}
-	
-	newPair := Pair{
-		Name:         name,
-		Base:         base,
-		Quote:        quote,
-		LP:           lpID,
-		Favorite:     false,
-		ShowDecimals: showDecimals,
-	}
-	
-	config.Pairs = append(config.Pairs, newPair)
-	return SaveConfig(config)
+	return showDecimals
 }
 
 // GetPairDecimals returns the decimal configuration for a trading pair
 // Returns [baseDecimals, quoteDecimals] by finding the matching pair or asset in config
 func (c *Config) GetPairDecimals(baseName, quoteName string) (int, int) {
 	// Look for exact pair match first (if pairs have show_decimals)
-	for _, pair := range c.Pairs {
+	for _, pair := range c.ActivePairs() {
 		pairBaseName := parseAssetCode(pair.Base)
 		pairQuoteName := parseAssetCode(pair.Quote)
 		if (pairBaseName == baseName && pairQuoteName == quoteName) ||
@@ -280,6 +343,7 @@ func getAssetDefaultDecimals(assetCode string) int {
 // getDefaultConfig returns a default configuration when no config file exists
 func getDefaultConfig() *Config {
 	return &Config{
+		SchemaVersion: CurrentSchemaVersion,
 		App: struct {
 			Version     string `yaml:"version"`
 			DefaultPair string `yaml:"default_pair"`
@@ -294,12 +358,17 @@ func getDefaultConfig() *Config {
 			AutoRefresh           bool `yaml:"auto_refresh"`
 			RefreshIntervalMs     int  `yaml:"refresh_interval_ms"`
 			ShowDebug             bool `yaml:"show_debug"`
+			Streaming             bool `yaml:"streaming"`
+			LPProvider            string `yaml:"lp_provider,omitempty"`
+			TradeHistoryDays      int    `yaml:"trade_history_days,omitempty"`
+			ArbFeeBps             float64 `yaml:"arb_fee_bps,omitempty"`
 		}{
 			DefaultOrderBookDepth: 7,
 			DefaultLiquidityPools: 10,
 			AutoRefresh:           true,
 			RefreshIntervalMs:     1500,
 			ShowDebug:             false,
+			Streaming:             false,
 		},
 		SystemSettings: struct {
 			TerminalSize struct {
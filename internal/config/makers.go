@@ -0,0 +1,8 @@
+package config
+
+// MarketMaker is one of our own trading accounts; its offers are marked in
+// the order book
+type MarketMaker struct {
+	Account string `yaml:"account"` // G... account ID
+	Name    string `yaml:"name,omitempty"`
+}
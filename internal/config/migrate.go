@@ -0,0 +1,252 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentSchemaVersion is the config document version this build writes.
+//
+// History:
+//
+//	0: unversioned files written by LoadConfig/SaveConfig (pairs, assets,
+//	   preferences) and/or by the old user config (custom_pairs)
+//	1: single document with schema_version; custom pairs live in pairs
+const CurrentSchemaVersion = 1
+
+// migration upgrades a raw config document from one version to the next
+type migration func(doc map[string]interface{}) error
+
+// migrations[v] upgrades a version v document to version v+1
+var migrations = []migration{
+	migrateMergeCustomPairs,
+}
+
+// migrateDocument runs every migration needed to bring doc up to
+// CurrentSchemaVersion and reports whether anything changed
+func migrateDocument(doc map[string]interface{}) (bool, error) {
+	version, err := schemaVersion(doc)
+	if err != nil {
+		return false, err
+	}
+	if version > CurrentSchemaVersion {
+		return false, fmt.Errorf("config schema_version %d is newer than this sdexmon supports (%d)", version, CurrentSchemaVersion)
+	}
+	for v := version; v < CurrentSchemaVersion; v++ {
+		if err := migrations[v](doc); err != nil {
+			return false, fmt.Errorf("failed to migrate config from version %d: %w", v, err)
+		}
+		doc["schema_version"] = v + 1
+	}
+	return version < CurrentSchemaVersion, nil
+}
+
+func schemaVersion(doc map[string]interface{}) (int, error) {
+	raw, ok := doc["schema_version"]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+	v, ok := raw.(int)
+	if !ok || v < 0 {
+		return 0, fmt.Errorf("invalid schema_version %v", raw)
+	}
+	return v, nil
+}
+
+// defaultPairs are the pairs the program lists while the config has none
+var (
+	defaultMu    sync.RWMutex
+	defaultPairs []Pair
+)
+
+// SetDefaultPairs registers the pairs the program falls back to when the
+// config lists none
+func SetDefaultPairs(pairs []Pair) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultPairs = append([]Pair(nil), pairs...)
+}
+
+// migrateMergeCustomPairs moves the old user config's custom_pairs into
+// pairs, skipping pairs that are already configured. A document without
+// pairs showed the default pairs, so they are listed ahead of the custom
+// ones to stay in the selector.
+func migrateMergeCustomPairs(doc map[string]interface{}) error {
+	raw, ok := doc["custom_pairs"]
+	delete(doc, "custom_pairs")
+	if !ok || raw == nil {
+		return nil
+	}
+	custom, ok := raw.([]interface{})
+	if !ok {
+		return fmt.Errorf("custom_pairs is not a list")
+	}
+
+	pairs, _ := doc["pairs"].([]interface{})
+	if len(pairs) == 0 && len(custom) > 0 {
+		defaultMu.RLock()
+		for _, p := range defaultPairs {
+			m := map[string]interface{}{
+				"name":          p.Name,
+				"base":          normalizeAssetString(p.Base),
+				"quote":         normalizeAssetString(p.Quote),
+				"show_decimals": p.ShowDecimals,
+			}
+			if p.ShowDecimals == 0 {
+				m["show_decimals"] = pairDefaultDecimals(p.Base, p.Quote)
+			}
+			if p.LP != "" {
+				m["lp"] = p.LP
+			}
+			pairs = append(pairs, m)
+		}
+		defaultMu.RUnlock()
+	}
+	seen := map[string]bool{}
+	for _, p := range pairs {
+		if m, ok := p.(map[string]interface{}); ok {
+			seen[pairKey(str(m["base"]), str(m["quote"]))] = true
+		}
+	}
+	for _, c := range custom {
+		m, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		base := normalizeAssetString(str(m["asset_a"]))
+		quote := normalizeAssetString(str(m["asset_b"]))
+		if base == "" || quote == "" || seen[pairKey(base, quote)] {
+			continue
+		}
+		seen[pairKey(base, quote)] = true
+		name := str(m["label"])
+		if name == "" {
+			name = parseAssetCode(base) + "/" + parseAssetCode(quote)
+		}
+		pairs = append(pairs, map[string]interface{}{
+			"name":          name,
+			"base":          base,
+			"quote":         quote,
+			"show_decimals": pairDefaultDecimals(base, quote),
+		})
+	}
+	doc["pairs"] = pairs
+	return nil
+}
+
+func str(v interface{}) string {
+	s, _ := v.(string)
+	return strings.TrimSpace(s)
+}
+
+func pairKey(base, quote string) string {
+	return normalizeAssetString(base) + "|" + normalizeAssetString(quote)
+}
+
+// normalizeAssetString converts the "native" spelling used by custom pairs
+// to the "XLM:native" spelling used in pairs
+func normalizeAssetString(s string) string {
+	if strings.EqualFold(s, "native") || strings.EqualFold(s, "XLM") {
+		return "XLM:native"
+	}
+	return s
+}
+
+// readConfigFile loads and migrates the document at path. migrated reports
+// whether the file on disk is older than CurrentSchemaVersion.
+func readConfigFile(path string) (cfg *Config, migrated bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, false, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+	migrated, err = migrateDocument(doc)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Round-trip the migrated document through YAML into the typed config
+	upgraded, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal migrated config: %w", err)
+	}
+	cfg = &Config{}
+	if err := yaml.Unmarshal(upgraded, cfg); err != nil {
+		return nil, false, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+	return cfg, migrated, nil
+}
+
+// writeConfigFile writes cfg to path atomically: the new document goes to a
+// temporary file in the same directory which then replaces path. The
+// previous file, if any, is kept as path.bak.
+func writeConfigFile(path string, cfg *Config) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	cfg.SchemaVersion = CurrentSchemaVersion
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config to YAML: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".config-*.yaml.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary config file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	if old, err := os.ReadFile(path); err == nil {
+		if err := os.WriteFile(path+".bak", old, 0644); err != nil {
+			return fmt.Errorf("failed to back up config file: %w", err)
+		}
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace config file: %w", err)
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the config, made the same way a loaded config
+// is: by a round trip through YAML
+func (c *Config) Clone() (*Config, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	out := &Config{}
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	return out, nil
+}
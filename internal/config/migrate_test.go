@@ -0,0 +1,163 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const legacyConfig = `app:
+  version: "0.1.0"
+  default_pair: "USDC/USDZ"
+pairs:
+  - name: "USDC/USDZ"
+    base: "USDC:GA5ZSEJYB37JRC5AVCIA5MOP4RHTM335X2KGX3IHOJAPP5RE34K4KZVN"
+    quote: "USDZ:GAKTLPC4ZV37SSCITQ5IS5AQ4WPF4CF4VZJQPPAROSGXMYOATF5U6XPR"
+    lp: "314e17d86ffc767a6132fba31cc9f53f23ca359d2db788f26f0d364d75e82c57"
+    favorite: true
+preferences:
+  default_order_book_depth: 7
+  streaming: true
+custom_pairs:
+  - asset_a: "USDC:GA5ZSEJYB37JRC5AVCIA5MOP4RHTM335X2KGX3IHOJAPP5RE34K4KZVN"
+    asset_b: "USDZ:GAKTLPC4ZV37SSCITQ5IS5AQ4WPF4CF4VZJQPPAROSGXMYOATF5U6XPR"
+    label: "duplicate"
+  - asset_a: "native"
+    asset_b: "XAUZ:GBKZRDDCC3ZDRCYFZ5JJZRKVJ5EYSLFUHGBAAHUTY2F7WNUVC3F7ZBGM"
+    label: "XLM/XAUZ"
+`
+
+func TestReadConfigFileMigratesCustomPairs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(legacyConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, migrated, err := readConfigFile(path)
+	if err != nil {
+		t.Fatalf("readConfigFile: %v", err)
+	}
+	if !migrated {
+		t.Errorf("expected legacy file to be reported as migrated")
+	}
+	if len(cfg.Pairs) != 2 {
+		t.Fatalf("expected 2 pairs after merge, got %d: %+v", len(cfg.Pairs), cfg.Pairs)
+	}
+	if cfg.Pairs[0].LP == "" || !cfg.Pairs[0].Favorite {
+		t.Errorf("existing pair lost its fields: %+v", cfg.Pairs[0])
+	}
+	p := cfg.Pairs[1]
+	if p.Name != "XLM/XAUZ" || p.Base != "XLM:native" || p.ShowDecimals != 7 {
+		t.Errorf("unexpected migrated pair: %+v", p)
+	}
+	if !cfg.Preferences.Streaming || cfg.Preferences.DefaultOrderBookDepth != 7 {
+		t.Errorf("preferences not preserved: %+v", cfg.Preferences)
+	}
+}
+
+func TestMigrateKeepsDefaultPairsAheadOfCustomPairs(t *testing.T) {
+	SetDefaultPairs([]Pair{
+		{Name: "USDC/USDZ", Base: "USDC:GA5ZSEJYB37JRC5AVCIA5MOP4RHTM335X2KGX3IHOJAPP5RE34K4KZVN",
+			Quote: "USDZ:GAKTLPC4ZV37SSCITQ5IS5AQ4WPF4CF4VZJQPPAROSGXMYOATF5U6XPR", LP: "314e17d8"},
+		{Name: "XLM/USDZ", Base: "native", Quote: "USDZ:GAKTLPC4ZV37SSCITQ5IS5AQ4WPF4CF4VZJQPPAROSGXMYOATF5U6XPR"},
+	})
+	t.Cleanup(func() { SetDefaultPairs(nil) })
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	legacy := `custom_pairs:
+  - asset_a: "native"
+    asset_b: "USDZ:GAKTLPC4ZV37SSCITQ5IS5AQ4WPF4CF4VZJQPPAROSGXMYOATF5U6XPR"
+    label: "already listed"
+  - asset_a: "native"
+    asset_b: "XAUZ:GBKZRDDCC3ZDRCYFZ5JJZRKVJ5EYSLFUHGBAAHUTY2F7WNUVC3F7ZBGM"
+    label: "XLM/XAUZ"
+`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _, err := readConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range cfg.Pairs {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "USDC/USDZ,XLM/USDZ,XLM/XAUZ" {
+		t.Fatalf("pairs after migration: %v", names)
+	}
+	if cfg.Pairs[0].LP != "314e17d8" || cfg.Pairs[1].Base != "XLM:native" {
+		t.Errorf("default pairs lost their fields: %+v", cfg.Pairs[:2])
+	}
+}
+
+func TestWriteConfigFileKeepsBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(legacyConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _, err := readConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeConfigFile(path, cfg); err != nil {
+		t.Fatalf("writeConfigFile: %v", err)
+	}
+
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil || string(backup) != legacyConfig {
+		t.Errorf("backup should hold the previous file, err=%v", err)
+	}
+	written, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(written), "schema_version: 1\n") || strings.Contains(string(written), "custom_pairs") {
+		t.Errorf("unexpected written config:\n%s", written)
+	}
+
+	// Reading the upgraded file is a no-op migration
+	again, migrated, err := readConfigFile(path)
+	if err != nil || migrated || len(again.Pairs) != 2 {
+		t.Errorf("re-read: migrated=%v pairs=%d err=%v", migrated, len(again.Pairs), err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestReadConfigFileRejectsNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("schema_version: 99\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := readConfigFile(path); err == nil {
+		t.Errorf("expected an error for a newer schema_version")
+	}
+}
+
+func TestCloneDoesNotShareEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(legacyConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _, err := readConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clone, err := cfg.Clone()
+	if err != nil {
+		t.Fatalf("Clone: %v", err)
+	}
+	clone.Pairs[0].Name = "renamed"
+	clone.Pairs = append(clone.Pairs, Pair{Name: "extra"})
+
+	if cfg.Pairs[0].Name != "USDC/USDZ" || len(cfg.Pairs) != 2 {
+		t.Errorf("edits to the clone leaked into the original: %+v", cfg.Pairs)
+	}
+	if clone.Pairs[1].Base != cfg.Pairs[1].Base {
+		t.Errorf("clone lost pair fields: %+v", clone.Pairs[1])
+	}
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultNetwork is the profile used when none is selected. Its pairs are the
+// top-level pairs of the config file.
+const DefaultNetwork = "pubnet"
+
+// Network is a named network profile
+type Network struct {
+	Name        string   `yaml:"-"`
+	HorizonURL  string   `yaml:"horizon_url,omitempty"`
+	HorizonURLs []string `yaml:"horizon_urls,omitempty"` // failover endpoints tried after horizon_url
+	Passphrase  string   `yaml:"passphrase,omitempty"`
+	ExplorerURL string   `yaml:"explorer_url,omitempty"` // stellar.expert API base, empty when unsupported
+	Pairs       []Pair   `yaml:"pairs,omitempty"`
+}
+
+// ErrInvalidNetwork is returned for unknown or incomplete network profiles
+var ErrInvalidNetwork = errors.New("invalid network")
+
+// builtinNetworks are always available; config profiles with the same name
+// override individual fields
+var builtinNetworks = map[string]Network{
+	"pubnet": {
+		HorizonURL:  "https://horizon.stellar.org",
+		Passphrase:  "Public Global Stellar Network ; September 2015",
+		ExplorerURL: "https://api.stellar.expert/explorer/public",
+	},
+	"testnet": {
+		HorizonURL:  "https://horizon-testnet.stellar.org",
+		Passphrase:  "Test SDF Network ; September 2015",
+		ExplorerURL: "https://api.stellar.expert/explorer/testnet",
+	},
+	"futurenet": {
+		HorizonURL: "https://horizon-futurenet.stellar.org",
+		Passphrase: "Test SDF Future Network ; October 2022",
+	},
+}
+
+var (
+	activeMu      sync.RWMutex
+	activeNetwork = Network{Name: DefaultNetwork, HorizonURL: builtinNetworks[DefaultNetwork].HorizonURL,
+		Passphrase: builtinNetworks[DefaultNetwork].Passphrase, ExplorerURL: builtinNetworks[DefaultNetwork].ExplorerURL}
+)
+
+// Network resolves a profile by name. An empty name selects the config's
+// default network, or pubnet. A nil config only knows the built-in profiles.
+func (c *Config) Network(name string) (Network, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" && c != nil {
+		name = strings.ToLower(c.DefaultNetwork)
+	}
+	if name == "" {
+		name = DefaultNetwork
+	}
+
+	n, builtin := builtinNetworks[name]
+	var custom *Network
+	if c != nil {
+		custom = c.Networks[name]
+	}
+	if !builtin && custom == nil {
+		return Network{}, fmt.Errorf("%w: unknown network %q (available: %s)", ErrInvalidNetwork, name, strings.Join(c.NetworkNames(), ", "))
+	}
+	if custom != nil {
+		if custom.HorizonURL != "" {
+			n.HorizonURL = custom.HorizonURL
+		}
+		n.HorizonURLs = custom.HorizonURLs
+		if custom.Passphrase != "" {
+			n.Passphrase = custom.Passphrase
+		}
+		if custom.ExplorerURL != "" {
+			n.ExplorerURL = custom.ExplorerURL
+		}
+		n.Pairs = custom.Pairs
+	}
+	if n.HorizonURL == "" {
+		return Network{}, fmt.Errorf("%w: network %q has no horizon_url", ErrInvalidNetwork, name)
+	}
+	n.Name = name
+	return n, nil
+}
+
+// NetworkNames lists the built-in and configured profiles
+func (c *Config) NetworkNames() []string {
+	seen := map[string]bool{}
+	for name := range builtinNetworks {
+		seen[name] = true
+	}
+	if c != nil {
+		for name := range c.Networks {
+			seen[strings.ToLower(name)] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ActivePairs returns the pairs of the active network: the top-level pairs
+// on pubnet, the profile's own pairs elsewhere
+func (c *Config) ActivePairs() []Pair {
+	name := ActiveNetwork().Name
+	if name == DefaultNetwork {
+		return c.Pairs
+	}
+	if n := c.Networks[name]; n != nil {
+		return n.Pairs
+	}
+	return nil
+}
+
+// activePairsRef is ActivePairs for editing, creating the profile entry when
+// the active network has none yet
+func (c *Config) activePairsRef() *[]Pair {
+	name := ActiveNetwork().Name
+	if name == DefaultNetwork {
+		return &c.Pairs
+	}
+	if c.Networks == nil {
+		c.Networks = map[string]*Network{}
+	}
+	if c.Networks[name] == nil {
+		c.Networks[name] = &Network{}
+	}
+	return &c.Networks[name].Pairs
+}
+
+// SetActiveNetwork selects the profile the rest of the process talks to
+func SetActiveNetwork(n Network) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	activeNetwork = n
+}
+
+// ActiveNetwork returns the selected profile, pubnet unless changed
+func ActiveNetwork() Network {
+	activeMu.RLock()
+	defer activeMu.RUnlock()
+	return activeNetwork
+}
+
+// ExplorerURL returns the stellar.expert API base of the active network, or
+// an empty string when the explorer does not cover it
+func ExplorerURL() string {
+	return ActiveNetwork().ExplorerURL
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNetworkMergesCustomProfileOverBuiltin(t *testing.T) {
+	cfg := &Config{
+		DefaultNetwork: "testnet",
+		Networks: map[string]*Network{
+			"testnet": {HorizonURL: "http://localhost:8000", Pairs: []Pair{{Name: "A/B"}}},
+			"local":   {HorizonURL: "http://localhost:8001", Passphrase: "Standalone Network ; February 2017"},
+		},
+	}
+
+	n, err := cfg.Network("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Name != "testnet" || n.HorizonURL != "http://localhost:8000" || n.Passphrase != builtinNetworks["testnet"].Passphrase || len(n.Pairs) != 1 {
+		t.Errorf("default profile: %+v", n)
+	}
+	if n, err := cfg.Network("LOCAL"); err != nil || n.Name != "local" || n.ExplorerURL != "" {
+		t.Errorf("custom profile: %+v %v", n, err)
+	}
+	if _, err := cfg.Network("mainnet"); !errors.Is(err, ErrInvalidNetwork) {
+		t.Errorf("unknown profile: %v", err)
+	}
+	if n, err := (*Config)(nil).Network(""); err != nil || n.Name != DefaultNetwork {
+		t.Errorf("nil config: %+v %v", n, err)
+	}
+}
+
+func TestActivePairsFollowTheNetwork(t *testing.T) {
+	defer SetActiveNetwork(ActiveNetwork())
+	cfg := &Config{Pairs: []Pair{{Name: "pubnet"}}}
+
+	SetActiveNetwork(Network{Name: "testnet"})
+	if len(cfg.ActivePairs()) != 0 || cfg.Networks != nil {
+		t.Fatalf("reading testnet pairs should not touch the config")
+	}
+	*cfg.activePairsRef() = append(*cfg.activePairsRef(), Pair{Name: "testnet"})
+	if got := cfg.ActivePairs(); len(got) != 1 || got[0].Name != "testnet" || len(cfg.Pairs) != 1 {
+		t.Errorf("testnet pairs: %+v, pubnet pairs: %+v", got, cfg.Pairs)
+	}
+
+	SetActiveNetwork(Network{Name: DefaultNetwork})
+	if got := cfg.ActivePairs(); len(got) != 1 || got[0].Name != "pubnet" {
+		t.Errorf("pubnet pairs: %+v", got)
+	}
+}
@@ -0,0 +1,27 @@
+package config
+
+// PegConfig configures the peg monitor: where reference rates come from, the
+// deviation thresholds and the pegged assets to watch
+type PegConfig struct {
+	Source string `yaml:"source,omitempty"` // stub (default), file or http
+	Path   string `yaml:"path,omitempty"`   // rates file, for the file source
+	URL    string `yaml:"url,omitempty"`    // rates endpoint, for the http source
+
+	// fixed rates for the stub source, units per one Base (USD if empty)
+	Base  string             `yaml:"base,omitempty"`
+	Rates map[string]float64 `yaml:"rates,omitempty"`
+
+	WarnBps   float64    `yaml:"warn_bps,omitempty"`
+	BreachBps float64    `yaml:"breach_bps,omitempty"`
+	Assets    []PegAsset `yaml:"assets,omitempty"`
+}
+
+// PegAsset is a pegged asset, priced on-chain against another asset
+type PegAsset struct {
+	Asset      string  `yaml:"asset"`       // curated code, CODE:ISSUER or native
+	Peg        string  `yaml:"peg"`         // reference symbol, e.g. EUR or XAU
+	Against    string  `yaml:"against"`     // counter asset of the observed pair
+	AgainstPeg string  `yaml:"against_peg"` // reference symbol of the counter asset
+	WarnBps    float64 `yaml:"warn_bps,omitempty"`
+	BreachBps  float64 `yaml:"breach_bps,omitempty"`
+}
@@ -0,0 +1,11 @@
+package config
+
+// SLAObligation is what our market making must show on a pair, e.g. at most
+// 30bp spread and 50k USDZ each side within 1% of mid
+type SLAObligation struct {
+	Pair         string  `yaml:"pair"` // BASE/QUOTE
+	MaxSpreadBps float64 `yaml:"max_spread_bps,omitempty"`
+	MinDepth     float64 `yaml:"min_depth,omitempty"`   // each side
+	DepthAsset   string  `yaml:"depth_asset,omitempty"` // base or quote code depth is counted in; quote when unset
+	DepthPct     float64 `yaml:"depth_pct,omitempty"`   // band around mid, percent; 1 when unset
+}
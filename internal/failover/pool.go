@@ -0,0 +1,414 @@
+// Package failover spreads Horizon requests over several endpoints, routing
+// each request to the healthiest one and retrying on the next when it fails.
+package failover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ewmaAlpha weighs the newest latency and error sample
+	ewmaAlpha = 0.3
+	// downAfter consecutive failures take an endpoint out of rotation for
+	// downFor, unless no other endpoint is left
+	downAfter = 3
+	downFor   = 30 * time.Second
+	// maxLag is how many ledgers an endpoint may trail the most advanced one
+	// before it is only used as a last resort
+	maxLag = 5
+	// switchRatio keeps the active endpoint until another scores this much
+	// better, so routing does not flap between similar endpoints
+	switchRatio = 0.8
+	// unsampledMs is the latency assumed for endpoints not yet tried, so
+	// the primary is kept until the others have been probed
+	unsampledMs  = 1000
+	probeTimeout = 5 * time.Second
+	// answerTimeout is how long an endpoint has to answer a request with
+	// its headers before the next one is tried; streams may then run on
+	answerTimeout = 15 * time.Second
+)
+
+// Doer sends HTTP requests; *http.Client satisfies it
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Health is a snapshot of one endpoint's state
+type Health struct {
+	URL       string
+	Latency   time.Duration // moving average, zero before the first sample
+	ErrorRate float64       // moving average of failed requests, 0-1
+	Lag       int64         // ledgers behind the most advanced endpoint, -1 unknown
+	Down      bool
+	LastError string
+}
+
+type endpoint struct {
+	url       string
+	latency   float64 // ms
+	sampled   bool
+	errRate   float64
+	failures  int
+	downUntil time.Time
+	ledger    int64
+	lastErr   string
+}
+
+// Pool routes requests built for the primary endpoint's URL to the
+// healthiest endpoint. It implements the horizonclient HTTP interface and is
+// safe for concurrent use.
+type Pool struct {
+	base   Doer
+	now    func() time.Time
+	answer time.Duration // answerTimeout
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+	active    int
+}
+
+// New returns a pool over urls, the first of which is the primary. Requests
+// are sent through base, http.DefaultClient when nil.
+func New(urls []string, base Doer) (*Pool, error) {
+	p := &Pool{base: base, now: time.Now, answer: answerTimeout}
+	if p.base == nil {
+		p.base = http.DefaultClient
+	}
+	seen := map[string]bool{}
+	for _, u := range urls {
+		u = strings.TrimRight(strings.TrimSpace(u), "/")
+		if u == "" || seen[u] {
+			continue
+		}
+		if _, err := url.Parse(u); err != nil {
+			return nil, fmt.Errorf("horizon url %q: %w", u, err)
+		}
+		seen[u] = true
+		p.endpoints = append(p.endpoints, &endpoint{url: u, ledger: -1})
+	}
+	if len(p.endpoints) == 0 {
+		return nil, errors.New("no horizon urls")
+	}
+	return p, nil
+}
+
+// Primary is the URL clients should be configured with
+func (p *Pool) Primary() string {
+	return p.endpoints[0].url
+}
+
+// Active is the URL requests currently go to
+func (p *Pool) Active() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.endpoints[p.active].url
+}
+
+// Len is the number of endpoints
+func (p *Pool) Len() int {
+	return len(p.endpoints)
+}
+
+// Health reports every endpoint in configuration order
+func (p *Pool) Health() []Health {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	top := p.topLedger()
+	out := make([]Health, len(p.endpoints))
+	for i, e := range p.endpoints {
+		out[i] = Health{
+			URL:       e.url,
+			Latency:   time.Duration(e.latency * float64(time.Millisecond)),
+			ErrorRate: e.errRate,
+			Lag:       e.lag(top),
+			Down:      now.Before(e.downUntil),
+			LastError: e.lastErr,
+		}
+	}
+	return out
+}
+
+// Do sends req to the healthiest endpoint. Transport errors, 429s, 5xx
+// responses and endpoints that do not answer within answerTimeout are
+// retried on the next endpoint; the last endpoint's answer is returned as
+// is.
+func (p *Pool) Do(req *http.Request) (*http.Response, error) {
+	path, ok := p.relative(req.URL.String())
+	if !ok {
+		return p.base.Do(req)
+	}
+	order := p.order()
+	var lastErr error
+	for n, i := range order {
+		last := n == len(order)-1
+		target, err := url.Parse(p.endpoints[i].url + path)
+		if err != nil {
+			return nil, err
+		}
+		// the attempt ends with its body, or when the endpoint stalls
+		ctx, cancel := context.WithCancel(req.Context())
+		stalled := time.AfterFunc(p.answer, cancel)
+		r := req.Clone(ctx)
+		r.URL = target
+		r.Host = ""
+		if req.GetBody != nil {
+			if r.Body, err = req.GetBody(); err != nil {
+				stalled.Stop()
+				cancel()
+				return nil, err
+			}
+		} else if req.Body != nil && n > 0 {
+			// the body was consumed by the first attempt
+			stalled.Stop()
+			cancel()
+			return nil, lastErr
+		}
+
+		start := p.now()
+		resp, err := p.base.Do(r)
+		stalled.Stop()
+		if err == nil {
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+		} else {
+			cancel()
+		}
+		if req.Context().Err() != nil {
+			// the caller gave up; not the endpoint's fault
+			return resp, err
+		}
+		failed := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		reason := ""
+		switch {
+		case err != nil && ctx.Err() != nil:
+			reason = fmt.Sprintf("no answer within %s", p.answer)
+		case err != nil:
+			reason = err.Error()
+		case failed:
+			reason = resp.Status
+		}
+		p.record(i, p.now().Sub(start), failed, reason)
+		if !failed || last {
+			return resp, err
+		}
+		p.order() // let the failure move the active endpoint
+		if resp != nil {
+			resp.Body.Close()
+		}
+		lastErr = fmt.Errorf("%s: %s", p.endpoints[i].url, reason)
+	}
+	return nil, lastErr
+}
+
+// cancelBody ends its attempt's context once the body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// Get issues a GET through Do
+func (p *Pool) Get(u string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return p.Do(req)
+}
+
+// PostForm issues a form POST through Do
+func (p *Pool) PostForm(u string, data url.Values) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, u, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return p.Do(req)
+}
+
+// Check probes every endpoint's root document, recording its latency and
+// history_latest_ledger so lagging endpoints can be avoided
+func (p *Pool) Check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := range p.endpoints {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p.probe(ctx, i)
+		}(i)
+	}
+	wg.Wait()
+	p.order()
+}
+
+// Run checks the endpoints every interval until ctx is done
+func (p *Pool) Run(ctx context.Context, interval time.Duration) {
+	p.Check(ctx)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			p.Check(ctx)
+		}
+	}
+}
+
+func (p *Pool) probe(ctx context.Context, i int) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoints[i].url+"/", nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "application/json")
+	start := p.now()
+	resp, err := p.base.Do(req)
+	if err != nil {
+		if ctx.Err() == nil || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			p.record(i, p.now().Sub(start), true, err.Error())
+		}
+		return
+	}
+	defer resp.Body.Close()
+	var root struct {
+		Ledger int64 `json:"history_latest_ledger"`
+	}
+	if resp.StatusCode/100 != 2 {
+		p.record(i, p.now().Sub(start), true, resp.Status)
+		return
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&root); err != nil {
+		p.record(i, p.now().Sub(start), true, "root: "+err.Error())
+		return
+	}
+	p.record(i, p.now().Sub(start), false, "")
+	p.mu.Lock()
+	p.endpoints[i].ledger = root.Ledger
+	p.mu.Unlock()
+}
+
+// relative strips the primary endpoint from a request URL
+func (p *Pool) relative(u string) (string, bool) {
+	primary := p.endpoints[0].url
+	if !strings.HasPrefix(u, primary) {
+		return "", false
+	}
+	rest := u[len(primary):]
+	if rest != "" && !strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, "?") {
+		return "", false
+	}
+	return rest, true
+}
+
+func (p *Pool) record(i int, took time.Duration, failed bool, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := p.endpoints[i]
+	ms := float64(took) / float64(time.Millisecond)
+	sample := 0.0
+	if failed {
+		sample = 1
+	}
+	if !e.sampled {
+		e.latency, e.errRate, e.sampled = ms, sample, true
+	} else {
+		e.latency += ewmaAlpha * (ms - e.latency)
+		e.errRate += ewmaAlpha * (sample - e.errRate)
+	}
+	if failed {
+		e.failures++
+		e.lastErr = reason
+		if e.failures >= downAfter {
+			e.downUntil = p.now().Add(downFor)
+		}
+		return
+	}
+	e.failures = 0
+	e.downUntil = time.Time{}
+}
+
+// order returns endpoint indexes best first and updates the active endpoint
+func (p *Pool) order() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	top := p.topLedger()
+	idx := make([]int, len(p.endpoints))
+	scores := make([]float64, len(p.endpoints))
+	for i, e := range p.endpoints {
+		idx[i] = i
+		scores[i] = e.score(now, top)
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return scores[idx[a]] < scores[idx[b]] })
+
+	best := idx[0]
+	if best != p.active && scores[best] < scores[p.active]*switchRatio {
+		p.active = best
+	}
+	if p.active != idx[0] {
+		// keep the active endpoint first while it is close enough
+		for n, i := range idx {
+			if i == p.active {
+				copy(idx[1:n+1], idx[:n])
+				idx[0] = p.active
+				break
+			}
+		}
+	}
+	return idx
+}
+
+func (p *Pool) topLedger() int64 {
+	top := int64(-1)
+	for _, e := range p.endpoints {
+		if e.ledger > top {
+			top = e.ledger
+		}
+	}
+	return top
+}
+
+func (e *endpoint) lag(top int64) int64 {
+	if e.ledger < 0 || top < 0 {
+		return -1
+	}
+	return top - e.ledger
+}
+
+// score is lower for healthier endpoints: latency in ms plus penalties for
+// errors and ingestion lag, with down and badly lagging endpoints last
+func (e *endpoint) score(now time.Time, top int64) float64 {
+	latency := e.latency
+	if !e.sampled {
+		latency = unsampledMs
+	}
+	s := latency + e.errRate*5000
+	if lag := e.lag(top); lag > 0 {
+		s += float64(lag) * 1000
+		if lag > maxLag {
+			s += 1e6
+		}
+	}
+	if now.Before(e.downUntil) {
+		s += 1e9
+	}
+	return s + 1 // keeps ratios meaningful for unsampled endpoints
+}
@@ -0,0 +1,159 @@
+package failover
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// horizon fakes a Horizon endpoint at the given ledger that fails with
+// status when it is non-zero
+func horizon(t *testing.T, ledger int64, status *int32, hits *int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits != nil {
+			atomic.AddInt32(hits, 1)
+		}
+		if s := atomic.LoadInt32(status); s != 0 {
+			w.WriteHeader(int(s))
+			return
+		}
+		if r.URL.Path == "/" {
+			fmt.Fprintf(w, `{"history_latest_ledger": %d}`, ledger)
+			return
+		}
+		fmt.Fprintf(w, "ok %s", r.URL.RequestURI())
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, p *Pool, path string) string {
+	t.Helper()
+	resp, err := p.Get(p.Primary() + path)
+	if err != nil {
+		t.Fatalf("get %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return string(b)
+}
+
+func TestDoFailsOverToTheNextEndpoint(t *testing.T) {
+	var failing, ok int32
+	var primaryHits int32
+	primary := horizon(t, 100, &failing, &primaryHits)
+	backup := horizon(t, 100, &ok, nil)
+	p, err := New([]string{primary.URL, backup.URL + "/"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atomic.StoreInt32(&failing, http.StatusBadGateway)
+	if got := get(t, p, "/trades?limit=1"); got != "ok /trades?limit=1" {
+		t.Fatalf("got %q", got)
+	}
+	if p.Active() != backup.URL {
+		t.Errorf("active = %s, want backup", p.Active())
+	}
+	if h := p.Health(); h[0].LastError != "502 Bad Gateway" || h[0].ErrorRate != 1 {
+		t.Errorf("health: %+v", h)
+	}
+	before := atomic.LoadInt32(&primaryHits)
+	get(t, p, "/ledgers")
+	if atomic.LoadInt32(&primaryHits) != before {
+		t.Errorf("the failing endpoint should not be tried first")
+	}
+}
+
+func TestDoFailsOverFromAStalledEndpoint(t *testing.T) {
+	release := make(chan struct{})
+	stalled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(stalled.Close)
+	t.Cleanup(func() { close(release) })
+	var ok int32
+	backup := horizon(t, 100, &ok, nil)
+	p, _ := New([]string{stalled.URL, backup.URL}, nil)
+	p.answer = 50 * time.Millisecond
+
+	if got := get(t, p, "/ledgers"); got != "ok /ledgers" {
+		t.Fatalf("got %q", got)
+	}
+	if h := p.Health(); h[0].LastError != "no answer within 50ms" {
+		t.Errorf("health: %+v", h)
+	}
+}
+
+func TestRepeatedFailuresTakeAnEndpointDown(t *testing.T) {
+	p, _ := New([]string{"http://a", "http://b"}, nil)
+	now := time.Unix(0, 0)
+	p.now = func() time.Time { return now }
+	for i := 0; i < downAfter; i++ {
+		p.record(0, time.Millisecond, true, "timeout")
+	}
+	if h := p.Health(); !h[0].Down {
+		t.Fatalf("not down after %d failures", downAfter)
+	}
+	if order := p.order(); order[0] != 1 {
+		t.Errorf("down endpoint first: %v", order)
+	}
+	now = now.Add(downFor + time.Second)
+	if h := p.Health(); h[0].Down {
+		t.Errorf("still down after %s", downFor)
+	}
+}
+
+func TestCheckAvoidsLaggingEndpoints(t *testing.T) {
+	var ok int32
+	behind := horizon(t, 100, &ok, nil)
+	current := horizon(t, 100+maxLag+1, &ok, nil)
+	p, _ := New([]string{behind.URL, current.URL}, nil)
+
+	p.Check(context.Background())
+	h := p.Health()
+	if h[0].Lag != maxLag+1 || h[1].Lag != 0 {
+		t.Fatalf("lag: %+v", h)
+	}
+	if p.Active() != current.URL {
+		t.Errorf("active = %s, want the up-to-date endpoint", p.Active())
+	}
+}
+
+func TestActiveEndpointIsKeptWhileComparable(t *testing.T) {
+	p, _ := New([]string{"http://a", "http://b"}, nil)
+	now := time.Unix(0, 0)
+	p.now = func() time.Time { return now }
+	p.record(0, 100*time.Millisecond, false, "")
+	p.record(1, 90*time.Millisecond, false, "")
+	if p.order(); p.Active() != "http://a" {
+		t.Errorf("switched for a marginal gain")
+	}
+	p.record(1, 10*time.Millisecond, false, "")
+	p.record(1, 10*time.Millisecond, false, "")
+	if p.order(); p.Active() != "http://b" {
+		t.Errorf("did not switch to a clearly faster endpoint")
+	}
+}
+
+func TestRequestsForOtherHostsPassThrough(t *testing.T) {
+	var ok int32
+	other := horizon(t, 1, &ok, nil)
+	p, _ := New([]string{"http://127.0.0.1:1"}, nil)
+	resp, err := p.Get(other.URL + "/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if h := p.Health(); h[0].Latency != 0 {
+		t.Errorf("foreign request was recorded: %+v", h)
+	}
+}
@@ -0,0 +1,312 @@
+// Package issuer classifies the operations of asset issuer accounts (mints,
+// burns, clawbacks and authorization changes) and keeps a running supply
+// ledger per asset from them.
+package issuer
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/protocols/horizon/operations"
+)
+
+// Kind of issuer activity
+type Kind int
+
+const (
+	Mint           Kind = iota // payment from the issuer
+	Burn                       // payment to the issuer
+	Clawback                   // clawback by the issuer
+	AccountOptions             // set_options on the issuer account
+	TrustlineFlags             // set_trust_line_flags or allow_trust on a holder
+)
+
+var kindNames = [...]string{"mint", "burn", "clawback", "set_options", "trustline flags"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "unknown"
+}
+
+// Event is one classified operation
+type Event struct {
+	ID      string // operation ID; an operation can give a mint and a burn
+	At      time.Time
+	Kind    Kind
+	Issuer  string
+	Code    string   // asset; empty for set_options
+	Account string   // holder paid, paid by, clawed back from or flagged
+	Amount  *big.Rat // mints, burns and clawbacks
+	Detail  string   // flags set and cleared
+	TxHash  string
+}
+
+// Classify turns an operation seen on issuer's account into events; nil for
+// failed operations and ones that do not change supply or authorization
+func Classify(op operations.Operation, issuer string) []Event {
+	if !op.IsTransactionSuccessful() {
+		return nil
+	}
+	var out []Event
+	add := func(b operations.Base, e Event) {
+		e.ID, e.At, e.Issuer, e.TxHash = b.ID, b.LedgerCloseTime, issuer, b.TransactionHash
+		out = append(out, e)
+	}
+	switch o := op.(type) {
+	case operations.Payment:
+		payment(o.Base, o.Asset, o.From, o.To, o.Amount, issuer, add)
+	case operations.PathPayment:
+		pathPayment(o.Payment, o.SourceAssetIssuer, o.SourceAssetCode, o.SourceAmount, issuer, add)
+	case operations.PathPaymentStrictSend:
+		pathPayment(o.Payment, o.SourceAssetIssuer, o.SourceAssetCode, o.SourceAmount, issuer, add)
+	case operations.Clawback:
+		if o.Asset.Issuer == issuer {
+			if amt, ok := parseAmount(o.Amount); ok {
+				add(o.Base, Event{Kind: Clawback, Code: o.Asset.Code, Account: o.From, Amount: amt})
+			}
+		}
+	case operations.SetOptions:
+		if o.SourceAccount == issuer {
+			add(o.Base, Event{Kind: AccountOptions, Detail: optionsDetail(o)})
+		}
+	case operations.SetTrustLineFlags:
+		if o.Asset.Issuer == issuer {
+			add(o.Base, Event{Kind: TrustlineFlags, Code: o.Asset.Code, Account: o.Trustor,
+				Detail: flagsDetail(o.SetFlagsS, o.ClearFlagsS)})
+		}
+	case operations.AllowTrust:
+		if o.Trustee == issuer {
+			detail := "deauthorized"
+			if o.Authorize {
+				detail = "authorized"
+			}
+			add(o.Base, Event{Kind: TrustlineFlags, Code: o.Asset.Code, Account: o.Trustor, Detail: detail})
+		}
+	}
+	return out
+}
+
+func payment(b operations.Base, a base.Asset, from, to, amount, issuer string, add func(operations.Base, Event)) {
+	if a.Issuer != issuer || from == to {
+		return
+	}
+	amt, ok := parseAmount(amount)
+	if !ok {
+		return
+	}
+	switch issuer {
+	case from:
+		add(b, Event{Kind: Mint, Code: a.Code, Account: to, Amount: amt})
+	case to:
+		add(b, Event{Kind: Burn, Code: a.Code, Account: from, Amount: amt})
+	}
+}
+
+// pathPayment reads both legs: the issuer sending its own asset mints it,
+// and its asset arriving at the issuer burns it
+func pathPayment(p operations.Payment, srcIssuer, srcCode, srcAmount, issuer string, add func(operations.Base, Event)) {
+	if p.From == issuer && p.To != issuer && srcIssuer == issuer {
+		if amt, ok := parseAmount(srcAmount); ok {
+			add(p.Base, Event{Kind: Mint, Code: srcCode, Account: p.To, Amount: amt})
+		}
+	}
+	if p.To == issuer && p.From != issuer && p.Asset.Issuer == issuer {
+		if amt, ok := parseAmount(p.Amount); ok {
+			add(p.Base, Event{Kind: Burn, Code: p.Asset.Code, Account: p.From, Amount: amt})
+		}
+	}
+}
+
+func parseAmount(s string) (*big.Rat, bool) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() <= 0 {
+		return nil, false
+	}
+	return r, true
+}
+
+func flagsDetail(set, clear []string) string {
+	var parts []string
+	if len(set) > 0 {
+		parts = append(parts, "set "+strings.Join(set, ", "))
+	}
+	if len(clear) > 0 {
+		parts = append(parts, "cleared "+strings.Join(clear, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func optionsDetail(o operations.SetOptions) string {
+	detail := flagsDetail(o.SetFlagsS, o.ClearFlagsS)
+	if o.HomeDomain != "" {
+		if detail != "" {
+			detail += "; "
+		}
+		detail += "home domain " + o.HomeDomain
+	}
+	if detail == "" {
+		return "signers or thresholds"
+	}
+	return detail
+}
+
+// Supply is an asset's issuance seen since the ledger started
+type Supply struct {
+	Code, Issuer               string
+	Minted, Burned, ClawedBack *big.Rat
+	Mints, Burns, Clawbacks    int
+	AuthChanges                int // trustline authorization changes
+	First, Last                time.Time
+
+	// Seeded is the outstanding supply reported at SeededAt; nil until
+	// Seed. moved is the net of the events after SeededAt.
+	Seeded   *big.Rat
+	SeededAt time.Time
+	moved    *big.Rat
+}
+
+// Outstanding is the seeded supply moved by the events after it; nil while
+// the asset has not been seeded
+func (s Supply) Outstanding() *big.Rat {
+	if s.Seeded == nil {
+		return nil
+	}
+	return new(big.Rat).Add(s.Seeded, s.moved)
+}
+
+// Net is minted less burned and clawed back
+func (s Supply) Net() *big.Rat {
+	n := new(big.Rat).Sub(s.Minted, s.Burned)
+	return n.Sub(n, s.ClawedBack)
+}
+
+// seenKeep is how many event keys the ledger remembers to drop repeats;
+// overlaps between a backfill and a stream are far shorter
+const seenKeep = 10000
+
+// Ledger keeps supply per asset and the latest events, ignoring events it
+// has already seen, so a backfill may overlap a stream
+type Ledger struct {
+	supply    map[string]*Supply // by CODE:ISSUER
+	seen      map[string]bool    // by event ID and kind
+	seenOrder []string           // keys of seen, oldest first
+	recent    []Event            // newest first
+	keep      int
+}
+
+// NewLedger returns a ledger remembering the keep latest events
+func NewLedger(keep int) *Ledger {
+	return &Ledger{supply: map[string]*Supply{}, seen: map[string]bool{}, keep: keep}
+}
+
+// Add records events, returning how many were new
+func (l *Ledger) Add(events ...Event) int {
+	added := 0
+	for _, e := range events {
+		key := e.ID + "/" + e.Kind.String()
+		if l.seen[key] {
+			continue
+		}
+		l.remember(key)
+		added++
+		l.insert(e)
+		if e.Code == "" {
+			continue
+		}
+		s := l.asset(e.Code, e.Issuer)
+		if s.First.IsZero() || e.At.Before(s.First) {
+			s.First = e.At
+		}
+		if e.At.After(s.Last) {
+			s.Last = e.At
+		}
+		moved := s.Seeded != nil && e.At.After(s.SeededAt)
+		switch e.Kind {
+		case Mint:
+			s.Minted.Add(s.Minted, e.Amount)
+			s.Mints++
+			if moved {
+				s.moved.Add(s.moved, e.Amount)
+			}
+		case Burn:
+			s.Burned.Add(s.Burned, e.Amount)
+			s.Burns++
+			if moved {
+				s.moved.Sub(s.moved, e.Amount)
+			}
+		case Clawback:
+			s.ClawedBack.Add(s.ClawedBack, e.Amount)
+			s.Clawbacks++
+			if moved {
+				s.moved.Sub(s.moved, e.Amount)
+			}
+		case TrustlineFlags:
+			s.AuthChanges++
+		}
+	}
+	return added
+}
+
+// remember marks key seen, forgetting the oldest keys beyond seenKeep
+func (l *Ledger) remember(key string) {
+	l.seen[key] = true
+	l.seenOrder = append(l.seenOrder, key)
+	if len(l.seenOrder) > seenKeep {
+		delete(l.seen, l.seenOrder[0])
+		l.seenOrder = l.seenOrder[1:]
+	}
+}
+
+// Seed sets an asset's outstanding supply as reported at at. Only events
+// added after the seed move it, so seed before adding events.
+func (l *Ledger) Seed(code, issuer string, amount *big.Rat, at time.Time) {
+	s := l.asset(code, issuer)
+	s.Seeded, s.SeededAt, s.moved = new(big.Rat).Set(amount), at, new(big.Rat)
+}
+
+// Track lists an asset in Supplies before any of its events arrive
+func (l *Ledger) Track(code, issuer string) { l.asset(code, issuer) }
+
+func (l *Ledger) asset(code, issuer string) *Supply {
+	key := code + ":" + issuer
+	s, ok := l.supply[key]
+	if !ok {
+		s = &Supply{Code: code, Issuer: issuer, Minted: new(big.Rat), Burned: new(big.Rat), ClawedBack: new(big.Rat)}
+		l.supply[key] = s
+	}
+	return s
+}
+
+// insert keeps recent newest first; backfilled events arrive out of order
+func (l *Ledger) insert(e Event) {
+	i := sort.Search(len(l.recent), func(i int) bool { return !l.recent[i].At.After(e.At) })
+	l.recent = append(l.recent, Event{})
+	copy(l.recent[i+1:], l.recent[i:])
+	l.recent[i] = e
+	if l.keep > 0 && len(l.recent) > l.keep {
+		l.recent = l.recent[:l.keep]
+	}
+}
+
+// Supplies returns every asset's supply by code
+func (l *Ledger) Supplies() []Supply {
+	out := make([]Supply, 0, len(l.supply))
+	for _, s := range l.supply {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Code != out[j].Code {
+			return out[i].Code < out[j].Code
+		}
+		return out[i].Issuer < out[j].Issuer
+	})
+	return out
+}
+
+// Recent returns the latest events, newest first
+func (l *Ledger) Recent() []Event { return l.recent }
@@ -0,0 +1,128 @@
+package issuer
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/protocols/horizon/operations"
+)
+
+const (
+	iss    = "GISSUER"
+	holder = "GHOLDER"
+)
+
+var usdz = base.Asset{Type: "credit_alphanum4", Code: "USDZ", Issuer: iss}
+
+func opBase(id string, min int) operations.Base {
+	return operations.Base{ID: id, TransactionSuccessful: true, SourceAccount: iss,
+		LedgerCloseTime: time.Date(2024, 5, 1, 0, min, 0, 0, time.UTC)}
+}
+
+func TestClassify(t *testing.T) {
+	mint := operations.Payment{Base: opBase("1", 0), Asset: usdz, From: iss, To: holder, Amount: "100.5"}
+	burn := operations.Payment{Base: opBase("2", 1), Asset: usdz, From: holder, To: iss, Amount: "40"}
+	other := operations.Payment{Base: opBase("3", 2), Asset: base.Asset{Type: "native"}, From: iss, To: holder, Amount: "5"}
+	failed := mint
+	failed.TransactionSuccessful = false
+	claw := operations.Clawback{Base: opBase("4", 3), Asset: usdz, From: holder, Amount: "10"}
+	flags := operations.SetTrustLineFlags{Base: opBase("5", 4), Asset: usdz, Trustor: holder,
+		ClearFlagsS: []string{"authorized"}}
+	opts := operations.SetOptions{Base: opBase("6", 5), SetFlagsS: []string{"auth_clawback_enabled"}}
+
+	cases := []struct {
+		op   operations.Operation
+		kind Kind
+		n    int
+	}{
+		{mint, Mint, 1}, {burn, Burn, 1}, {other, 0, 0}, {failed, 0, 0},
+		{claw, Clawback, 1}, {flags, TrustlineFlags, 1}, {opts, AccountOptions, 1},
+	}
+	for _, c := range cases {
+		got := Classify(c.op, iss)
+		if len(got) != c.n || (c.n > 0 && got[0].Kind != c.kind) {
+			t.Errorf("Classify(%s) = %+v, want %d %s", c.op.GetID(), got, c.n, c.kind)
+		}
+	}
+	if e := Classify(mint, iss)[0]; e.Account != holder || e.Amount.Cmp(big.NewRat(201, 2)) != 0 || e.Code != "USDZ" {
+		t.Errorf("mint = %+v", e)
+	}
+	if e := Classify(flags, iss)[0]; e.Detail != "cleared authorized" {
+		t.Errorf("flags detail = %q", e.Detail)
+	}
+
+	// a path payment arriving at the issuer burns what arrived, not what was sent
+	swap := operations.PathPaymentStrictSend{Payment: operations.Payment{Base: opBase("7", 6), Asset: usdz,
+		From: holder, To: iss, Amount: "9"}, SourceAssetType: "native", SourceAmount: "50"}
+	if got := Classify(swap, iss); len(got) != 1 || got[0].Kind != Burn || got[0].Amount.Cmp(big.NewRat(9, 1)) != 0 {
+		t.Errorf("path payment to issuer = %+v", got)
+	}
+}
+
+func TestLedger(t *testing.T) {
+	l := NewLedger(3)
+	l.Track("EURZ", iss)
+	mint := Classify(operations.Payment{Base: opBase("1", 0), Asset: usdz, From: iss, To: holder, Amount: "100"}, iss)
+	burn := Classify(operations.Payment{Base: opBase("2", 2), Asset: usdz, From: holder, To: iss, Amount: "30"}, iss)
+	claw := Classify(operations.Clawback{Base: opBase("3", 1), Asset: usdz, From: holder, Amount: "5"}, iss)
+	if n := l.Add(append(append(mint, burn...), claw...)...); n != 3 {
+		t.Errorf("Add = %d, want 3", n)
+	}
+	// a backfill overlapping the stream adds nothing twice
+	if n := l.Add(burn...); n != 0 {
+		t.Errorf("re-Add = %d, want 0", n)
+	}
+
+	s := l.Supplies()
+	if len(s) != 2 || s[0].Code != "EURZ" || s[1].Code != "USDZ" {
+		t.Fatalf("Supplies = %+v", s)
+	}
+	u := s[1]
+	if u.Net().Cmp(big.NewRat(65, 1)) != 0 || u.Mints != 1 || u.Burns != 1 || u.Clawbacks != 1 {
+		t.Errorf("USDZ supply = %+v net %s", u, u.Net().FloatString(2))
+	}
+	if !u.First.Equal(opBase("", 0).LedgerCloseTime) || !u.Last.Equal(opBase("", 2).LedgerCloseTime) {
+		t.Errorf("USDZ seen %s to %s", u.First, u.Last)
+	}
+
+	r := l.Recent()
+	if len(r) != 3 || r[0].ID != "2" || r[1].ID != "3" || r[2].ID != "1" {
+		t.Errorf("Recent = %+v", r)
+	}
+}
+
+func TestLedgerSeed(t *testing.T) {
+	l := NewLedger(10)
+	l.Seed("USDZ", iss, big.NewRat(1000, 1), opBase("", 1).LedgerCloseTime)
+	before := Classify(operations.Payment{Base: opBase("1", 0), Asset: usdz, From: iss, To: holder, Amount: "100"}, iss)
+	mint := Classify(operations.Payment{Base: opBase("2", 2), Asset: usdz, From: iss, To: holder, Amount: "50"}, iss)
+	claw := Classify(operations.Clawback{Base: opBase("3", 3), Asset: usdz, From: holder, Amount: "20"}, iss)
+	l.Add(append(append(before, mint...), claw...)...)
+
+	u := l.Supplies()[0]
+	if got := u.Outstanding(); got == nil || got.Cmp(big.NewRat(1030, 1)) != 0 {
+		t.Errorf("Outstanding = %v, want 1030", got)
+	}
+	if u.Net().Cmp(big.NewRat(130, 1)) != 0 {
+		t.Errorf("Net = %s, want 130", u.Net().FloatString(0))
+	}
+	if l.Track("EURZ", iss); l.Supplies()[0].Outstanding() != nil {
+		t.Errorf("an unseeded asset has no outstanding supply")
+	}
+}
+
+func TestLedgerForgetsOldKeys(t *testing.T) {
+	l := NewLedger(1)
+	for i := 0; i < seenKeep+10; i++ {
+		l.Add(Event{ID: fmt.Sprint(i), Kind: AccountOptions, Issuer: iss})
+	}
+	if len(l.seen) != seenKeep || len(l.seenOrder) != seenKeep {
+		t.Errorf("remembers %d keys, want %d", len(l.seen), seenKeep)
+	}
+	if n := l.Add(Event{ID: fmt.Sprint(seenKeep + 9), Kind: AccountOptions, Issuer: iss}); n != 0 {
+		t.Errorf("a recent event was added twice")
+	}
+}
@@ -0,0 +1,86 @@
+// Package jsonl keeps records as JSON Lines in the files of a directory.
+// Files are only appended to, so another process may read them while they
+// grow and old files can simply be deleted.
+package jsonl
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store appends records of type T to the files of Dir
+type Store[T any] struct {
+	Dir string
+
+	mu sync.Mutex
+}
+
+// Open returns a store in dir, creating it if needed
+func Open[T any](dir string) (*Store[T], error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Store[T]{Dir: dir}, nil
+}
+
+// Append writes each record as a line of the file file names for it,
+// opening each file once
+func (s *Store[T]) Append(file func(T) string, records ...T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	byFile := map[string][]byte{}
+	var order []string
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		name := file(r)
+		if _, ok := byFile[name]; !ok {
+			order = append(order, name)
+		}
+		byFile[name] = append(append(byFile[name], line...), '\n')
+	}
+	for _, name := range order {
+		f, err := os.OpenFile(filepath.Join(s.Dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(byFile[name])
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read returns the records of file that keep accepts, in the order they
+// were appended; none when the file does not exist. A line cut short by a
+// crash is skipped rather than failing the read.
+func (s *Store[T]) Read(file string, keep func(T) bool) ([]T, error) {
+	f, err := os.Open(filepath.Join(s.Dir, file))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []T
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r T
+		if json.Unmarshal(sc.Bytes(), &r) != nil || !keep(r) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out, sc.Err()
+}
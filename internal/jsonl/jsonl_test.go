@@ -0,0 +1,45 @@
+package jsonl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type rec struct {
+	File string `json:"file"`
+	N    int    `json:"n"`
+}
+
+func TestAppendAndRead(t *testing.T) {
+	s, err := Open[rec](filepath.Join(t.TempDir(), "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := func(r rec) string { return r.File }
+	if err := s.Append(file, rec{"a", 1}, rec{"b", 2}, rec{"a", 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append(file, rec{"a", 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a crash left half a line behind
+	f, err := os.OpenFile(filepath.Join(s.Dir, "a"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"file":"a","n`)
+	f.Close()
+
+	got, err := s.Read("a", func(r rec) bool { return r.N > 1 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].N != 3 || got[1].N != 4 {
+		t.Errorf("Read(a) = %+v, want records 3 and 4", got)
+	}
+	if got, err := s.Read("missing", func(rec) bool { return true }); err != nil || got != nil {
+		t.Errorf("Read(missing) = %+v, %v", got, err)
+	}
+}
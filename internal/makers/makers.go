@@ -0,0 +1,186 @@
+// Package makers tracks the open offers of our own market-making accounts
+// on a pair and measures their share of the book.
+package makers
+
+import (
+	"math/big"
+	"sort"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+)
+
+// Side of the book an offer rests on
+type Side int
+
+const (
+	Bid Side = iota // selling quote for base
+	Ask             // selling base for quote
+)
+
+// Offer is one of our offers as a book level: Price in quote per base and
+// Amount in base units, like orderbook.Level
+type Offer struct {
+	ID      int64
+	Account string
+	Side    Side
+	Price   *big.Rat
+	Amount  *big.Rat
+}
+
+// AssetName names a Horizon asset as CODE:ISSUER, or XLM:native
+func AssetName(a hProtocol.Asset) string {
+	if a.Type == "native" {
+		return "XLM:native"
+	}
+	return a.Code + ":" + a.Issuer
+}
+
+// FromHorizon converts an offer on the base/quote pair, named as by
+// AssetName; ok is false for offers on other pairs and empty offers
+func FromHorizon(o hProtocol.Offer, base, quote string) (Offer, bool) {
+	selling, buying := AssetName(o.Selling), AssetName(o.Buying)
+	amount, ok := new(big.Rat).SetString(o.Amount)
+	if !ok || amount.Sign() <= 0 || o.PriceR.N <= 0 || o.PriceR.D <= 0 {
+		return Offer{}, false
+	}
+	// price_r is buying per selling
+	price := big.NewRat(int64(o.PriceR.N), int64(o.PriceR.D))
+	switch {
+	case selling == base && buying == quote:
+		return Offer{ID: o.ID, Account: o.Seller, Side: Ask, Price: price, Amount: amount}, true
+	case selling == quote && buying == base:
+		// amount is in quote; price_r is base per quote
+		return Offer{ID: o.ID, Account: o.Seller, Side: Bid, Price: new(big.Rat).Inv(price),
+			Amount: amount.Mul(amount, price)}, true
+	}
+	return Offer{}, false
+}
+
+// Set holds offers by ID
+type Set map[int64]Offer
+
+// Replace swaps account's offers for a fresh listing of them
+func (s Set) Replace(account string, offers []Offer) {
+	for id, o := range s {
+		if o.Account == account {
+			delete(s, id)
+		}
+	}
+	for _, o := range offers {
+		s[o.ID] = o
+	}
+}
+
+// Remove drops an offer, e.g. one streamed with a zero amount
+func (s Set) Remove(id int64) { delete(s, id) }
+
+// Side returns the offers on one side, best price first
+func (s Set) Side(side Side) []Offer {
+	var out []Offer
+	for _, o := range s {
+		if o.Side == side {
+			out = append(out, o)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if c := out[i].Price.Cmp(out[j].Price); c != 0 {
+			return (c > 0) == (side == Bid)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// At returns our amount resting at each level, nil where we have none
+func At(levels []orderbook.Level, ours []Offer) []*big.Rat {
+	byPrice := map[string]*big.Rat{}
+	for _, o := range ours {
+		k := o.Price.RatString()
+		if byPrice[k] == nil {
+			byPrice[k] = new(big.Rat)
+		}
+		byPrice[k].Add(byPrice[k], o.Amount)
+	}
+	out := make([]*big.Rat, len(levels))
+	for i, l := range levels {
+		out[i] = byPrice[l.Price.RatString()]
+	}
+	return out
+}
+
+// Share is our part of some amount of the book, in base units
+type Share struct {
+	Ours, Total float64
+}
+
+// Fraction is Ours/Total, 0 for an empty book
+func (s Share) Fraction() float64 {
+	if s.Total <= 0 {
+		return 0
+	}
+	return s.Ours / s.Total
+}
+
+// Band is the depth within Pct percent of mid
+type Band struct {
+	Pct      float64
+	Bid, Ask Share
+}
+
+// Report is our presence in a book
+type Report struct {
+	TopBid, TopAsk Share // at the best price
+	Bands          []Band
+}
+
+// Measure compares our offers with the book at the best prices and within
+// each percentage of mid. Bands are empty while either side of the book is.
+func Measure(book orderbook.Book, ours Set, bandsPct []float64) Report {
+	bids, asks := ours.Side(Bid), ours.Side(Ask)
+	var r Report
+	if len(book.Bids) > 0 {
+		r.TopBid = top(book.Bids[0], bids)
+	}
+	if len(book.Asks) > 0 {
+		r.TopAsk = top(book.Asks[0], asks)
+	}
+	mid := book.Mid()
+	if mid == nil {
+		return r
+	}
+	for _, pct := range bandsPct {
+		pctR := new(big.Rat).SetFloat64(pct)
+		if pctR == nil {
+			continue
+		}
+		// the same bounds as DepthWithin
+		frac := new(big.Rat).Quo(pctR, big.NewRat(100, 1))
+		lo := new(big.Rat).Mul(mid, new(big.Rat).Sub(big.NewRat(1, 1), frac))
+		hi := new(big.Rat).Mul(mid, new(big.Rat).Add(big.NewRat(1, 1), frac))
+		totalBid, totalAsk := book.DepthWithin(pctR)
+		b := Band{Pct: pct}
+		b.Bid = Share{Ours: sumWhere(bids, func(p *big.Rat) bool { return p.Cmp(lo) >= 0 }), Total: orderbook.Float(totalBid)}
+		b.Ask = Share{Ours: sumWhere(asks, func(p *big.Rat) bool { return p.Cmp(hi) <= 0 }), Total: orderbook.Float(totalAsk)}
+		r.Bands = append(r.Bands, b)
+	}
+	return r
+}
+
+func top(best orderbook.Level, ours []Offer) Share {
+	return Share{
+		Ours:  sumWhere(ours, func(p *big.Rat) bool { return p.Cmp(best.Price) == 0 }),
+		Total: orderbook.Float(best.Amount),
+	}
+}
+
+func sumWhere(offers []Offer, keep func(price *big.Rat) bool) float64 {
+	sum := new(big.Rat)
+	for _, o := range offers {
+		if keep(o.Price) {
+			sum.Add(sum, o.Amount)
+		}
+	}
+	return orderbook.Float(sum)
+}
@@ -0,0 +1,111 @@
+package makers
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+)
+
+const (
+	usdz = "USDZ:GAKTLPC4ZV37SSCITQ5IS5AQ4WPF4CF4VZJQPPAROSGXMYOATF5U6XPR"
+	us   = "GUS"
+)
+
+func offer(id int64, selling, buying hProtocol.Asset, amount string, n, d int32) hProtocol.Offer {
+	return hProtocol.Offer{ID: id, Seller: us, Selling: selling, Buying: buying, Amount: amount,
+		PriceR: hProtocol.Price{N: n, D: d}}
+}
+
+var (
+	xlm   = hProtocol.Asset{Type: "native"}
+	usdzA = hProtocol.Asset{Type: "credit_alphanum4", Code: "USDZ", Issuer: "GAKTLPC4ZV37SSCITQ5IS5AQ4WPF4CF4VZJQPPAROSGXMYOATF5U6XPR"}
+	other = hProtocol.Asset{Type: "credit_alphanum4", Code: "EURZ", Issuer: "GX"}
+)
+
+func level(price, amount string) orderbook.Level {
+	p, _ := new(big.Rat).SetString(price)
+	a, _ := new(big.Rat).SetString(amount)
+	return orderbook.Level{Price: p, Amount: a}
+}
+
+func TestFromHorizonOrientsOffers(t *testing.T) {
+	// selling 100 XLM at 0.25 USDZ each: an ask
+	ask, ok := FromHorizon(offer(1, xlm, usdzA, "100", 1, 4), "XLM:native", usdz)
+	if !ok || ask.Side != Ask || ask.Price.RatString() != "1/4" || ask.Amount.RatString() != "100" {
+		t.Errorf("ask = %+v, %v", ask, ok)
+	}
+	// selling 50 USDZ at 5 XLM per USDZ: a bid for 250 XLM at 0.2
+	bid, ok := FromHorizon(offer(2, usdzA, xlm, "50", 5, 1), "XLM:native", usdz)
+	if !ok || bid.Side != Bid || bid.Price.RatString() != "1/5" || bid.Amount.RatString() != "250" {
+		t.Errorf("bid = %+v, %v", bid, ok)
+	}
+	if _, ok := FromHorizon(offer(3, other, xlm, "10", 1, 1), "XLM:native", usdz); ok {
+		t.Errorf("offer on another pair accepted")
+	}
+	if _, ok := FromHorizon(offer(4, xlm, usdzA, "0", 1, 1), "XLM:native", usdz); ok {
+		t.Errorf("empty offer accepted")
+	}
+}
+
+func TestSetReplaceAndAt(t *testing.T) {
+	s := Set{}
+	a, _ := FromHorizon(offer(1, xlm, usdzA, "100", 1, 4), "XLM:native", usdz)
+	b, _ := FromHorizon(offer(2, xlm, usdzA, "20", 1, 4), "XLM:native", usdz)
+	c, _ := FromHorizon(offer(3, xlm, usdzA, "5", 13, 50), "XLM:native", usdz)
+	s.Replace(us, []Offer{a, b, c})
+	c.Account = "GTHEM"
+	s[c.ID] = c
+
+	asks := s.Side(Ask)
+	if len(asks) != 3 || asks[0].ID != 1 || asks[2].ID != 3 {
+		t.Fatalf("Side(Ask) = %+v", asks)
+	}
+	got := At([]orderbook.Level{level("0.25", "500"), level("0.255", "10"), level("0.26", "5")}, asks)
+	if got[0].RatString() != "120" || got[1] != nil || got[2].RatString() != "5" {
+		t.Errorf("At = %v", got)
+	}
+
+	s.Replace(us, nil)
+	if len(s) != 1 || s[3].Account != "GTHEM" {
+		t.Errorf("Replace kept %v", s)
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	book := orderbook.Book{
+		Bids: []orderbook.Level{level("0.99", "100"), level("0.985", "300"), level("0.9", "1000")},
+		Asks: []orderbook.Level{level("1.01", "200"), level("1.05", "100")},
+	}
+	s := Set{
+		1: {ID: 1, Account: us, Side: Bid, Price: level("0.99", "0").Price, Amount: big.NewRat(25, 1)},
+		2: {ID: 2, Account: us, Side: Bid, Price: level("0.9", "0").Price, Amount: big.NewRat(500, 1)},
+		3: {ID: 3, Account: us, Side: Ask, Price: level("1.05", "0").Price, Amount: big.NewRat(100, 1)},
+	}
+	r := Measure(book, s, []float64{1, 5})
+	if r.TopBid != (Share{25, 100}) || r.TopAsk != (Share{0, 200}) {
+		t.Errorf("top = %+v %+v", r.TopBid, r.TopAsk)
+	}
+	if len(r.Bands) != 2 {
+		t.Fatalf("bands = %+v", r.Bands)
+	}
+	// mid is 1: within 1% are the bids from 0.99 and the asks to 1.01
+	one := r.Bands[0]
+	if one.Bid != (Share{25, 100}) || one.Ask != (Share{0, 200}) {
+		t.Errorf("1%% band = %+v", one)
+	}
+	five := r.Bands[1]
+	if five.Bid != (Share{25, 400}) || five.Ask != (Share{100, 300}) {
+		t.Errorf("5%% band = %+v", five)
+	}
+	if f := five.Ask.Fraction(); math.Abs(f-1.0/3) > 1e-12 {
+		t.Errorf("Fraction = %v", f)
+	}
+
+	if r := Measure(orderbook.Book{Bids: book.Bids}, s, []float64{1}); len(r.Bands) != 0 || r.TopBid.Ours != 25 {
+		t.Errorf("one-sided book: %+v", r)
+	}
+}
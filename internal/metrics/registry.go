@@ -0,0 +1,208 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Labels are the label name/value pairs identifying one series
+type Labels map[string]string
+
+// Registry holds gauge families and renders them in the Prometheus text
+// exposition format (version 0.0.4)
+type Registry struct {
+	mu       sync.RWMutex
+	families map[string]*family
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    string // "gauge" or "counter"
+	samples map[string]sample
+}
+
+type sample struct {
+	labels Labels
+	value  float64
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// NewGauge registers a gauge family with its help text
+func (r *Registry) NewGauge(name, help string) {
+	r.register(name, help, "gauge")
+}
+
+// NewCounter registers a counter family with its help text
+func (r *Registry) NewCounter(name, help string) {
+	r.register(name, help, "counter")
+}
+
+func (r *Registry) register(name, help, kind string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.families[name]; ok {
+		return
+	}
+	r.families[name] = &family{name: name, help: help, kind: kind, samples: make(map[string]sample)}
+}
+
+// Set sets the value of a series, registering an undocumented gauge if needed
+func (r *Registry) Set(name string, labels Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := r.familyLocked(name)
+	f.samples[signature(labels)] = sample{labels: copyLabels(labels), value: value}
+}
+
+// Add increments a series by delta
+func (r *Registry) Add(name string, labels Labels, delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := r.familyLocked(name)
+	key := signature(labels)
+	s, ok := f.samples[key]
+	if !ok {
+		s = sample{labels: copyLabels(labels)}
+	}
+	s.value += delta
+	f.samples[key] = s
+}
+
+// Delete removes a series so it is no longer exported
+func (r *Registry) Delete(name string, labels Labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		delete(f.samples, signature(labels))
+	}
+}
+
+func (r *Registry) familyLocked(name string) *family {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, kind: "gauge", samples: make(map[string]sample)}
+		r.families[name] = f
+	}
+	return f
+}
+
+// WriteTo writes all families in the text exposition format, sorted by
+// family name and label signature so output is stable between scrapes
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, name := range names {
+		f := r.families[name]
+		if len(f.samples) == 0 {
+			continue
+		}
+		if f.help != "" {
+			fmt.Fprintf(cw, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		}
+		fmt.Fprintf(cw, "# TYPE %s %s\n", f.name, f.kind)
+
+		keys := make([]string, 0, len(f.samples))
+		for k := range f.samples {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			s := f.samples[k]
+			fmt.Fprintf(cw, "%s%s %s\n", f.name, formatLabels(s.labels), formatValue(s.value))
+		}
+	}
+	if err := cw.w.(*bufio.Writer).Flush(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, cw.err
+}
+
+// Handler serves the registry for Prometheus scrapes
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+func signature(labels Labels) string {
+	return formatLabels(labels)
+}
+
+func copyLabels(labels Labels) Labels {
+	out := make(Labels, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
+
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, k := range names {
+		parts = append(parts, fmt.Sprintf("%s=\"%s\"", k, escapeLabelValue(labels[k])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeLabelValue(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string       { return helpEscaper.Replace(s) }
+
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
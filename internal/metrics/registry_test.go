@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	r := NewRegistry()
+	r.NewGauge("sdexmon_best_bid", "Best bid price")
+	r.NewCounter("sdexmon_poll_errors_total", "Failed polls")
+	r.Set("sdexmon_best_bid", Labels{"pair": "XLM/USDZ"}, 0.25)
+	r.Set("sdexmon_best_bid", Labels{"pair": "USDC/USDZ"}, 1)
+	r.Add("sdexmon_poll_errors_total", Labels{"pair": "XLM/USDZ"}, 1)
+	r.Add("sdexmon_poll_errors_total", Labels{"pair": "XLM/USDZ"}, 2)
+	r.Set("sdexmon_mid_price", Labels{"pair": `odd"pair\`}, math.NaN())
+	r.NewGauge("sdexmon_unused", "No samples, not exported")
+
+	var sb strings.Builder
+	if _, err := r.WriteTo(&sb); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	expected := `# HELP sdexmon_best_bid Best bid price
+# TYPE sdexmon_best_bid gauge
+sdexmon_best_bid{pair="USDC/USDZ"} 1
+sdexmon_best_bid{pair="XLM/USDZ"} 0.25
+# TYPE sdexmon_mid_price gauge
+sdexmon_mid_price{pair="odd\"pair\\"} NaN
+# HELP sdexmon_poll_errors_total Failed polls
+# TYPE sdexmon_poll_errors_total counter
+sdexmon_poll_errors_total{pair="XLM/USDZ"} 3
+`
+	if sb.String() != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", sb.String(), expected)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	r := NewRegistry()
+	r.Set("g", Labels{"a": "1"}, 1)
+	r.Set("g", Labels{"a": "2"}, 2)
+	r.Delete("g", Labels{"a": "1"})
+
+	var sb strings.Builder
+	r.WriteTo(&sb)
+	if strings.Contains(sb.String(), `a="1"`) || !strings.Contains(sb.String(), `g{a="2"} 2`) {
+		t.Errorf("unexpected output after delete:\n%s", sb.String())
+	}
+}
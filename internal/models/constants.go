@@ -34,7 +34,9 @@ import (
 //    Example:
 //    {"USDT", "USDZ"},
 //
-// 3. ADDING LIQUIDITY POOL DATA (OPTIONAL):
+// 3. OVERRIDING LIQUIDITY POOL DATA (OPTIONAL):
+//    - Pools are discovered from Horizon for any pair, so this is only
+//      needed to pin a pair to a specific pool
 //    - Add both directions to LiquidityPoolIDs map
 //    - Use "BASE-QUOTE" and "QUOTE-BASE" as keys
 //    - Use the 64-character hex pool ID as the value
@@ -62,7 +64,7 @@ import (
 // - Asset codes must be 1-12 characters, A-Z and 0-9 only
 // - Issuer addresses must be exactly 56 characters starting with 'G'
 // - Pool IDs must be exactly 64 hex characters (0-9, a-f)
-// - Both directions of each pair must be added to LiquidityPoolIDs
+// - Both directions of each overridden pair must be added to LiquidityPoolIDs
 // - Test your changes by building and running the application
 //
 // =============================================================================
@@ -138,7 +140,7 @@ var CuratedPairs = []PairOption{
 	// {"BASE", "QUOTE"}, // BASE/QUOTE - Description
 }
 
-// LiquidityPoolIDs maps pair keys to pool IDs for liquidity pool data
+// LiquidityPoolIDs maps pair keys to pool IDs that override pool discovery
 // IMPORTANT: Both directions must be included for each pool
 // Format: "BASE-QUOTE" -> "pool_id" AND "QUOTE-BASE" -> "pool_id"
 // Pool IDs are obtained from stellar.expert liquidity pools section
@@ -15,6 +15,8 @@ const (
 	AssetBDomainInput
 	AssetBSelection
 	PairConfirmation
+	ManagePairs
+	EditPairLabel
 )
 
 // StellarExpertAsset represents an asset from stellar.expert API
@@ -52,6 +54,12 @@ type MaintenanceState struct {
 	ConfirmationData *PairConfirmationData
 	LoadingMessage   string
 	ErrorMessage     string
+
+	// Manage pairs: remove, reorder and rename configured pairs
+	PairCursor    int
+	ConfirmRemove bool
+	LabelInput    textinput.Model
+	StatusMessage string
 }
 
 // Messages for maintenance mode
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/sdexmon/sdexmon/internal/alerts"
+)
+
+// Email sends each event as a plain text message over SMTP, with STARTTLS
+// when the server offers it. Auth is only used when User is set.
+type Email struct {
+	SinkName string
+	Addr     string // host:port
+	From     string
+	To       []string
+	User     string
+	Pass     string
+
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error // smtp.SendMail
+}
+
+func (m Email) Name() string { return m.SinkName }
+
+func (m Email) Notify(ctx context.Context, e alerts.Event) error {
+	var auth smtp.Auth
+	if m.User != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return fmt.Errorf("%w: smtp address %q: %v", ErrPermanent, m.Addr, err)
+		}
+		auth = smtp.PlainAuth("", m.User, m.Pass, host)
+	}
+	send := m.send
+	if send == nil {
+		send = smtp.SendMail
+	}
+	msg := m.message(e, time.Now())
+	// SendMail takes no context; give up waiting when ctx ends and let the
+	// send finish on its own
+	done := make(chan error, 1)
+	go func() { done <- send(m.Addr, auth, m.From, m.To, msg) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m Email) message(e alerts.Event, now time.Time) []byte {
+	subject := "sdexmon: " + Text(e)
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	a := e.Alert
+	fmt.Fprintf(&b, "%s\r\n\r\n", Text(e))
+	fmt.Fprintf(&b, "rule:      %s\r\n", a.Rule)
+	fmt.Fprintf(&b, "pair:      %s\r\n", a.Pair)
+	fmt.Fprintf(&b, "condition: %s\r\n", a.Condition)
+	fmt.Fprintf(&b, "value:     %g\r\n", a.Value)
+	fmt.Fprintf(&b, "since:     %s\r\n", a.Since.UTC().Format(time.RFC3339))
+	if e.Resolved {
+		fmt.Fprintf(&b, "resolved:  %s\r\n", a.ResolvedAt.UTC().Format(time.RFC3339))
+	}
+	return []byte(b.String())
+}
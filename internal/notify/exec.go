@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/sdexmon/sdexmon/internal/alerts"
+)
+
+// Exec runs a command for each event. The Payload is written to its stdin
+// as JSON and the main fields are set as SDEXMON_ALERT_* variables. The
+// command is run directly, not through a shell.
+type Exec struct {
+	SinkName string
+	Command  []string
+}
+
+func (x Exec) Name() string { return x.SinkName }
+
+func (x Exec) Notify(ctx context.Context, e alerts.Event) error {
+	p := PayloadOf(e)
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPermanent, err)
+	}
+	cmd := exec.CommandContext(ctx, x.Command[0], x.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(append(data, '\n'))
+	cmd.Env = append(os.Environ(),
+		"SDEXMON_ALERT_STATUS="+p.Status,
+		"SDEXMON_ALERT_ID="+strconv.Itoa(p.ID),
+		"SDEXMON_ALERT_RULE="+p.Rule,
+		"SDEXMON_ALERT_PAIR="+p.Pair,
+		"SDEXMON_ALERT_CONDITION="+p.Condition,
+		"SDEXMON_ALERT_SEVERITY="+p.Severity,
+		"SDEXMON_ALERT_VALUE="+strconv.FormatFloat(p.Value, 'g', -1, 64),
+		"SDEXMON_ALERT_TEXT="+p.Text,
+	)
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("%w: %v", ErrPermanent, err)
+		}
+		msg := strings.TrimSpace(out.String())
+		if len(msg) > 200 {
+			msg = msg[:200] + "…"
+		}
+		if msg != "" {
+			return fmt.Errorf("%s: %w: %s", x.Command[0], err, msg)
+		}
+		return fmt.Errorf("%s: %w", x.Command[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/sdexmon/sdexmon/internal/alerts"
+)
+
+// Webhook posts each event as a JSON Payload
+type Webhook struct {
+	SinkName string
+	URL      string
+	Headers  map[string]string
+	Client   *http.Client // http.DefaultClient when nil
+}
+
+func (w Webhook) Name() string { return w.SinkName }
+
+func (w Webhook) Notify(ctx context.Context, e alerts.Event) error {
+	return post(ctx, w.Client, w.URL, w.Headers, PayloadOf(e))
+}
+
+// Chat posts to a Slack, Mattermost or Discord incoming webhook. Slack and
+// Mattermost take {"text": ...}; Discord takes {"content": ...}.
+type Chat struct {
+	SinkName string
+	URL      string
+	Discord  bool
+	Client   *http.Client // http.DefaultClient when nil
+}
+
+func (c Chat) Name() string { return c.SinkName }
+
+func (c Chat) Notify(ctx context.Context, e alerts.Event) error {
+	body := map[string]string{"text": Text(e)}
+	if c.Discord {
+		body = map[string]string{"content": Text(e)}
+	}
+	return post(ctx, c.Client, c.URL, nil, body)
+}
+
+// post sends body as JSON. Client errors other than 408 and 429 are
+// permanent; server errors and transport failures may be retried. Errors
+// name the host only: chat webhook URLs carry their token in the path.
+func post(ctx context.Context, client *http.Client, rawURL string, headers map[string]string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPermanent, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPermanent, redactErr(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return redactErr(err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("post %s: %s", redactURL(rawURL), resp.Status)
+	default:
+		return fmt.Errorf("%w: post %s: %s", ErrPermanent, redactURL(rawURL), resp.Status)
+	}
+}
+
+// redactURL keeps the scheme and host of a URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "(url)"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// redactErr replaces the URL net/http puts in its errors
+func redactErr(err error) error {
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		redacted := *ue
+		redacted.URL = redactURL(ue.URL)
+		return &redacted
+	}
+	return err
+}
@@ -0,0 +1,206 @@
+// Package notify delivers alert events outside the TUI: JSON webhooks,
+// Slack-style chat webhooks, email and local commands. Every sink can be
+// wrapped with rate limiting, retries and a dry-run mode.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sdexmon/sdexmon/internal/alerts"
+)
+
+// Sink types accepted by New
+const (
+	TypeWebhook    = "webhook"
+	TypeSlack      = "slack"
+	TypeDiscord    = "discord"
+	TypeMattermost = "mattermost"
+	TypeEmail      = "email"
+	TypeExec       = "exec"
+)
+
+var (
+	// ErrInvalidSink is returned by New for an unknown type or missing
+	// settings
+	ErrInvalidSink = errors.New("notify: invalid sink")
+	// ErrRateLimited is returned for events dropped by a sink's limit
+	ErrRateLimited = errors.New("notify: rate limited")
+	// ErrPermanent marks failures a retry cannot fix, such as a 4xx reply
+	ErrPermanent = errors.New("notify: permanent failure")
+)
+
+// Spec describes one sink
+type Spec struct {
+	Name    string
+	Type    string
+	URL     string            // webhook and chat types
+	Headers map[string]string // webhook
+	SMTP    string            // email: host:port
+	From    string
+	To      []string
+	User    string
+	Pass    string
+	Command []string // exec: program and arguments
+
+	Options
+}
+
+// Options guard a sink
+type Options struct {
+	Limit   int           // events per Per; 0 is unlimited
+	Per     time.Duration // one minute when zero
+	Retries int           // further attempts after a failure
+	Backoff time.Duration // first retry delay, doubling; one second when zero
+	DryRun  bool          // log instead of sending
+	Logf    func(format string, args ...any)
+}
+
+// New builds the sink a spec describes, wrapped with its options
+func New(s Spec) (alerts.Sink, error) {
+	var sink alerts.Sink
+	name := s.Name
+	if name == "" {
+		name = s.Type
+	}
+	switch t := strings.ToLower(s.Type); t {
+	case TypeWebhook:
+		if s.URL == "" {
+			return nil, fmt.Errorf("%w %q: webhook needs a url", ErrInvalidSink, name)
+		}
+		sink = Webhook{SinkName: name, URL: s.URL, Headers: s.Headers}
+	case TypeSlack, TypeDiscord, TypeMattermost:
+		if s.URL == "" {
+			return nil, fmt.Errorf("%w %q: %s needs a url", ErrInvalidSink, name, t)
+		}
+		sink = Chat{SinkName: name, URL: s.URL, Discord: t == TypeDiscord}
+	case TypeEmail:
+		if s.SMTP == "" || s.From == "" || len(s.To) == 0 {
+			return nil, fmt.Errorf("%w %q: email needs smtp, from and to", ErrInvalidSink, name)
+		}
+		sink = Email{SinkName: name, Addr: s.SMTP, From: s.From, To: s.To, User: s.User, Pass: s.Pass}
+	case TypeExec:
+		if len(s.Command) == 0 {
+			return nil, fmt.Errorf("%w %q: exec needs a command", ErrInvalidSink, name)
+		}
+		sink = Exec{SinkName: name, Command: s.Command}
+	default:
+		return nil, fmt.Errorf("%w %q: unknown type %q (webhook, slack, discord, mattermost, email or exec)", ErrInvalidSink, name, s.Type)
+	}
+	return Guard(sink, s.Options), nil
+}
+
+// Guard wraps a sink with rate limiting, retries and dry-run
+func Guard(s alerts.Sink, o Options) alerts.Sink {
+	if o.Per <= 0 {
+		o.Per = time.Minute
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = time.Second
+	}
+	if o.Logf == nil {
+		o.Logf = func(string, ...any) {}
+	}
+	return &guarded{sink: s, opts: o}
+}
+
+type guarded struct {
+	sink alerts.Sink
+	opts Options
+
+	mu   sync.Mutex
+	sent []time.Time // within the last Per
+}
+
+func (g *guarded) Name() string { return g.sink.Name() }
+
+func (g *guarded) Notify(ctx context.Context, e alerts.Event) error {
+	if !g.allow(time.Now()) {
+		return fmt.Errorf("%w: more than %d events in %s", ErrRateLimited, g.opts.Limit, g.opts.Per)
+	}
+	if g.opts.DryRun {
+		g.opts.Logf("notify %s (dry run): %s", g.Name(), Text(e))
+		return nil
+	}
+	delay := g.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		err := g.sink.Notify(ctx, e)
+		if err == nil || errors.Is(err, ErrPermanent) || attempt == g.opts.Retries {
+			return err
+		}
+		g.opts.Logf("notify %s: %v; retrying in %s", g.Name(), err, delay)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (gave up: %v)", err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// allow counts an event against the limit
+func (g *guarded) allow(now time.Time) bool {
+	if g.opts.Limit <= 0 {
+		return true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for len(g.sent) > 0 && now.Sub(g.sent[0]) >= g.opts.Per {
+		g.sent = g.sent[1:]
+	}
+	if len(g.sent) >= g.opts.Limit {
+		return false
+	}
+	g.sent = append(g.sent, now)
+	return true
+}
+
+// Text is the one-line summary chat, email and exec sinks send
+func Text(e alerts.Event) string {
+	a := e.Alert
+	pair := a.Pair
+	if pair == "" {
+		pair = "network"
+	}
+	if e.Resolved {
+		return fmt.Sprintf("resolved: %s %s: %s", a.Rule, pair, a.Condition)
+	}
+	sev := a.Severity
+	if sev == "" {
+		sev = "alert"
+	}
+	return fmt.Sprintf("[%s] %s %s: %s (value %g, since %s)", sev, a.Rule, pair, a.Condition, a.Value,
+		a.Since.UTC().Format(time.RFC3339))
+}
+
+// Payload is the JSON body the webhook sink posts
+type Payload struct {
+	Status     string     `json:"status"` // firing or resolved
+	ID         int        `json:"id"`
+	Rule       string     `json:"rule"`
+	Pair       string     `json:"pair,omitempty"`
+	Condition  string     `json:"condition"`
+	Severity   string     `json:"severity,omitempty"`
+	Value      float64    `json:"value"`
+	Since      time.Time  `json:"since"`
+	FiredAt    time.Time  `json:"fired_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	Text       string     `json:"text"`
+}
+
+// PayloadOf describes an event for the webhook sink
+func PayloadOf(e alerts.Event) Payload {
+	a := e.Alert
+	p := Payload{Status: "firing", ID: a.ID, Rule: a.Rule, Pair: a.Pair, Condition: a.Condition,
+		Severity: a.Severity, Value: a.Value, Since: a.Since, FiredAt: a.FiredAt, Text: Text(e)}
+	if e.Resolved {
+		p.Status = "resolved"
+		at := a.ResolvedAt
+		p.ResolvedAt = &at
+	}
+	return p
+}
@@ -0,0 +1,235 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sdexmon/sdexmon/internal/alerts"
+)
+
+var fired = alerts.Event{Alert: alerts.Alert{ID: 7, Rule: "wide spread", Pair: "USDZ/ZARZ",
+	Condition: "spread_bps > 50", Severity: "critical", Value: 63.5,
+	Since: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), FiredAt: time.Date(2024, 5, 1, 12, 2, 0, 0, time.UTC)}}
+
+// recorder stands in for a webhook endpoint, answering with the queued
+// statuses and then 200
+type recorder struct {
+	mu       sync.Mutex
+	statuses []int
+	bodies   []map[string]any
+	headers  []http.Header
+}
+
+func (r *recorder) server(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Errorf("body is not JSON: %v", err)
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.bodies = append(r.bodies, body)
+		r.headers = append(r.headers, req.Header.Clone())
+		if len(r.statuses) > 0 {
+			w.WriteHeader(r.statuses[0])
+			r.statuses = r.statuses[1:]
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWebhookPostsPayload(t *testing.T) {
+	rec := &recorder{}
+	srv := rec.server(t)
+	sink, err := New(Spec{Type: TypeWebhook, URL: srv.URL, Headers: map[string]string{"X-Token": "s3cret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved := fired
+	resolved.Resolved = true
+	resolved.Alert.ResolvedAt = fired.Alert.FiredAt.Add(time.Minute)
+	for _, e := range []alerts.Event{fired, resolved} {
+		if err := sink.Notify(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(rec.bodies) != 2 {
+		t.Fatalf("got %d posts, want 2", len(rec.bodies))
+	}
+	first := rec.bodies[0]
+	if first["status"] != "firing" || first["rule"] != "wide spread" || first["value"] != 63.5 || first["id"] != 7.0 {
+		t.Errorf("firing payload = %v", first)
+	}
+	if _, ok := first["resolved_at"]; ok {
+		t.Errorf("firing payload has resolved_at")
+	}
+	if rec.bodies[1]["status"] != "resolved" || rec.bodies[1]["resolved_at"] != "2024-05-01T12:03:00Z" {
+		t.Errorf("resolved payload = %v", rec.bodies[1])
+	}
+	if rec.headers[0].Get("X-Token") != "s3cret" {
+		t.Errorf("custom header not sent")
+	}
+}
+
+func TestChatShapes(t *testing.T) {
+	rec := &recorder{}
+	srv := rec.server(t)
+	for _, typ := range []string{TypeSlack, TypeMattermost, TypeDiscord} {
+		sink, err := New(Spec{Type: typ, URL: srv.URL})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Notify(context.Background(), fired); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := Text(fired)
+	if !strings.HasPrefix(want, "[critical] wide spread USDZ/ZARZ") {
+		t.Errorf("Text = %q", want)
+	}
+	for i, key := range []string{"text", "text", "content"} {
+		if rec.bodies[i][key] != want || len(rec.bodies[i]) != 1 {
+			t.Errorf("post %d = %v, want {%s: %q}", i, rec.bodies[i], key, want)
+		}
+	}
+}
+
+func TestRetriesServerErrorsOnly(t *testing.T) {
+	rec := &recorder{statuses: []int{http.StatusBadGateway, http.StatusTooManyRequests}}
+	srv := rec.server(t)
+	sink, _ := New(Spec{Type: TypeWebhook, URL: srv.URL, Options: Options{Retries: 2, Backoff: time.Millisecond}})
+	if err := sink.Notify(context.Background(), fired); err != nil {
+		t.Fatalf("should succeed on the third attempt: %v", err)
+	}
+	if len(rec.bodies) != 3 {
+		t.Errorf("got %d attempts, want 3", len(rec.bodies))
+	}
+
+	rec = &recorder{statuses: []int{http.StatusBadRequest}}
+	srv = rec.server(t)
+	sink, _ = New(Spec{Type: TypeWebhook, URL: srv.URL, Options: Options{Retries: 2, Backoff: time.Millisecond}})
+	if err := sink.Notify(context.Background(), fired); !errors.Is(err, ErrPermanent) {
+		t.Fatalf("400 should fail permanently, got %v", err)
+	}
+	if len(rec.bodies) != 1 {
+		t.Errorf("400 was retried: %d attempts", len(rec.bodies))
+	}
+
+	rec = &recorder{statuses: []int{500, 500, 500, 500}}
+	srv = rec.server(t)
+	sink, _ = New(Spec{Type: TypeWebhook, URL: srv.URL, Options: Options{Retries: 1, Backoff: time.Millisecond}})
+	if err := sink.Notify(context.Background(), fired); err == nil || len(rec.bodies) != 2 {
+		t.Errorf("got %v after %d attempts, want an error after 2", err, len(rec.bodies))
+	}
+}
+
+func TestErrorsHideTheWebhookPath(t *testing.T) {
+	rec := &recorder{statuses: []int{http.StatusForbidden}}
+	srv := rec.server(t)
+	sink, _ := New(Spec{Type: TypeSlack, URL: srv.URL + "/services/T000/B000/secret"})
+	err := sink.Notify(context.Background(), fired)
+	if err == nil || strings.Contains(err.Error(), "secret") || !strings.Contains(err.Error(), srv.URL) {
+		t.Errorf("status error = %v", err)
+	}
+
+	sink, _ = New(Spec{Type: TypeSlack, URL: "http://127.0.0.1:1/services/T000/B000/secret"})
+	if err := sink.Notify(context.Background(), fired); err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("transport error = %v", err)
+	}
+}
+
+func TestRateLimitAndDryRun(t *testing.T) {
+	rec := &recorder{}
+	srv := rec.server(t)
+	sink, _ := New(Spec{Type: TypeSlack, URL: srv.URL, Options: Options{Limit: 2, Per: time.Hour}})
+	for i := 0; i < 3; i++ {
+		err := sink.Notify(context.Background(), fired)
+		if i < 2 && err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 && !errors.Is(err, ErrRateLimited) {
+			t.Errorf("third event: got %v, want rate limited", err)
+		}
+	}
+	if len(rec.bodies) != 2 {
+		t.Errorf("got %d posts, want 2", len(rec.bodies))
+	}
+
+	var logged []string
+	dry, _ := New(Spec{Type: TypeWebhook, URL: srv.URL, Options: Options{DryRun: true,
+		Logf: func(format string, args ...any) { logged = append(logged, format) }}})
+	if err := dry.Notify(context.Background(), fired); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.bodies) != 2 || len(logged) != 1 {
+		t.Errorf("dry run posted (%d posts) or did not log (%d lines)", len(rec.bodies), len(logged))
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	var gotTo []string
+	var gotMsg string
+	m := Email{SinkName: "ops", Addr: "mail.example:587", From: "sdexmon@example", To: []string{"ops@example"},
+		User: "u", Pass: "p", send: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			if a == nil || addr != "mail.example:587" {
+				t.Errorf("auth %v addr %s", a, addr)
+			}
+			gotTo, gotMsg = to, string(msg)
+			return nil
+		}}
+	if err := m.Notify(context.Background(), fired); err != nil {
+		t.Fatal(err)
+	}
+	if len(gotTo) != 1 || !strings.Contains(gotMsg, "Subject: sdexmon: [critical] wide spread") ||
+		!strings.Contains(gotMsg, "condition: spread_bps > 50") {
+		t.Errorf("message to %v:\n%s", gotTo, gotMsg)
+	}
+}
+
+func TestExecPassesEvent(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	sink, err := New(Spec{Type: TypeExec, Command: []string{"sh", "-c", `cat > "$0"; echo "$SDEXMON_ALERT_RULE" >> "$0"`, out}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Notify(context.Background(), fired); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"rule":"wide spread"`) || !strings.HasSuffix(string(data), "\nwide spread\n") {
+		t.Errorf("command saw %q", data)
+	}
+
+	fail, _ := New(Spec{Type: TypeExec, Command: []string{"sh", "-c", "echo boom; exit 3"}})
+	if err := fail.Notify(context.Background(), fired); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("got %v, want the command's output in the error", err)
+	}
+}
+
+func TestNewRejectsIncompleteSpecs(t *testing.T) {
+	for _, s := range []Spec{
+		{Type: "pager"},
+		{Type: TypeWebhook},
+		{Type: TypeDiscord},
+		{Type: TypeEmail, SMTP: "mail:25"},
+		{Type: TypeExec},
+	} {
+		if _, err := New(s); !errors.Is(err, ErrInvalidSink) {
+			t.Errorf("New(%+v) = %v, want ErrInvalidSink", s, err)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package orderbook
+
+import (
+	"math/big"
+	"sort"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+)
+
+// Level is one price level of a merged book. Price is in quote per base and
+// Amount is in base units, both exact.
+type Level struct {
+	Price  *big.Rat
+	Amount *big.Rat
+}
+
+// Total returns the quote value of the level (amount * price)
+func (l Level) Total() *big.Rat {
+	return new(big.Rat).Mul(l.Amount, l.Price)
+}
+
+// Book is a merged order book with both sides sorted best first
+type Book struct {
+	Bids []Level
+	Asks []Level
+}
+
+// Merge combines the base->quote book with the quote->base book.
+//
+// Horizon reports bids with the amount in the counter asset, so direct bids
+// are converted to base units (amount / price). Reverse levels are inverted:
+// a reverse bid (someone selling base) becomes an ask at 1/price and a reverse
+// ask (someone selling quote) becomes a bid at 1/price, with the amount
+// converted to base units (amount * price). All of this is done on the n/d
+// rationals Horizon returns, so the same offers seen from both directions
+// land on exactly the same price and are kept once rather than duplicated.
+func Merge(direct, reverse hProtocol.OrderBookSummary) Book {
+	bids := map[string]Level{}
+	asks := map[string]Level{}
+
+	for _, pl := range direct.Asks {
+		if price, amount, ok := parseLevel(pl); ok {
+			add(asks, price, amount)
+		}
+	}
+	for _, pl := range direct.Bids {
+		if price, amount, ok := parseLevel(pl); ok {
+			add(bids, price, new(big.Rat).Quo(amount, price))
+		}
+	}
+
+	// Reverse levels only fill prices the direct book does not already have
+	revAsks := map[string]Level{}
+	revBids := map[string]Level{}
+	for _, pl := range reverse.Bids {
+		if price, amount, ok := parseLevel(pl); ok {
+			// reverse bid amount is in base (reverse counter) units already
+			add(revAsks, new(big.Rat).Inv(price), amount)
+		}
+	}
+	for _, pl := range reverse.Asks {
+		if price, amount, ok := parseLevel(pl); ok {
+			add(revBids, new(big.Rat).Inv(price), new(big.Rat).Mul(amount, price))
+		}
+	}
+	fill(asks, revAsks)
+	fill(bids, revBids)
+
+	b := Book{Bids: levels(bids), Asks: levels(asks)}
+	sort.Slice(b.Bids, func(i, j int) bool { return b.Bids[i].Price.Cmp(b.Bids[j].Price) > 0 })
+	sort.Slice(b.Asks, func(i, j int) bool { return b.Asks[i].Price.Cmp(b.Asks[j].Price) < 0 })
+	return b
+}
+
+// parseLevel returns the exact price and amount of a Horizon price level,
+// preferring the price_r rational over the rounded decimal string
+func parseLevel(pl hProtocol.PriceLevel) (price, amount *big.Rat, ok bool) {
+	if pl.PriceR.D != 0 {
+		price = big.NewRat(int64(pl.PriceR.N), int64(pl.PriceR.D))
+	} else if price, ok = new(big.Rat).SetString(pl.Price); !ok {
+		return nil, nil, false
+	}
+	amount, ok = new(big.Rat).SetString(pl.Amount)
+	if !ok || price.Sign() <= 0 || amount.Sign() <= 0 {
+		return nil, nil, false
+	}
+	return price, amount, true
+}
+
+// add sums amount into the level at price
+func add(side map[string]Level, price, amount *big.Rat) {
+	key := price.RatString()
+	if l, ok := side[key]; ok {
+		l.Amount.Add(l.Amount, amount)
+		return
+	}
+	side[key] = Level{Price: price, Amount: new(big.Rat).Set(amount)}
+}
+
+// fill copies reverse levels into side. A reverse level at a price the side
+// already has mirrors the same offers and is skipped.
+func fill(side, reverse map[string]Level) {
+	for key, l := range reverse {
+		if _, ok := side[key]; ok {
+			continue
+		}
+		add(side, l.Price, l.Amount)
+	}
+}
+
+func levels(side map[string]Level) []Level {
+	out := make([]Level, 0, len(side))
+	for _, l := range side {
+		out = append(out, l)
+	}
+	return out
+}
+
+// Empty reports whether the book has no levels on either side
+func (b Book) Empty() bool {
+	return len(b.Bids) == 0 && len(b.Asks) == 0
+}
+
+// BestBid returns the highest bid price, or nil
+func (b Book) BestBid() *big.Rat {
+	if len(b.Bids) == 0 {
+		return nil
+	}
+	return b.Bids[0].Price
+}
+
+// BestAsk returns the lowest ask price, or nil
+func (b Book) BestAsk() *big.Rat {
+	if len(b.Asks) == 0 {
+		return nil
+	}
+	return b.Asks[0].Price
+}
+
+// Mid returns the mid between best bid and best ask, or nil when either
+// side is empty
+func (b Book) Mid() *big.Rat {
+	bid, ask := b.BestBid(), b.BestAsk()
+	if bid == nil || ask == nil {
+		return nil
+	}
+	mid := new(big.Rat).Add(bid, ask)
+	return mid.Quo(mid, big.NewRat(2, 1))
+}
+
+// SpreadPercent returns the bid/ask spread as a percentage of mid, floored
+// at zero for crossed books, or nil when either side is empty
+func (b Book) SpreadPercent() *big.Rat {
+	mid := b.Mid()
+	if mid == nil {
+		return nil
+	}
+	sp := new(big.Rat).Sub(b.BestAsk(), b.BestBid())
+	if sp.Sign() < 0 {
+		return new(big.Rat)
+	}
+	sp.Quo(sp, mid)
+	return sp.Mul(sp, big.NewRat(100, 1))
+}
+
+// DepthWithin returns the base amount of bids priced no more than pct
+// percent below mid and of asks priced no more than pct percent above it
+func (b Book) DepthWithin(pct *big.Rat) (bids, asks *big.Rat) {
+	bids, asks = new(big.Rat), new(big.Rat)
+	mid := b.Mid()
+	if mid == nil {
+		return bids, asks
+	}
+	frac := new(big.Rat).Quo(pct, big.NewRat(100, 1))
+	lo := new(big.Rat).Mul(mid, new(big.Rat).Sub(big.NewRat(1, 1), frac))
+	hi := new(big.Rat).Mul(mid, new(big.Rat).Add(big.NewRat(1, 1), frac))
+	for _, l := range b.Bids {
+		if l.Price.Cmp(lo) < 0 {
+			break
+		}
+		bids.Add(bids, l.Amount)
+	}
+	for _, l := range b.Asks {
+		if l.Price.Cmp(hi) > 0 {
+			break
+		}
+		asks.Add(asks, l.Amount)
+	}
+	return bids, asks
+}
+
+// WithinRatio keeps the levels of side whose price is between lo and hi times
+// the best (first) price
+func WithinRatio(side []Level, lo, hi *big.Rat) []Level {
+	if len(side) == 0 {
+		return side
+	}
+	best := side[0].Price
+	lower := new(big.Rat).Mul(best, lo)
+	upper := new(big.Rat).Mul(best, hi)
+	out := make([]Level, 0, len(side))
+	for _, l := range side {
+		if l.Price.Cmp(lower) >= 0 && l.Price.Cmp(upper) <= 0 {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// Format rounds r to the given number of decimals for display
+func Format(r *big.Rat, decimals int) string {
+	if r == nil {
+		return ""
+	}
+	return r.FloatString(decimals)
+}
+
+// Float converts r to the nearest float64, 0 when nil
+func Float(r *big.Rat) float64 {
+	if r == nil {
+		return 0
+	}
+	f, _ := r.Float64()
+	return f
+}
@@ -0,0 +1,76 @@
+package orderbook
+
+import (
+	"math/big"
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+)
+
+func level(n, d int32, price, amount string) hProtocol.PriceLevel {
+	return hProtocol.PriceLevel{PriceR: hProtocol.Price{N: n, D: d}, Price: price, Amount: amount}
+}
+
+func TestMergeDedupesMirroredOffers(t *testing.T) {
+	// An offer selling 3 quote for 1 base shows up as a bid at 3 in the direct
+	// book and as an ask at 1/3 in the reverse book
+	direct := hProtocol.OrderBookSummary{
+		Asks: []hProtocol.PriceLevel{level(10, 3, "3.3333333", "2.0000000")},
+		Bids: []hProtocol.PriceLevel{level(3, 1, "3.0000000", "3.0000000")},
+	}
+	reverse := hProtocol.OrderBookSummary{
+		Asks: []hProtocol.PriceLevel{level(1, 3, "0.3333333", "3.0000000")},
+		Bids: []hProtocol.PriceLevel{level(3, 10, "0.3000000", "2.0000000")},
+	}
+
+	b := Merge(direct, reverse)
+	if len(b.Bids) != 1 || len(b.Asks) != 1 {
+		t.Fatalf("expected mirrored levels to merge, got %d bids %d asks", len(b.Bids), len(b.Asks))
+	}
+	if b.Bids[0].Price.Cmp(big.NewRat(3, 1)) != 0 || b.Bids[0].Amount.Cmp(big.NewRat(1, 1)) != 0 {
+		t.Errorf("unexpected bid %s @ %s", b.Bids[0].Amount.RatString(), b.Bids[0].Price.RatString())
+	}
+	if b.Asks[0].Price.Cmp(big.NewRat(10, 3)) != 0 || b.Asks[0].Amount.Cmp(big.NewRat(2, 1)) != 0 {
+		t.Errorf("unexpected ask %s @ %s", b.Asks[0].Amount.RatString(), b.Asks[0].Price.RatString())
+	}
+	if got := Format(b.SpreadPercent(), 4); got != "10.5263" {
+		t.Errorf("spread = %s, expected 10.5263", got)
+	}
+}
+
+func TestMergeSortsAndFillsReverseOnly(t *testing.T) {
+	direct := hProtocol.OrderBookSummary{
+		Asks: []hProtocol.PriceLevel{level(21, 10, "2.1000000", "1.0000000")},
+		Bids: []hProtocol.PriceLevel{level(19, 10, "1.9000000", "3.8000000")},
+	}
+	reverse := hProtocol.OrderBookSummary{
+		// reverse-only ask at 2.05 and bid at 1.95
+		Bids: []hProtocol.PriceLevel{level(20, 41, "0.4878049", "5.0000000")},
+		Asks: []hProtocol.PriceLevel{level(20, 39, "0.5128205", "10.0000000")},
+	}
+
+	b := Merge(direct, reverse)
+	if len(b.Bids) != 2 || len(b.Asks) != 2 {
+		t.Fatalf("expected 2 levels per side, got %d bids %d asks", len(b.Bids), len(b.Asks))
+	}
+	if got := Format(b.BestBid(), 7); got != "1.9500000" {
+		t.Errorf("best bid = %s", got)
+	}
+	if got := Format(b.BestAsk(), 7); got != "2.0500000" {
+		t.Errorf("best ask = %s", got)
+	}
+	if got := Format(b.Bids[0].Amount, 7); got != "5.1282051" {
+		t.Errorf("reverse bid amount = %s", got)
+	}
+	if got := Format(b.Bids[1].Amount, 7); got != "2.0000000" {
+		t.Errorf("direct bid amount = %s", got)
+	}
+	if got := Format(b.Mid(), 7); got != "2.0000000" {
+		t.Errorf("mid = %s", got)
+	}
+
+	bids, asks := b.DepthWithin(big.NewRat(3, 1))
+	if Format(bids, 7) != "5.1282051" || Format(asks, 7) != "5.0000000" {
+		t.Errorf("depth within 3%% = %s / %s", Format(bids, 7), Format(asks, 7))
+	}
+}
@@ -0,0 +1,178 @@
+// Package peg compares on-chain prices of pegged assets with reference
+// FX and commodity rates and grades the deviation against thresholds.
+package peg
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Rates are reference rates quoted like common FX APIs: units of each symbol
+// per one unit of Base, e.g. base USD with EUR 0.92 and XAU 0.00042
+type Rates struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+	At    time.Time          `json:"-"` // when the source produced them
+}
+
+// Cross returns how many units of to one unit of from is worth
+func (r Rates) Cross(from, to string) (float64, bool) {
+	a, ok1 := r.rate(from)
+	b, ok2 := r.rate(to)
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+	return b / a, true
+}
+
+func (r Rates) rate(symbol string) (float64, bool) {
+	symbol = strings.ToUpper(symbol)
+	if symbol == strings.ToUpper(r.Base) {
+		return 1, true
+	}
+	for k, v := range r.Rates {
+		if strings.ToUpper(k) == symbol && v > 0 && !math.IsInf(v, 0) {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// Peg is a pegged asset observed on its pair against another asset. The
+// on-chain price is Against per Asset, so the expected price is the
+// reference cross rate from Ref to AgainstRef.
+type Peg struct {
+	Asset      string // label, e.g. EURZ
+	Ref        string // reference symbol the asset tracks, e.g. EUR
+	Against    string // counter asset label, e.g. USDC
+	AgainstRef string // reference symbol of the counter asset, e.g. USD
+
+	// per-asset thresholds in basis points; zero uses the defaults
+	WarnBps, BreachBps float64
+}
+
+// Thresholds grade absolute deviations in basis points
+type Thresholds struct {
+	WarnBps   float64
+	BreachBps float64
+}
+
+// DefaultThresholds apply when none are configured
+var DefaultThresholds = Thresholds{WarnBps: 50, BreachBps: 100}
+
+// Level is how far a price is off its peg
+type Level int
+
+const (
+	Unknown Level = iota // no price or no reference
+	OK
+	Warn
+	Breach
+)
+
+func (l Level) String() string {
+	switch l {
+	case OK:
+		return "ok"
+	case Warn:
+		return "warn"
+	case Breach:
+		return "breach"
+	}
+	return "unknown"
+}
+
+// Observation is the on-chain price of a peg's asset in Against units. Zero
+// or NaN means the venue has no price.
+type Observation struct {
+	Book float64 // order book mid
+	Pool float64 // liquidity pool reserve ratio
+}
+
+// Reading is a peg's deviation from its reference. Deviations are NaN when
+// either side is unknown.
+type Reading struct {
+	Peg
+	Expected float64
+	Book     float64
+	Pool     float64
+	BookBps  float64
+	PoolBps  float64
+	Level    Level // the worse of the two venues
+}
+
+// Evaluate grades each peg's observation against the reference rates
+func Evaluate(pegs []Peg, rates Rates, obs map[string]Observation, th Thresholds) []Reading {
+	out := make([]Reading, 0, len(pegs))
+	for _, p := range pegs {
+		o := obs[p.Asset]
+		r := Reading{Peg: p, Expected: math.NaN(), Book: known(o.Book), Pool: known(o.Pool),
+			BookBps: math.NaN(), PoolBps: math.NaN()}
+		if expected, ok := rates.Cross(p.Ref, p.AgainstRef); ok {
+			r.Expected = expected
+			r.BookBps = deviation(r.Book, expected)
+			r.PoolBps = deviation(r.Pool, expected)
+		}
+		t := th
+		if p.WarnBps > 0 {
+			t.WarnBps = p.WarnBps
+		}
+		if p.BreachBps > 0 {
+			t.BreachBps = p.BreachBps
+		}
+		r.Level = max(t.grade(r.BookBps), t.grade(r.PoolBps))
+		out = append(out, r)
+	}
+	return out
+}
+
+// Worst returns readings ordered by severity, then absolute deviation
+func Worst(readings []Reading) []Reading {
+	out := append([]Reading(nil), readings...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Level != out[j].Level {
+			return out[i].Level > out[j].Level
+		}
+		return out[i].MaxAbsBps() > out[j].MaxAbsBps()
+	})
+	return out
+}
+
+// MaxAbsBps is the larger absolute deviation of the two venues, or 0
+func (r Reading) MaxAbsBps() float64 {
+	m := 0.0
+	for _, v := range []float64{r.BookBps, r.PoolBps} {
+		if !math.IsNaN(v) {
+			m = math.Max(m, math.Abs(v))
+		}
+	}
+	return m
+}
+
+func (t Thresholds) grade(bps float64) Level {
+	switch {
+	case math.IsNaN(bps):
+		return Unknown
+	case t.BreachBps > 0 && math.Abs(bps) >= t.BreachBps:
+		return Breach
+	case t.WarnBps > 0 && math.Abs(bps) >= t.WarnBps:
+		return Warn
+	}
+	return OK
+}
+
+func deviation(price, expected float64) float64 {
+	if math.IsNaN(price) || expected <= 0 {
+		return math.NaN()
+	}
+	return (price/expected - 1) * 10000
+}
+
+func known(v float64) float64 {
+	if v <= 0 || math.IsInf(v, 0) {
+		return math.NaN()
+	}
+	return v
+}
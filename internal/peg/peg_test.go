@@ -0,0 +1,118 @@
+package peg
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var rates = Rates{Base: "USD", Rates: map[string]float64{"EUR": 0.8, "ZAR": 20, "XAU": 0.0005}}
+
+func TestCross(t *testing.T) {
+	for _, c := range []struct {
+		from, to string
+		want     float64
+	}{
+		{"EUR", "USD", 1.25},
+		{"usd", "zar", 20},
+		{"XAU", "EUR", 1600},
+		{"USD", "USD", 1},
+	} {
+		if got, ok := rates.Cross(c.from, c.to); !ok || math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("Cross(%s, %s) = %v, %v; want %v", c.from, c.to, got, ok, c.want)
+		}
+	}
+	if _, ok := rates.Cross("BTC", "USD"); ok {
+		t.Errorf("unknown symbol should not cross")
+	}
+}
+
+func TestEvaluateGradesTheWorseVenue(t *testing.T) {
+	pegs := []Peg{
+		{Asset: "EURZ", Ref: "EUR", Against: "USDC", AgainstRef: "USD"},
+		{Asset: "ZARZ", Ref: "ZAR", Against: "USDC", AgainstRef: "USD"},
+		{Asset: "XAUZ", Ref: "XAU", Against: "USDC", AgainstRef: "USD", BreachBps: 300},
+		{Asset: "BTCZ", Ref: "BTC", Against: "USDC", AgainstRef: "USD"},
+	}
+	obs := map[string]Observation{
+		"EURZ": {Book: 1.25, Pool: 1.26},     // pool 80bp rich
+		"ZARZ": {Book: 0.0485, Pool: 0},      // book 300bp cheap, no pool
+		"XAUZ": {Book: 2000 * 1.02, Pool: 0}, // 200bp, under its own breach level
+		"BTCZ": {Book: 60000},                // no reference
+	}
+	got := Evaluate(pegs, rates, obs, DefaultThresholds)
+
+	want := []struct {
+		level   Level
+		bookBps float64
+		poolBps float64
+	}{
+		{Warn, 0, 80},
+		{Breach, -300, math.NaN()},
+		{Warn, 200, math.NaN()},
+		{Unknown, math.NaN(), math.NaN()},
+	}
+	for i, w := range want {
+		r := got[i]
+		if r.Level != w.level || !near(r.BookBps, w.bookBps) || !near(r.PoolBps, w.poolBps) {
+			t.Errorf("%s: level %s book %.1f pool %.1f; want %s %.1f %.1f",
+				r.Asset, r.Level, r.BookBps, r.PoolBps, w.level, w.bookBps, w.poolBps)
+		}
+	}
+	if worst := Worst(got); worst[0].Asset != "ZARZ" || worst[1].Asset != "XAUZ" {
+		t.Errorf("worst first: %s, %s", worst[0].Asset, worst[1].Asset)
+	}
+}
+
+func near(a, b float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	return math.Abs(a-b) < 1e-6
+}
+
+const ratesJSON = `{"base": "USD", "rates": {"EUR": 0.8}}`
+
+func TestFileAndHTTPSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	if err := os.WriteFile(path, []byte(ratesJSON), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rates" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, ratesJSON)
+	}))
+	defer srv.Close()
+
+	for _, src := range []Source{File{Path: path}, HTTP{URL: srv.URL + "/rates"}} {
+		r, err := src.Rates(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", src.Name(), err)
+		}
+		if v, ok := r.Cross("EUR", "USD"); !ok || v != 1.25 || r.At.IsZero() {
+			t.Errorf("%s: %+v", src.Name(), r)
+		}
+	}
+	if _, err := (HTTP{URL: srv.URL + "/missing"}).Rates(context.Background()); err == nil {
+		t.Errorf("a 404 should fail")
+	}
+}
+
+func TestNewSource(t *testing.T) {
+	if s, err := NewSource("", "", rates); err != nil || s.Name() != KindStub {
+		t.Errorf("default source: %v %v", s, err)
+	}
+	for _, kind := range []string{"file", "http", "carrier-pigeon"} {
+		if _, err := NewSource(kind, "", rates); err == nil {
+			t.Errorf("%s without a location should fail", kind)
+		}
+	}
+}
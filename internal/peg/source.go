@@ -0,0 +1,133 @@
+package peg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Source supplies reference rates
+type Source interface {
+	Name() string
+	Rates(ctx context.Context) (Rates, error)
+}
+
+// Source kinds accepted by NewSource
+const (
+	KindStub = "stub"
+	KindFile = "file"
+	KindHTTP = "http"
+)
+
+// ErrNoSource is returned by NewSource for an unknown kind or a missing
+// path or URL
+var ErrNoSource = errors.New("peg: invalid rate source")
+
+// NewSource builds a source of the given kind: stub serves rates as given,
+// file reads a JSON rates file, http fetches one
+func NewSource(kind, location string, rates Rates) (Source, error) {
+	switch strings.ToLower(kind) {
+	case "", KindStub:
+		return Stub{Fixed: rates}, nil
+	case KindFile:
+		if location == "" {
+			return nil, fmt.Errorf("%w: file source needs a path", ErrNoSource)
+		}
+		return File{Path: location}, nil
+	case KindHTTP:
+		if location == "" {
+			return nil, fmt.Errorf("%w: http source needs a url", ErrNoSource)
+		}
+		return HTTP{URL: location}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown kind %q (stub, file or http)", ErrNoSource, kind)
+}
+
+// Stub serves fixed rates, for offline use and tests
+type Stub struct {
+	Fixed Rates
+}
+
+func (Stub) Name() string { return KindStub }
+
+func (s Stub) Rates(context.Context) (Rates, error) {
+	r := s.Fixed
+	r.At = time.Now()
+	return r, nil
+}
+
+// File reads rates from a JSON file written by some other process, in the
+// same shape as the HTTP source. It is re-read on every call.
+type File struct {
+	Path string
+}
+
+func (File) Name() string { return KindFile }
+
+func (f File) Rates(context.Context) (Rates, error) {
+	fh, err := os.Open(f.Path)
+	if err != nil {
+		return Rates{}, err
+	}
+	defer fh.Close()
+	r, err := decode(fh)
+	if err != nil {
+		return Rates{}, fmt.Errorf("%s: %w", f.Path, err)
+	}
+	if info, err := fh.Stat(); err == nil {
+		r.At = info.ModTime()
+	}
+	return r, nil
+}
+
+// HTTP fetches rates from an endpoint returning
+// {"base": "USD", "rates": {"EUR": 0.92, ...}}
+type HTTP struct {
+	URL    string
+	Client *http.Client // http.DefaultClient when nil
+}
+
+func (HTTP) Name() string { return KindHTTP }
+
+func (h HTTP) Rates(ctx context.Context) (Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		return Rates{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Rates{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Rates{}, fmt.Errorf("rates %s: %s", h.URL, resp.Status)
+	}
+	r, err := decode(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Rates{}, fmt.Errorf("rates %s: %w", h.URL, err)
+	}
+	r.At = time.Now()
+	return r, nil
+}
+
+func decode(rd io.Reader) (Rates, error) {
+	var r Rates
+	if err := json.NewDecoder(rd).Decode(&r); err != nil {
+		return Rates{}, err
+	}
+	if r.Base == "" || len(r.Rates) == 0 {
+		return Rates{}, errors.New("rates need a base and at least one rate")
+	}
+	return r, nil
+}
@@ -0,0 +1,155 @@
+// Package pools finds the constant-product liquidity pool of an asset pair
+// and keeps the trades it makes.
+package pools
+
+import (
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// ErrNoPool is returned when the pair has no liquidity pool
+var ErrNoPool = errors.New("no liquidity pool")
+
+// negativeTTL is how long a pair without a pool is remembered before Horizon
+// is asked again, since pools can be created at any time
+const negativeTTL = 10 * time.Minute
+
+// Lister is the part of the Horizon client the resolver needs
+type Lister interface {
+	LiquidityPools(request horizonclient.LiquidityPoolsRequest) (hProtocol.LiquidityPoolsPage, error)
+}
+
+type entry struct {
+	id      string
+	expires time.Time // zero for pools that exist; they never change ID
+}
+
+// Resolver maps asset pairs to pool IDs. Overrides win, then cached lookups,
+// then a Horizon query for pools holding both reserves. Safe for concurrent
+// use.
+type Resolver struct {
+	mu        sync.Mutex
+	overrides map[string]string
+	cache     map[string]entry
+	now       func() time.Time
+}
+
+// NewResolver returns an empty resolver
+func NewResolver() *Resolver {
+	return &Resolver{
+		overrides: map[string]string{},
+		cache:     map[string]entry{},
+		now:       time.Now,
+	}
+}
+
+// SetOverrides replaces the configured pool IDs, keyed by Key
+func (r *Resolver) SetOverrides(overrides map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.overrides = make(map[string]string, len(overrides))
+	for k, v := range overrides {
+		r.overrides[k] = v
+	}
+}
+
+// Resolve returns the pool ID for the pair in either order. It returns
+// ErrNoPool when Horizon knows no pool for it. If Horizon cannot be reached
+// the computed canonical ID is returned uncached.
+func (r *Resolver) Resolve(client Lister, a, b txnbuild.Asset) (string, error) {
+	key := Key(a, b)
+	r.mu.Lock()
+	if id, ok := r.overrides[key]; ok {
+		r.mu.Unlock()
+		return id, nil
+	}
+	if e, ok := r.cache[key]; ok && (e.expires.IsZero() || r.now().Before(e.expires)) {
+		r.mu.Unlock()
+		if e.id == "" {
+			return "", ErrNoPool
+		}
+		return e.id, nil
+	}
+	r.mu.Unlock()
+
+	computed, cerr := CanonicalID(a, b)
+	id, err := query(client, a, b, computed)
+	if err != nil {
+		if cerr == nil {
+			return computed, nil
+		}
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == "" {
+		r.cache[key] = entry{expires: r.now().Add(negativeTTL)}
+		return "", ErrNoPool
+	}
+	r.cache[key] = entry{id: id}
+	return id, nil
+}
+
+// query asks Horizon for constant-product pools holding both assets and
+// prefers the one matching the computed ID
+func query(client Lister, a, b txnbuild.Asset, computed string) (string, error) {
+	if client == nil {
+		return "", errors.New("no horizon client")
+	}
+	page, err := client.LiquidityPools(horizonclient.LiquidityPoolsRequest{
+		Reserves: []string{Reserve(a), Reserve(b)},
+		Limit:    10,
+	})
+	if err != nil {
+		return "", err
+	}
+	found := ""
+	for _, p := range page.Embedded.Records {
+		if p.Type != "" && p.Type != "constant_product" {
+			continue
+		}
+		if computed != "" && p.ID == computed {
+			return p.ID, nil
+		}
+		if found == "" {
+			found = p.ID
+		}
+	}
+	return found, nil
+}
+
+// CanonicalID computes the constant-product pool ID for the pair, which is
+// the same whichever order the assets are given in
+func CanonicalID(a, b txnbuild.Asset) (string, error) {
+	if b.LessThan(a) {
+		a, b = b, a
+	}
+	id, err := txnbuild.NewLiquidityPoolId(a, b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id[:]), nil
+}
+
+// Key identifies a pair independently of asset order
+func Key(a, b txnbuild.Asset) string {
+	s := []string{Reserve(a), Reserve(b)}
+	sort.Strings(s)
+	return s[0] + "|" + s[1]
+}
+
+// Reserve formats an asset the way Horizon's reserves filter expects
+func Reserve(a txnbuild.Asset) string {
+	if a.IsNative() {
+		return "native"
+	}
+	return a.GetCode() + ":" + a.GetIssuer()
+}
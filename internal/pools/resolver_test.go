@@ -0,0 +1,98 @@
+package pools
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+var (
+	usdc = txnbuild.CreditAsset{Code: "USDC", Issuer: "GA5ZSEJYB37JRC5AVCIA5MOP4RHTM335X2KGX3IHOJAPP5RE34K4KZVN"}
+	usdz = txnbuild.CreditAsset{Code: "USDZ", Issuer: "GAKTLPC4ZV37SSCITQ5IS5AQ4WPF4CF4VZJQPPAROSGXMYOATF5U6XPR"}
+	xlm  = txnbuild.NativeAsset{}
+)
+
+type fakeLister struct {
+	ids   []string
+	err   error
+	calls int
+}
+
+func (f *fakeLister) LiquidityPools(horizonclient.LiquidityPoolsRequest) (hProtocol.LiquidityPoolsPage, error) {
+	f.calls++
+	var page hProtocol.LiquidityPoolsPage
+	for _, id := range f.ids {
+		page.Embedded.Records = append(page.Embedded.Records, hProtocol.LiquidityPool{ID: id, Type: "constant_product"})
+	}
+	return page, f.err
+}
+
+func TestCanonicalIDIsOrderIndependent(t *testing.T) {
+	ab, err1 := CanonicalID(usdc, usdz)
+	ba, err2 := CanonicalID(usdz, usdc)
+	if err1 != nil || err2 != nil || ab != ba || len(ab) != 64 {
+		t.Fatalf("CanonicalID: %q %q %v %v", ab, ba, err1, err2)
+	}
+	if Key(usdc, xlm) != Key(xlm, usdc) {
+		t.Errorf("Key should not depend on order")
+	}
+}
+
+func TestResolvePrefersOverridesThenCaches(t *testing.T) {
+	r := NewResolver()
+	r.SetOverrides(map[string]string{Key(usdc, usdz): "configured"})
+	f := &fakeLister{}
+	if id, err := r.Resolve(f, usdz, usdc); err != nil || id != "configured" || f.calls != 0 {
+		t.Fatalf("override: id=%q err=%v calls=%d", id, err, f.calls)
+	}
+
+	computed, _ := CanonicalID(xlm, usdc)
+	f.ids = []string{"other", computed}
+	for i := 0; i < 2; i++ {
+		if id, err := r.Resolve(f, xlm, usdc); err != nil || id != computed {
+			t.Fatalf("lookup: id=%q err=%v", id, err)
+		}
+	}
+	if f.calls != 1 {
+		t.Errorf("found pools should be cached, Horizon called %d times", f.calls)
+	}
+}
+
+func TestResolveRemembersMissingPoolsForAWhile(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := NewResolver()
+	r.now = func() time.Time { return now }
+	f := &fakeLister{}
+
+	if _, err := r.Resolve(f, xlm, usdz); !errors.Is(err, ErrNoPool) {
+		t.Fatalf("expected ErrNoPool, got %v", err)
+	}
+	r.Resolve(f, xlm, usdz)
+	if f.calls != 1 {
+		t.Errorf("missing pool should be cached, Horizon called %d times", f.calls)
+	}
+	now = now.Add(negativeTTL + time.Second)
+	f.ids = []string{"new"}
+	if id, err := r.Resolve(f, xlm, usdz); err != nil || id != "new" {
+		t.Errorf("after TTL: id=%q err=%v", id, err)
+	}
+}
+
+func TestResolveFallsBackToComputedIDWhenHorizonFails(t *testing.T) {
+	r := NewResolver()
+	f := &fakeLister{err: errors.New("down")}
+	computed, _ := CanonicalID(usdc, usdz)
+	if id, err := r.Resolve(f, usdc, usdz); err != nil || id != computed {
+		t.Fatalf("id=%q err=%v", id, err)
+	}
+	f.err = nil
+	f.ids = []string{computed}
+	r.Resolve(f, usdc, usdz)
+	if f.calls != 2 {
+		t.Errorf("a failed lookup should not be cached")
+	}
+}
@@ -0,0 +1,202 @@
+package pools
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+)
+
+// A refresh fetches at most tradeMaxPages pages of tradePageSize trades
+const (
+	tradePageSize = 200
+	tradeMaxPages = 25
+)
+
+// Trader is the part of the Horizon client the trade log needs
+type Trader interface {
+	Trades(request horizonclient.TradeRequest) (hProtocol.TradesPage, error)
+}
+
+// poolTrades is one pool's cached trades, oldest first
+type poolTrades struct {
+	mu     sync.Mutex
+	trades []hProtocol.Trade
+	cursor string    // paging token of the newest trade fetched
+	from   time.Time // trades before this were cut off by the page cap
+	behind bool      // the last refresh hit the page cap before the newest trade
+}
+
+// TradeLog keeps each pool's trades over a trailing window. The first
+// refresh pages back through the window; later ones only fetch the trades
+// made since. Safe for concurrent use.
+type TradeLog struct {
+	mu     sync.Mutex
+	window time.Duration
+	pools  map[string]*poolTrades
+	now    func() time.Time
+}
+
+// NewTradeLog returns an empty log keeping trades for window
+func NewTradeLog(window time.Duration) *TradeLog {
+	return &TradeLog{window: window, pools: map[string]*poolTrades{}, now: time.Now}
+}
+
+// Trades refreshes the pool's trades and returns those within the window,
+// oldest first. truncated is set when the page cap left some of the window
+// unfetched, so sums over it are too low.
+func (l *TradeLog) Trades(client Trader, poolID string) (trades []hProtocol.Trade, truncated bool, err error) {
+	l.mu.Lock()
+	p := l.pools[poolID]
+	if p == nil {
+		p = &poolTrades{}
+		l.pools[poolID] = p
+	}
+	l.mu.Unlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	since := l.now().Add(-l.window)
+	if p.cursor == "" {
+		err = p.fill(client, poolID, since)
+	} else {
+		err = p.catchUp(client, poolID)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	keep := 0
+	for keep < len(p.trades) && p.trades[keep].LedgerCloseTime.Before(since) {
+		keep++
+	}
+	p.trades = p.trades[keep:]
+	if !p.from.IsZero() && !p.from.After(since) {
+		p.from = time.Time{} // the cut-off part has left the window
+	}
+	return append([]hProtocol.Trade(nil), p.trades...), p.behind || !p.from.IsZero(), nil
+}
+
+// fill pages the pool's trades newest first until they are older than since
+func (p *poolTrades) fill(client Trader, poolID string, since time.Time) error {
+	var desc []hProtocol.Trade
+	cursor := ""
+	capped := true
+	for page := 0; page < tradeMaxPages; page++ {
+		tp, err := client.Trades(horizonclient.TradeRequest{
+			ForLiquidityPool: poolID,
+			Order:            horizonclient.OrderDesc,
+			Cursor:           cursor,
+			Limit:            tradePageSize,
+		})
+		if err != nil {
+			return err
+		}
+		records := tp.Embedded.Records
+		done := len(records) < tradePageSize
+		for _, t := range records {
+			if t.LedgerCloseTime.Before(since) {
+				done = true
+				break
+			}
+			desc = append(desc, t)
+		}
+		if done {
+			capped = false
+			break
+		}
+		cursor = records[len(records)-1].PagingToken()
+	}
+
+	p.trades = p.trades[:0]
+	for i := len(desc) - 1; i >= 0; i-- {
+		p.trades = append(p.trades, desc[i])
+	}
+	p.from, p.behind = time.Time{}, false
+	if len(desc) > 0 {
+		p.cursor = desc[0].PagingToken()
+		if capped {
+			p.from = desc[len(desc)-1].LedgerCloseTime
+		}
+	}
+	return nil
+}
+
+// catchUp pages the trades made since the cursor, oldest first
+func (p *poolTrades) catchUp(client Trader, poolID string) error {
+	var fresh []hProtocol.Trade
+	cursor := p.cursor
+	behind := true
+	for page := 0; page < tradeMaxPages; page++ {
+		tp, err := client.Trades(horizonclient.TradeRequest{
+			ForLiquidityPool: poolID,
+			Order:            horizonclient.OrderAsc,
+			Cursor:           cursor,
+			Limit:            tradePageSize,
+		})
+		if err != nil {
+			return err
+		}
+		records := tp.Embedded.Records
+		fresh = append(fresh, records...)
+		if len(records) > 0 {
+			cursor = records[len(records)-1].PagingToken()
+		}
+		if len(records) < tradePageSize {
+			behind = false
+			break
+		}
+	}
+	p.trades = append(p.trades, fresh...)
+	p.cursor, p.behind = cursor, behind
+	return nil
+}
+
+// TradeStats sums the volume of each reserve asset traded since the cutoff
+// and the fees the pool earned on it. The fee is charged on the asset
+// flowing into the pool: the counter amount when the pool is the base party
+// and the base amount when it is the counter party. assets are named as in
+// the pool's reserves.
+func TradeStats(trades []hProtocol.Trade, poolID string, assets [2]string, feeBP uint32, since time.Time) (vol, fees [2]*big.Rat) {
+	for i := range vol {
+		vol[i], fees[i] = new(big.Rat), new(big.Rat)
+	}
+	feeRate := big.NewRat(int64(feeBP), 10000)
+	for _, t := range trades {
+		if t.LedgerCloseTime.Before(since) {
+			continue
+		}
+		base := tradeAsset(t.BaseAssetType, t.BaseAssetCode, t.BaseAssetIssuer)
+		counter := tradeAsset(t.CounterAssetType, t.CounterAssetCode, t.CounterAssetIssuer)
+		baseAmt, ok1 := new(big.Rat).SetString(t.BaseAmount)
+		counterAmt, ok2 := new(big.Rat).SetString(t.CounterAmount)
+		if !ok1 || !ok2 {
+			continue
+		}
+		for i, a := range assets {
+			switch a {
+			case base:
+				vol[i].Add(vol[i], baseAmt)
+				if t.CounterLiquidityPoolID == poolID {
+					fees[i].Add(fees[i], new(big.Rat).Mul(baseAmt, feeRate))
+				}
+			case counter:
+				vol[i].Add(vol[i], counterAmt)
+				if t.BaseLiquidityPoolID == poolID {
+					fees[i].Add(fees[i], new(big.Rat).Mul(counterAmt, feeRate))
+				}
+			}
+		}
+	}
+	return vol, fees
+}
+
+// tradeAsset formats a trade's asset the way pool reserves name it
+func tradeAsset(assetType, code, issuer string) string {
+	if assetType == "native" {
+		return "native"
+	}
+	return code + ":" + issuer
+}
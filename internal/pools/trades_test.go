@@ -0,0 +1,127 @@
+package pools
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+)
+
+const testPool = "pool"
+
+var t0 = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeTrader serves trades, oldest first, by paging token
+type fakeTrader struct {
+	trades []hProtocol.Trade
+	calls  int
+}
+
+func (f *fakeTrader) add(at time.Time, n int) {
+	for i := 0; i < n; i++ {
+		pt := fmt.Sprintf("%08d", len(f.trades)+1)
+		f.trades = append(f.trades, hProtocol.Trade{
+			ID: pt, PT: pt, LedgerCloseTime: at,
+			BaseAssetType: "native", BaseAmount: "1",
+			CounterAssetType: "credit_alphanum4", CounterAssetCode: "USDZ", CounterAssetIssuer: "GI", CounterAmount: "2",
+			BaseLiquidityPoolID: testPool,
+		})
+	}
+}
+
+func (f *fakeTrader) Trades(r horizonclient.TradeRequest) (hProtocol.TradesPage, error) {
+	f.calls++
+	var page hProtocol.TradesPage
+	if r.Order == horizonclient.OrderDesc {
+		for i := len(f.trades) - 1; i >= 0 && len(page.Embedded.Records) < int(r.Limit); i-- {
+			if r.Cursor == "" || f.trades[i].PT < r.Cursor {
+				page.Embedded.Records = append(page.Embedded.Records, f.trades[i])
+			}
+		}
+		return page, nil
+	}
+	for _, t := range f.trades {
+		if t.PT > r.Cursor && len(page.Embedded.Records) < int(r.Limit) {
+			page.Embedded.Records = append(page.Embedded.Records, t)
+		}
+	}
+	return page, nil
+}
+
+func TestTradeStatsChargesFeeOnTheInflowingAsset(t *testing.T) {
+	assets := [2]string{"native", "USDZ:GI"}
+	trades := []hProtocol.Trade{
+		// pool is the base party: it receives the counter asset
+		{LedgerCloseTime: t0, BaseAssetType: "native", BaseAmount: "10", CounterAssetType: "credit_alphanum4",
+			CounterAssetCode: "USDZ", CounterAssetIssuer: "GI", CounterAmount: "20", BaseLiquidityPoolID: testPool},
+		// pool is the counter party: it receives the base asset
+		{LedgerCloseTime: t0, BaseAssetType: "credit_alphanum4", BaseAssetCode: "USDZ", BaseAssetIssuer: "GI",
+			BaseAmount: "100", CounterAssetType: "native", CounterAmount: "50", CounterLiquidityPoolID: testPool},
+		// before the cutoff
+		{LedgerCloseTime: t0.Add(-2 * time.Hour), BaseAssetType: "native", BaseAmount: "1000", CounterAssetType: "credit_alphanum4",
+			CounterAssetCode: "USDZ", CounterAssetIssuer: "GI", CounterAmount: "1000", BaseLiquidityPoolID: testPool},
+	}
+
+	vol, fees := TradeStats(trades, testPool, assets, 30, t0.Add(-time.Hour))
+	if vol[0].RatString() != "60" || vol[1].RatString() != "120" {
+		t.Errorf("volume = %s, %s; want 60, 120", vol[0].RatString(), vol[1].RatString())
+	}
+	if fees[0].FloatString(2) != "0.00" || fees[1].FloatString(2) != "0.36" {
+		t.Errorf("fees = %s, %s; want 0.00, 0.36", fees[0].FloatString(2), fees[1].FloatString(2))
+	}
+}
+
+func TestTradeLogFetchesOnlyNewTrades(t *testing.T) {
+	f := &fakeTrader{}
+	f.add(t0.Add(-10*24*time.Hour), 3) // outside the window
+	f.add(t0.Add(-time.Hour), 5)
+	l := NewTradeLog(7 * 24 * time.Hour)
+	l.now = func() time.Time { return t0 }
+
+	trades, truncated, err := l.Trades(f, testPool)
+	if err != nil || truncated || len(trades) != 5 {
+		t.Fatalf("first refresh: %d trades, truncated=%v, err=%v", len(trades), truncated, err)
+	}
+	if trades[0].PT > trades[4].PT {
+		t.Errorf("trades should be oldest first")
+	}
+
+	f.add(t0, 2)
+	f.calls = 0
+	trades, truncated, err = l.Trades(f, testPool)
+	if err != nil || truncated || len(trades) != 7 {
+		t.Fatalf("second refresh: %d trades, truncated=%v, err=%v", len(trades), truncated, err)
+	}
+	if f.calls != 1 {
+		t.Errorf("second refresh made %d requests, want 1", f.calls)
+	}
+
+	// trades leave the window as time passes
+	l.now = func() time.Time { return t0.Add(7 * 24 * time.Hour) }
+	if trades, _, _ = l.Trades(f, testPool); len(trades) != 2 {
+		t.Errorf("after a week %d trades remain, want 2", len(trades))
+	}
+}
+
+func TestTradeLogReportsThePageCap(t *testing.T) {
+	f := &fakeTrader{}
+	f.add(t0.Add(-2*24*time.Hour), tradePageSize*tradeMaxPages+10)
+	l := NewTradeLog(7 * 24 * time.Hour)
+	l.now = func() time.Time { return t0 }
+
+	trades, truncated, err := l.Trades(f, testPool)
+	if err != nil || !truncated || len(trades) != tradePageSize*tradeMaxPages {
+		t.Fatalf("capped fill: %d trades, truncated=%v, err=%v", len(trades), truncated, err)
+	}
+
+	// still truncated while the cut-off trades are inside the window
+	if _, truncated, _ = l.Trades(f, testPool); !truncated {
+		t.Errorf("a later refresh should still report the cut-off history")
+	}
+	l.now = func() time.Time { return t0.Add(6 * 24 * time.Hour) }
+	if _, truncated, _ = l.Trades(f, testPool); truncated {
+		t.Errorf("truncation should clear once the cut-off trades leave the window")
+	}
+}
@@ -0,0 +1,218 @@
+// Package session records timestamped market events to a JSON Lines file
+// and plays them back on a virtual clock that can be paused, sped up and
+// seeked.
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is one line of a session file. Data is decoded by the consumer
+// according to Kind.
+type Event struct {
+	At   time.Time       `json:"at"`
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Recorder appends events to a session file. Every event is flushed as it is
+// written, so a crash loses at most the event being written. Safe for
+// concurrent use.
+type Recorder struct {
+	mu  sync.Mutex
+	w   *bufio.Writer
+	c   io.Closer
+	err error
+}
+
+// Create truncates path and returns a recorder writing to it
+func Create(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := NewRecorder(f)
+	r.c = f
+	return r, nil
+}
+
+// NewRecorder returns a recorder writing to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: bufio.NewWriter(w)}
+}
+
+// Record writes an event of the given kind with v as its data. After the
+// first write error every call returns that error.
+func (r *Recorder) Record(kind string, at time.Time, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("session: %s event: %w", kind, err)
+	}
+	line, err := json.Marshal(Event{At: at.UTC(), Kind: kind, Data: data})
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	if _, err := r.w.Write(append(line, '\n')); err != nil {
+		r.err = err
+		return err
+	}
+	if err := r.w.Flush(); err != nil {
+		r.err = err
+	}
+	return r.err
+}
+
+// Close flushes and closes the underlying file, if the recorder opened one
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.w.Flush()
+	if r.c != nil {
+		if cerr := r.c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Load reads a session file
+func Load(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read parses session events, one JSON object per line, and orders them by
+// time. Blank lines are skipped; a truncated last line, as left by a crash,
+// is dropped.
+func Read(r io.Reader) ([]Event, error) {
+	var events []Event
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	lineNo := 0
+	var pending error
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if pending != nil {
+			return nil, pending
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			pending = fmt.Errorf("session line %d: %w", lineNo, err)
+			continue
+		}
+		events = append(events, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At.Before(events[j].At) })
+	return events, nil
+}
+
+// ParseSpeed parses a playback speed such as "4x", "0.5x" or "2"
+func ParseSpeed(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), "x"), 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid speed %q (e.g. 1x, 4x, 0.5x)", s)
+	}
+	return v, nil
+}
+
+// Player releases each event once a virtual clock, which starts at the first
+// event, reaches its offset
+type Player struct {
+	events []Event
+	next   int
+	pos    time.Duration
+
+	Speed  float64
+	Paused bool
+}
+
+// NewPlayer returns a player at the start of events, which must be sorted
+func NewPlayer(events []Event, speed float64) *Player {
+	return &Player{events: events, Speed: speed}
+}
+
+// Start is the time of the first event
+func (p *Player) Start() time.Time {
+	if len(p.events) == 0 {
+		return time.Time{}
+	}
+	return p.events[0].At
+}
+
+// Duration is the offset of the last event
+func (p *Player) Duration() time.Duration {
+	if len(p.events) == 0 {
+		return 0
+	}
+	return p.events[len(p.events)-1].At.Sub(p.Start())
+}
+
+// Position is the current offset of the virtual clock
+func (p *Player) Position() time.Duration {
+	return p.pos
+}
+
+// Done reports whether every event has been released
+func (p *Player) Done() bool {
+	return p.next >= len(p.events)
+}
+
+// Advance moves the clock by wall time elapsed times the speed, unless
+// paused, and returns the events that became due
+func (p *Player) Advance(elapsed time.Duration) []Event {
+	if p.Paused || p.Done() {
+		return nil
+	}
+	return p.forwardTo(p.pos + time.Duration(float64(elapsed)*p.Speed))
+}
+
+// Seek moves the clock to offset, clamped to the session. Seeking forward
+// returns the events skipped over. Seeking backward rewinds: it returns every
+// event from the start up to offset and rewound is true, so the consumer can
+// rebuild its state from scratch.
+func (p *Player) Seek(offset time.Duration) (events []Event, rewound bool) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset < p.pos {
+		p.next, p.pos, rewound = 0, 0, true
+	}
+	return p.forwardTo(offset), rewound
+}
+
+func (p *Player) forwardTo(offset time.Duration) []Event {
+	if d := p.Duration(); offset > d {
+		offset = d
+	}
+	p.pos = offset
+	start := p.next
+	for p.next < len(p.events) && p.events[p.next].At.Sub(p.Start()) <= offset {
+		p.next++
+	}
+	return p.events[start:p.next]
+}
@@ -0,0 +1,99 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndReadRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRecorder(&buf)
+	t0 := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	r.Record("network", t0.Add(time.Second), 0.42)
+	r.Record("pair", t0, map[string]string{"base": "native"})
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a crash can leave a partial last line
+	buf.WriteString(`{"at":"2025-03-01T12:00:02Z","kind":"tra`)
+	events, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Kind != "pair" || events[1].Kind != "network" {
+		t.Fatalf("events: %+v", events)
+	}
+	var capacity float64
+	if err := json.Unmarshal(events[1].Data, &capacity); err != nil || capacity != 0.42 {
+		t.Errorf("data: %s %v", events[1].Data, err)
+	}
+}
+
+func TestReadRejectsCorruptLinesBeforeTheEnd(t *testing.T) {
+	in := "{\"kind\":\"a\"}\nnot json\n{\"kind\":\"b\"}\n"
+	if _, err := Read(strings.NewReader(in)); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected a line 2 error, got %v", err)
+	}
+}
+
+func player() *Player {
+	t0 := time.Unix(1000, 0)
+	var events []Event
+	for _, s := range []int{0, 10, 20, 30} {
+		events = append(events, Event{At: t0.Add(time.Duration(s) * time.Second), Kind: "e"})
+	}
+	return NewPlayer(events, 4)
+}
+
+func TestPlayerAdvancesAtSpeedAndPauses(t *testing.T) {
+	p := player()
+	if got := p.Advance(0); len(got) != 1 {
+		t.Fatalf("first event should be due at once, got %d", len(got))
+	}
+	if got := p.Advance(2 * time.Second); len(got) != 0 || p.Position() != 8*time.Second {
+		t.Fatalf("at 8s: %d events, position %s", len(got), p.Position())
+	}
+	p.Paused = true
+	if got := p.Advance(time.Minute); len(got) != 0 || p.Position() != 8*time.Second {
+		t.Fatalf("paused player moved to %s", p.Position())
+	}
+	p.Paused = false
+	if got := p.Advance(4 * time.Second); len(got) != 2 || p.Done() {
+		t.Fatalf("at 24s: %d events, done %v", len(got), p.Done())
+	}
+	if got := p.Advance(time.Minute); len(got) != 1 || !p.Done() || p.Position() != p.Duration() {
+		t.Errorf("past the end: %d events, at %s", len(got), p.Position())
+	}
+}
+
+func TestPlayerSeek(t *testing.T) {
+	p := player()
+	got, rewound := p.Seek(25 * time.Second)
+	if len(got) != 3 || rewound {
+		t.Fatalf("forward seek: %d events, rewound %v", len(got), rewound)
+	}
+	got, rewound = p.Seek(10 * time.Second)
+	if len(got) != 2 || !rewound || p.Position() != 10*time.Second {
+		t.Fatalf("backward seek: %d events, rewound %v, at %s", len(got), rewound, p.Position())
+	}
+	if got, _ = p.Seek(time.Hour); len(got) != 2 || !p.Done() || p.Position() != p.Duration() {
+		t.Errorf("seek past the end: %d events, at %s", len(got), p.Position())
+	}
+}
+
+func TestParseSpeed(t *testing.T) {
+	for in, want := range map[string]float64{"4x": 4, "0.5X": 0.5, "2": 2} {
+		if got, err := ParseSpeed(in); err != nil || got != want {
+			t.Errorf("ParseSpeed(%q) = %v, %v", in, got, err)
+		}
+	}
+	for _, in := range []string{"", "x", "-1x", "0"} {
+		if _, err := ParseSpeed(in); err == nil {
+			t.Errorf("ParseSpeed(%q) should fail", in)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Package sla measures order books against market-making obligations (a
+// maximum spread and a minimum depth each side of mid) and reports how much
+// of a period they were met.
+package sla
+
+import (
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+)
+
+// DefaultDepthPct is the band around mid depth is measured in when an
+// obligation does not set one
+const DefaultDepthPct = 1.0
+
+// Obligation is what a pair's book must show
+type Obligation struct {
+	Pair         string  // key samples are stored under
+	Label        string  // the pair as written in the config
+	MaxSpreadBps float64 // 0 for no spread obligation
+	MinDepth     float64 // each side, in DepthAsset; 0 for no depth obligation
+	DepthAsset   string  // code depth is counted in
+	DepthInQuote bool    // DepthAsset is the quote asset rather than the base
+	DepthPct     float64 // band around mid, in percent
+}
+
+// Sample is one measurement of a book
+type Sample struct {
+	Pair      string    `json:"pair"`
+	At        time.Time `json:"at"`
+	Quoted    bool      `json:"quoted"` // both sides had offers
+	SpreadBps float64   `json:"spread_bps,omitempty"`
+	DepthPct  float64   `json:"depth_pct"`
+	BidDepth  float64   `json:"bid_depth"` // within DepthPct of mid, in the obligation's depth asset
+	AskDepth  float64   `json:"ask_depth"`
+}
+
+// Measure samples a book for o
+func (o Obligation) Measure(book orderbook.Book, at time.Time) Sample {
+	s := Sample{Pair: o.Pair, At: at, DepthPct: o.depthPct()}
+	mid := book.Mid()
+	if mid == nil {
+		return s
+	}
+	s.Quoted = true
+	s.SpreadBps = orderbook.Float(book.SpreadPercent()) * 100
+	pct := new(big.Rat).SetFloat64(s.DepthPct)
+	if pct == nil {
+		return s
+	}
+	frac := new(big.Rat).Quo(pct, big.NewRat(100, 1))
+	lo := new(big.Rat).Mul(mid, new(big.Rat).Sub(big.NewRat(1, 1), frac))
+	hi := new(big.Rat).Mul(mid, new(big.Rat).Add(big.NewRat(1, 1), frac))
+	s.BidDepth = o.depth(book.Bids, func(p *big.Rat) bool { return p.Cmp(lo) >= 0 })
+	s.AskDepth = o.depth(book.Asks, func(p *big.Rat) bool { return p.Cmp(hi) <= 0 })
+	return s
+}
+
+func (o Obligation) depthPct() float64 {
+	if o.DepthPct > 0 {
+		return o.DepthPct
+	}
+	return DefaultDepthPct
+}
+
+// depth sums the levels from the best price while within holds
+func (o Obligation) depth(levels []orderbook.Level, within func(*big.Rat) bool) float64 {
+	sum := new(big.Rat)
+	for _, l := range levels {
+		if !within(l.Price) {
+			break
+		}
+		if o.DepthInQuote {
+			sum.Add(sum, l.Total())
+		} else {
+			sum.Add(sum, l.Amount)
+		}
+	}
+	return orderbook.Float(sum)
+}
+
+// Breach reasons
+const (
+	ReasonOneSided = "one-sided book"
+	ReasonSpread   = "spread"
+	ReasonBidDepth = "bid depth"
+	ReasonAskDepth = "ask depth"
+)
+
+// Check returns why s breaches o; nil when it complies
+func (o Obligation) Check(s Sample) []string {
+	if !s.Quoted {
+		return []string{ReasonOneSided}
+	}
+	var out []string
+	if o.MaxSpreadBps > 0 && s.SpreadBps > o.MaxSpreadBps {
+		out = append(out, ReasonSpread)
+	}
+	if o.MinDepth > 0 && s.BidDepth < o.MinDepth {
+		out = append(out, ReasonBidDepth)
+	}
+	if o.MinDepth > 0 && s.AskDepth < o.MinDepth {
+		out = append(out, ReasonAskDepth)
+	}
+	return out
+}
+
+// Interval is a stretch of consecutive breaching samples
+type Interval struct {
+	From, To       time.Time
+	Reasons        []string
+	WorstSpreadBps float64 // NaN when the book was one-sided throughout
+}
+
+// Report is an obligation's compliance over a period
+type Report struct {
+	Obligation Obligation
+	From, To   time.Time
+	Samples    int
+	// Measured is the time covered by samples; Compliant the part of it
+	// that met the obligation
+	Measured, Compliant time.Duration
+	Breaches            []Interval
+	WorstSpreadBps      float64 // widest two-sided spread seen; NaN if none
+	WorstAt             time.Time
+}
+
+// Uptime is the compliant share of the measured time, NaN when nothing was
+// measured
+func (r Report) Uptime() float64 {
+	if r.Measured <= 0 {
+		return math.NaN()
+	}
+	return float64(r.Compliant) / float64(r.Measured)
+}
+
+// Evaluate reports o over [from, to). Each sample stands until the next one
+// but for no longer than maxGap; time not covered by any sample counts
+// neither for nor against the obligation.
+func Evaluate(o Obligation, samples []Sample, from, to time.Time, maxGap time.Duration) Report {
+	r := Report{Obligation: o, From: from, To: to, WorstSpreadBps: math.NaN()}
+	sorted := make([]Sample, 0, len(samples))
+	for _, s := range samples {
+		if s.Pair == o.Pair && !s.At.Before(from) && s.At.Before(to) {
+			sorted = append(sorted, s)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].At.Before(sorted[j].At) })
+	r.Samples = len(sorted)
+
+	var open *Interval
+	closeOpen := func() {
+		if open != nil {
+			r.Breaches = append(r.Breaches, *open)
+			open = nil
+		}
+	}
+	for i, s := range sorted {
+		end := s.At.Add(maxGap)
+		if i+1 < len(sorted) && sorted[i+1].At.Before(end) {
+			end = sorted[i+1].At
+		}
+		if end.After(to) {
+			end = to
+		}
+		span := end.Sub(s.At)
+		r.Measured += span
+
+		if s.Quoted && (math.IsNaN(r.WorstSpreadBps) || s.SpreadBps > r.WorstSpreadBps) {
+			r.WorstSpreadBps, r.WorstAt = s.SpreadBps, s.At
+		}
+		reasons := o.Check(s)
+		if len(reasons) == 0 {
+			r.Compliant += span
+			closeOpen()
+			continue
+		}
+		// a gap ends a breach: nobody knows what the book did meanwhile
+		if open != nil && open.To.Before(s.At) {
+			closeOpen()
+		}
+		if open == nil {
+			open = &Interval{From: s.At, WorstSpreadBps: math.NaN()}
+		}
+		open.To = end
+		open.Reasons = union(open.Reasons, reasons)
+		if s.Quoted && (math.IsNaN(open.WorstSpreadBps) || s.SpreadBps > open.WorstSpreadBps) {
+			open.WorstSpreadBps = s.SpreadBps
+		}
+	}
+	closeOpen()
+	return r
+}
+
+func union(a, b []string) []string {
+	for _, x := range b {
+		found := false
+		for _, y := range a {
+			if x == y {
+				found = true
+				break
+			}
+		}
+		if !found {
+			a = append(a, x)
+		}
+	}
+	return a
+}
+
+// ReasonText joins reasons for display
+func ReasonText(reasons []string) string {
+	return strings.Join(reasons, ", ")
+}
@@ -0,0 +1,115 @@
+package sla
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+)
+
+func level(price, amount string) orderbook.Level {
+	p, _ := new(big.Rat).SetString(price)
+	a, _ := new(big.Rat).SetString(amount)
+	return orderbook.Level{Price: p, Amount: a}
+}
+
+func TestMeasure(t *testing.T) {
+	book := orderbook.Book{
+		Bids: []orderbook.Level{level("1.99", "100"), level("1.98", "50"), level("1.9", "1000")},
+		Asks: []orderbook.Level{level("2.01", "10"), level("2.1", "500")},
+	}
+	at := time.Unix(1700000000, 0)
+	o := Obligation{Pair: "p"}
+	s := o.Measure(book, at)
+	// mid 2, spread 0.02/2 = 100bp; within 1% are bids from 1.98 and asks to 2.02
+	if !s.Quoted || math.Abs(s.SpreadBps-100) > 1e-9 || s.BidDepth != 150 || s.AskDepth != 10 || s.DepthPct != 1 {
+		t.Errorf("base depth sample = %+v", s)
+	}
+	o.DepthInQuote, o.DepthPct = true, 5
+	s = o.Measure(book, at)
+	if math.Abs(s.BidDepth-(199+99+1900)) > 1e-9 || math.Abs(s.AskDepth-(20.1+1050)) > 1e-9 {
+		t.Errorf("quote depth sample = %+v", s)
+	}
+	if s := o.Measure(orderbook.Book{Bids: book.Bids}, at); s.Quoted {
+		t.Errorf("one-sided book sampled as quoted: %+v", s)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	o := Obligation{Pair: "p", MaxSpreadBps: 30, MinDepth: 100}
+	t0 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	ok := func(min int) Sample {
+		return Sample{Pair: "p", At: t0.Add(time.Duration(min) * time.Minute), Quoted: true, SpreadBps: 20, BidDepth: 200, AskDepth: 200}
+	}
+	samples := []Sample{
+		ok(0), ok(1),
+		{Pair: "p", At: t0.Add(2 * time.Minute), Quoted: true, SpreadBps: 45, BidDepth: 200, AskDepth: 200},
+		{Pair: "p", At: t0.Add(3 * time.Minute), Quoted: true, SpreadBps: 50, BidDepth: 200, AskDepth: 80},
+		ok(4),
+		// ten minutes without samples, then a one-sided book
+		{Pair: "p", At: t0.Add(15 * time.Minute)},
+		ok(16),
+		{Pair: "other", At: t0.Add(16 * time.Minute)},
+	}
+	r := Evaluate(o, samples, t0, t0.Add(17*time.Minute), 2*time.Minute)
+
+	if r.Samples != 7 {
+		t.Errorf("Samples = %d, want 7", r.Samples)
+	}
+	// 0-6 (the last sample stands for maxGap) and 15-17: 8 minutes measured,
+	// 2-4 and 15-16 breaching
+	if r.Measured != 8*time.Minute || r.Compliant != 5*time.Minute {
+		t.Errorf("measured %s compliant %s, want 8m and 5m", r.Measured, r.Compliant)
+	}
+	if math.Abs(r.Uptime()-0.625) > 1e-12 {
+		t.Errorf("Uptime = %v", r.Uptime())
+	}
+	if r.WorstSpreadBps != 50 || !r.WorstAt.Equal(t0.Add(3*time.Minute)) {
+		t.Errorf("worst %v at %s", r.WorstSpreadBps, r.WorstAt)
+	}
+	if len(r.Breaches) != 2 {
+		t.Fatalf("breaches = %+v", r.Breaches)
+	}
+	b := r.Breaches[0]
+	if !b.From.Equal(t0.Add(2*time.Minute)) || !b.To.Equal(t0.Add(4*time.Minute)) || b.WorstSpreadBps != 50 ||
+		!reflect.DeepEqual(b.Reasons, []string{ReasonSpread, ReasonAskDepth}) {
+		t.Errorf("first breach = %+v", b)
+	}
+	b = r.Breaches[1]
+	if !b.From.Equal(t0.Add(15*time.Minute)) || !math.IsNaN(b.WorstSpreadBps) || ReasonText(b.Reasons) != ReasonOneSided {
+		t.Errorf("second breach = %+v", b)
+	}
+
+	if r := Evaluate(o, nil, t0, t0.Add(time.Hour), time.Minute); !math.IsNaN(r.Uptime()) || len(r.Breaches) != 0 {
+		t.Errorf("empty report = %+v", r)
+	}
+}
+
+func TestStoreSpansMonths(t *testing.T) {
+	st, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := time.Date(2024, 5, 31, 23, 59, 0, 0, time.UTC)
+	var in []Sample
+	for i := 0; i < 4; i++ {
+		in = append(in, Sample{Pair: "p", At: end.Add(time.Duration(i) * time.Minute), Quoted: true, SpreadBps: float64(i)})
+	}
+	in = append(in, Sample{Pair: "q", At: end})
+	if err := st.Append(in...); err != nil {
+		t.Fatal(err)
+	}
+	got, err := st.Load("p", end.Add(time.Minute), end.Add(3*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].SpreadBps != 1 || got[1].SpreadBps != 2 || !got[1].At.Equal(end.Add(2*time.Minute)) {
+		t.Errorf("Load = %+v", got)
+	}
+	if all, _ := st.Load("", end.Add(-time.Hour), end.Add(time.Hour)); len(all) != 5 {
+		t.Errorf("Load all = %d samples, want 5", len(all))
+	}
+}
@@ -0,0 +1,51 @@
+package sla
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sdexmon/sdexmon/internal/jsonl"
+)
+
+// Store keeps samples as JSON Lines, one file per month, so a report can
+// read them while the TUI appends and old months can simply be deleted
+type Store struct {
+	lines *jsonl.Store[Sample]
+}
+
+// Open returns a store in dir, creating it if needed
+func Open(dir string) (*Store, error) {
+	lines, err := jsonl.Open[Sample](dir)
+	if err != nil {
+		return nil, fmt.Errorf("sla store %s: %w", dir, err)
+	}
+	return &Store{lines: lines}, nil
+}
+
+func file(month time.Time) string {
+	return "samples-" + month.UTC().Format("2006-01") + ".jsonl"
+}
+
+// Append stores samples
+func (s *Store) Append(samples ...Sample) error {
+	return s.lines.Append(func(smp Sample) string { return file(smp.At) }, samples...)
+}
+
+// Load returns the samples taken in [from, to), for every pair when pair is
+// empty, oldest first within each month
+func (s *Store) Load(pair string, from, to time.Time) ([]Sample, error) {
+	keep := func(smp Sample) bool {
+		return (pair == "" || smp.Pair == pair) && !smp.At.Before(from) && smp.At.Before(to)
+	}
+	var out []Sample
+	month := time.Date(from.UTC().Year(), from.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+	for month.Before(to) {
+		got, err := s.lines.Read(file(month), keep)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, got...)
+		month = month.AddDate(0, 1, 0)
+	}
+	return out, nil
+}
@@ -0,0 +1,173 @@
+// Package slippage estimates the execution of a market order of a given size
+// against the merged order book and against a constant-product liquidity pool.
+package slippage
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+)
+
+// PoolFeeBps is the fee Stellar liquidity pools charge on the input amount
+const PoolFeeBps = 30
+
+// Side is the direction of the order in base asset terms
+type Side int
+
+const (
+	Buy  Side = iota // buy base, pay quote (takes asks)
+	Sell             // sell base, receive quote (hits bids)
+)
+
+func (s Side) String() string {
+	if s == Sell {
+		return "sell"
+	}
+	return "buy"
+}
+
+// Fill is the estimated execution of an order. Amounts are in base units and
+// prices in quote per base.
+type Fill struct {
+	Size   *big.Rat // requested base amount
+	Filled *big.Rat // base amount the venue can fill
+	Total  *big.Rat // quote paid (buy) or received (sell) for Filled
+	Avg    *big.Rat // average fill price, nil when nothing fills
+	Worst  *big.Rat // last book level touched, or pool price after the trade
+}
+
+// Complete reports whether the whole size was filled
+func (f Fill) Complete() bool {
+	return f.Filled != nil && f.Size != nil && f.Filled.Cmp(f.Size) >= 0
+}
+
+// SlippagePercent returns how much worse than mid the average price is, in
+// percent (positive is worse), or nil when nothing fills or mid is unknown
+func (f Fill) SlippagePercent(side Side, mid *big.Rat) *big.Rat {
+	if f.Avg == nil || mid == nil || mid.Sign() <= 0 {
+		return nil
+	}
+	d := new(big.Rat).Sub(f.Avg, mid)
+	if side == Sell {
+		d.Neg(d)
+	}
+	d.Quo(d, mid)
+	return d.Mul(d, big.NewRat(100, 1))
+}
+
+// WalkBook fills size against the book side the order takes: asks for a
+// buy, bids for a sell
+func WalkBook(b orderbook.Book, side Side, size *big.Rat) Fill {
+	levels := b.Asks
+	if side == Sell {
+		levels = b.Bids
+	}
+	f := Fill{Size: size, Filled: new(big.Rat), Total: new(big.Rat)}
+	remaining := new(big.Rat).Set(size)
+	for _, l := range levels {
+		if remaining.Sign() <= 0 {
+			break
+		}
+		take := l.Amount
+		if take.Cmp(remaining) > 0 {
+			take = remaining
+		}
+		f.Filled.Add(f.Filled, take)
+		f.Total.Add(f.Total, new(big.Rat).Mul(take, l.Price))
+		remaining = new(big.Rat).Sub(remaining, take)
+		f.Worst = l.Price
+	}
+	if f.Filled.Sign() > 0 {
+		f.Avg = new(big.Rat).Quo(f.Total, f.Filled)
+	}
+	return f
+}
+
+// Pool is a constant-product pool's reserves in base and quote units
+type Pool struct {
+	Base   *big.Rat
+	Quote  *big.Rat
+	FeeBps int64
+}
+
+// Price returns the pool's spot price in quote per base, or nil when empty
+func (p Pool) Price() *big.Rat {
+	if p.Base == nil || p.Quote == nil || p.Base.Sign() <= 0 {
+		return nil
+	}
+	return new(big.Rat).Quo(p.Quote, p.Base)
+}
+
+// Swap fills size against the pool. The fee is taken from the input amount,
+// so a buy needs quote in = y*dx / ((x-dx)*(1-fee)) and a sell returns
+// quote out = y*dx' / (x+dx') with dx' = dx*(1-fee). A buy can never drain
+// the base reserve, so it reports an empty fill when size reaches it.
+func (p Pool) Swap(side Side, size *big.Rat) Fill {
+	f := Fill{Size: size, Filled: new(big.Rat), Total: new(big.Rat)}
+	if p.Price() == nil || p.Quote.Sign() <= 0 || size.Sign() <= 0 {
+		return f
+	}
+	x, y := p.Base, p.Quote
+	keep := big.NewRat(10000-p.FeeBps, 10000) // 1 - fee
+	k := new(big.Rat).Mul(x, y)
+
+	switch side {
+	case Buy:
+		if size.Cmp(x) >= 0 {
+			return f
+		}
+		rest := new(big.Rat).Sub(x, size)
+		in := new(big.Rat).Mul(y, size)
+		in.Quo(in, new(big.Rat).Mul(rest, keep))
+		f.Total = in
+		// marginal price after the trade, fee included
+		f.Worst = new(big.Rat).Quo(k, new(big.Rat).Mul(rest, rest))
+		f.Worst.Quo(f.Worst, keep)
+	case Sell:
+		eff := new(big.Rat).Mul(size, keep)
+		after := new(big.Rat).Add(x, eff)
+		out := new(big.Rat).Mul(y, eff)
+		f.Total = out.Quo(out, after)
+		f.Worst = new(big.Rat).Quo(k, new(big.Rat).Mul(after, after))
+		f.Worst.Mul(f.Worst, keep)
+	}
+	f.Filled.Set(size)
+	f.Avg = new(big.Rat).Quo(f.Total, f.Filled)
+	return f
+}
+
+// Better reports whether a executes strictly better than b: a complete fill
+// beats a partial one, then the better average price wins
+func Better(side Side, a, b Fill) bool {
+	switch {
+	case a.Avg == nil:
+		return false
+	case b.Avg == nil:
+		return true
+	case a.Complete() != b.Complete():
+		return a.Complete()
+	}
+	if side == Buy {
+		return a.Avg.Cmp(b.Avg) < 0
+	}
+	return a.Avg.Cmp(b.Avg) > 0
+}
+
+// ParseSize parses an order size such as "10000", "10,000", "10k" or "0.5"
+func ParseSize(s string) (*big.Rat, error) {
+	s = strings.NewReplacer(",", "", "_", "", " ", "").Replace(strings.TrimSpace(s))
+	mult := big.NewRat(1, 1)
+	switch {
+	case strings.HasSuffix(strings.ToLower(s), "k"):
+		mult, s = big.NewRat(1000, 1), s[:len(s)-1]
+	case strings.HasSuffix(strings.ToLower(s), "m"):
+		mult, s = big.NewRat(1000000, 1), s[:len(s)-1]
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid size %q", s)
+	}
+	return r.Mul(r, mult), nil
+}
@@ -0,0 +1,80 @@
+package slippage
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/sdexmon/sdexmon/internal/orderbook"
+)
+
+func rat(s string) *big.Rat {
+	r, _ := new(big.Rat).SetString(s)
+	return r
+}
+
+func book() orderbook.Book {
+	return orderbook.Book{
+		Bids: []orderbook.Level{{Price: rat("0.99"), Amount: rat("100")}, {Price: rat("0.98"), Amount: rat("200")}},
+		Asks: []orderbook.Level{{Price: rat("1.01"), Amount: rat("100")}, {Price: rat("1.03"), Amount: rat("100")}},
+	}
+}
+
+func TestWalkBook(t *testing.T) {
+	f := WalkBook(book(), Buy, rat("150"))
+	if !f.Complete() || f.Total.Cmp(rat("152.5")) != 0 || f.Worst.Cmp(rat("1.03")) != 0 {
+		t.Fatalf("buy 150: total=%s worst=%s", f.Total.FloatString(4), f.Worst.FloatString(4))
+	}
+	if got := f.SlippagePercent(Buy, rat("1")).FloatString(4); got != "1.6667" {
+		t.Errorf("slippage = %s", got)
+	}
+
+	f = WalkBook(book(), Sell, rat("500"))
+	if f.Complete() || f.Filled.Cmp(rat("300")) != 0 || f.Total.Cmp(rat("295")) != 0 {
+		t.Errorf("sell 500 should fill 300 for 295, got %s for %s", f.Filled.FloatString(2), f.Total.FloatString(2))
+	}
+}
+
+func TestPoolSwap(t *testing.T) {
+	p := Pool{Base: rat("1000"), Quote: rat("1000")}
+	f := p.Swap(Buy, rat("100"))
+	if got := f.Total.FloatString(4); got != "111.1111" {
+		t.Errorf("fee-free buy cost = %s", got)
+	}
+	if got := f.Worst.FloatString(4); got != "1.2346" {
+		t.Errorf("price after buy = %s", got)
+	}
+
+	p.FeeBps = PoolFeeBps
+	f = p.Swap(Sell, rat("100"))
+	// 1000 * 99.7 / 1099.7
+	if got := f.Total.FloatString(4); got != "90.6611" {
+		t.Errorf("sell proceeds = %s", got)
+	}
+	if f := p.Swap(Buy, rat("1000")); f.Avg != nil {
+		t.Errorf("buying the whole reserve should not fill")
+	}
+}
+
+func TestBetter(t *testing.T) {
+	b := WalkBook(book(), Buy, rat("150"))
+	pool := Pool{Base: rat("100000"), Quote: rat("100000"), FeeBps: PoolFeeBps}.Swap(Buy, rat("150"))
+	if !Better(Buy, pool, b) || Better(Buy, b, pool) {
+		t.Errorf("deep pool should beat the book: pool avg %s, book avg %s", pool.Avg.FloatString(4), b.Avg.FloatString(4))
+	}
+	partial := WalkBook(book(), Buy, rat("1000"))
+	if Better(Buy, partial, Pool{Base: rat("10000"), Quote: rat("10000")}.Swap(Buy, rat("1000"))) {
+		t.Errorf("a partial fill should not beat a complete one")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]string{"10k": "10000", "10,000": "10000", "0.5": "1/2", "1.5M": "1500000"} {
+		got, err := ParseSize(in)
+		if err != nil || got.RatString() != want {
+			t.Errorf("ParseSize(%q) = %v, %v", in, got, err)
+		}
+	}
+	if _, err := ParseSize("-1"); err == nil {
+		t.Errorf("negative size should fail")
+	}
+}
@@ -1,15 +1,14 @@
 package stellar
 
 import (
-	"fmt"
-
 	"github.com/sdexmon/sdexmon/internal/models"
 	"github.com/stellar/go/clients/horizonclient"
 	"github.com/stellar/go/txnbuild"
 )
 
-// FetchPairConfirmationData fetches market data for pair confirmation screen
-func FetchPairConfirmationData(client *horizonclient.Client, assetA, assetB txnbuild.Asset, poolIDsMap map[string]string) (*models.PairConfirmationData, error) {
+// FetchPairConfirmationData fetches market data for pair confirmation screen.
+// poolID is the pair's liquidity pool, empty when it has none.
+func FetchPairConfirmationData(client *horizonclient.Client, assetA, assetB txnbuild.Asset, poolID string) (*models.PairConfirmationData, error) {
 	data := &models.PairConfirmationData{
 		AssetA:    assetA,
 		AssetB:    assetB,
@@ -59,17 +58,6 @@ func FetchPairConfirmationData(client *horizonclient.Client, assetA, assetB txnb
 		}
 	}
 
-	// Try to find liquidity pool
-	pairKey := fmt.Sprintf("%s-%s", assetA.GetCode(), assetB.GetCode())
-	reversePairKey := fmt.Sprintf("%s-%s", assetB.GetCode(), assetA.GetCode())
-
-	poolID := ""
-	if id, ok := poolIDsMap[pairKey]; ok {
-		poolID = id
-	} else if id, ok := poolIDsMap[reversePairKey]; ok {
-		poolID = id
-	}
-
 	// Note: LP data is fetched from stellar.expert API in the main app
 	// We just store the pool ID if found
 	if poolID != "" {
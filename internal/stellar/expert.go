@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/sdexmon/sdexmon/internal/config"
 	"github.com/sdexmon/sdexmon/internal/models"
 )
 
@@ -36,12 +37,41 @@ func (f flexNumber) String() string {
 	return string(f)
 }
 
+// trustlineCount reads stellar.expert's trustlines, which is an object
+// ({"total": n, "authorized": n, "funded": n}) or, from older versions of
+// the API, an array in that order or a plain number; it keeps the total
+type trustlineCount int
+
+func (t *trustlineCount) UnmarshalJSON(data []byte) error {
+	var obj struct {
+		Total int `json:"total"`
+	}
+	if err := json.Unmarshal(data, &obj); err == nil {
+		*t = trustlineCount(obj.Total)
+		return nil
+	}
+	var arr []int
+	if err := json.Unmarshal(data, &arr); err == nil {
+		if len(arr) > 0 {
+			*t = trustlineCount(arr[0])
+		}
+		return nil
+	}
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		*t = trustlineCount(n)
+		return nil
+	}
+	return fmt.Errorf("trustlines must be an object, array or number")
+}
+
 // expertAssetRecord represents a single asset from stellar.expert API
 type expertAssetRecord struct {
-	Asset    string     `json:"asset"`
-	Supply   flexNumber `json:"supply"`
-	Domain   string     `json:"domain"`
-	TomlInfo struct {
+	Asset      string         `json:"asset"`
+	Supply     flexNumber     `json:"supply"`
+	Trustlines trustlineCount `json:"trustlines"`
+	Domain     string         `json:"domain"`
+	TomlInfo   struct {
 		Code   string `json:"code"`
 		Issuer string `json:"issuer"`
 		Name   string `json:"name"`
@@ -61,7 +91,11 @@ func SearchAssetsByDomain(domain string) ([]models.StellarExpertAsset, error) {
 		return nil, fmt.Errorf("domain cannot be empty")
 	}
 
-	url := fmt.Sprintf("https://api.stellar.expert/explorer/public/asset?search=%s", domain)
+	explorer := config.ExplorerURL()
+	if explorer == "" {
+		return nil, fmt.Errorf("stellar.expert does not cover the %s network", config.ActiveNetwork().Name)
+	}
+	url := fmt.Sprintf("%s/asset?search=%s", explorer, domain)
 
 	client := &http.Client{
 		Timeout: 10 * time.Second,
@@ -93,7 +127,7 @@ func SearchAssetsByDomain(domain string) ([]models.StellarExpertAsset, error) {
 			Issuer:     record.TomlInfo.Issuer,
 			Domain:     record.Domain,
 			Supply:     supplyStr,
-			Trustlines: 0, // Not easily available in this format
+			Trustlines: int(record.Trustlines),
 			Name:       record.TomlInfo.Name,
 		}
 		
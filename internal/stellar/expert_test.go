@@ -0,0 +1,24 @@
+package stellar
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTrustlineCountForms(t *testing.T) {
+	for in, want := range map[string]int{
+		`{"total": 120, "authorized": 100, "funded": 80}`: 120,
+		`[120, 100, 80]`: 120,
+		`120`:            120,
+		`null`:           0,
+	} {
+		var rec expertAssetRecord
+		if err := json.Unmarshal([]byte(`{"asset": "USDZ-G-1", "trustlines": `+in+`}`), &rec); err != nil {
+			t.Errorf("%s: %v", in, err)
+			continue
+		}
+		if int(rec.Trustlines) != want {
+			t.Errorf("%s: trustlines = %d, want %d", in, rec.Trustlines, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package tradestore
+
+import (
+	"context"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+)
+
+// Fetcher is the part of the Horizon client backfilling needs
+type Fetcher interface {
+	Trades(request horizonclient.TradeRequest) (hProtocol.TradesPage, error)
+}
+
+const (
+	pageSize = 200
+	// maxPages bounds one backfill run; the next run continues from the
+	// oldest stored trade
+	maxPages = 500
+)
+
+// Backfill brings pair's history up to date from Horizon: first the trades
+// newer than where the last backfill got to, which covers the time sdexmon
+// was not running, then older trades back to since. req selects the pair's
+// assets; its paging fields are overwritten. Once Horizon runs out of older
+// trades the pair is marked complete and only the newer ones are fetched
+// again.
+//
+// The forward pass does not start from the newest stored trade: the live
+// feeds store trades from the moment sdexmon starts, which would hide the
+// gap before them.
+func (s *Store) Backfill(ctx context.Context, client Fetcher, pair string, req horizonclient.TradeRequest, since time.Time) (int, error) {
+	req.Limit = pageSize
+	total := 0
+	pages := 0
+
+	cursor, resume := s.synced(pair)
+	if !resume {
+		// First backfill of the pair: whatever is stored came from feeds
+		// that are still running, so there is no gap before the newest trade
+		if newest, found := s.Newest(pair); found {
+			cursor, resume = newest.ID, true
+			if err := s.setSynced(pair, cursor); err != nil {
+				return total, err
+			}
+		}
+	}
+	if resume {
+		req.Order, req.Cursor = horizonclient.OrderAsc, cursor
+		for ; pages < maxPages; pages++ {
+			recs, err := fetchPage(ctx, client, req)
+			if err != nil {
+				return total, err
+			}
+			n, err := s.Add(pair, convert(recs))
+			total += n
+			if err != nil {
+				return total, err
+			}
+			if len(recs) == 0 {
+				break
+			}
+			req.Cursor = recs[len(recs)-1].PagingToken()
+			if err := s.setSynced(pair, req.Cursor); err != nil {
+				return total, err
+			}
+			if len(recs) < pageSize {
+				break
+			}
+		}
+	}
+
+	if s.Complete(pair) {
+		return total, nil
+	}
+	req.Order, req.Cursor = horizonclient.OrderDesc, ""
+	if oldest, ok := s.Oldest(pair); ok {
+		if oldest.Time.Before(since) {
+			return total, nil
+		}
+		req.Cursor = oldest.ID
+	}
+	for ; pages < maxPages; pages++ {
+		recs, err := fetchPage(ctx, client, req)
+		if err != nil {
+			return total, err
+		}
+		n, err := s.Add(pair, convert(recs))
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if !resume && len(recs) > 0 {
+			// the newest trade Horizon has; later runs continue from it
+			resume = true
+			if err := s.setSynced(pair, recs[0].PagingToken()); err != nil {
+				return total, err
+			}
+		}
+		if len(recs) < pageSize {
+			return total, s.setComplete(pair)
+		}
+		last := recs[len(recs)-1]
+		if last.LedgerCloseTime.Before(since) {
+			return total, nil
+		}
+		req.Cursor = last.PagingToken()
+	}
+	return total, nil
+}
+
+func fetchPage(ctx context.Context, client Fetcher, req horizonclient.TradeRequest) ([]hProtocol.Trade, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	page, err := client.Trades(req)
+	if err != nil {
+		return nil, err
+	}
+	return page.Embedded.Records, nil
+}
+
+func convert(recs []hProtocol.Trade) []Trade {
+	out := make([]Trade, len(recs))
+	for i, t := range recs {
+		out[i] = FromHorizon(t)
+	}
+	return out
+}
@@ -0,0 +1,347 @@
+// Package tradestore keeps every trade seen for a pair in an embedded bbolt
+// database and backfills older history from Horizon.
+package tradestore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Trade is the stored form of a Horizon trade, oriented like the pair it is
+// stored under
+type Trade struct {
+	ID             string    `json:"id"` // Horizon paging token
+	Time           time.Time `json:"t"`
+	BaseAmount     string    `json:"ba"`
+	CounterAmount  string    `json:"ca"`
+	PriceN         int64     `json:"pn"`
+	PriceD         int64     `json:"pd"`
+	BaseIsSeller   bool      `json:"bs,omitempty"`
+	BaseAccount    string    `json:"bac,omitempty"`
+	CounterAccount string    `json:"cac,omitempty"`
+	BasePool       string    `json:"bp,omitempty"`
+	CounterPool    string    `json:"cp,omitempty"`
+}
+
+// FromHorizon converts a Horizon trade record
+func FromHorizon(t hProtocol.Trade) Trade {
+	return Trade{
+		ID:             t.PagingToken(),
+		Time:           t.LedgerCloseTime.UTC(),
+		BaseAmount:     t.BaseAmount,
+		CounterAmount:  t.CounterAmount,
+		PriceN:         t.Price.N,
+		PriceD:         t.Price.D,
+		BaseIsSeller:   t.BaseIsSeller,
+		BaseAccount:    t.BaseAccount,
+		CounterAccount: t.CounterAccount,
+		BasePool:       t.BaseLiquidityPoolID,
+		CounterPool:    t.CounterLiquidityPoolID,
+	}
+}
+
+// Horizon converts back to the Horizon record, with the fields the store
+// keeps
+func (t Trade) Horizon() hProtocol.Trade {
+	return hProtocol.Trade{
+		ID:                     t.ID,
+		PT:                     t.ID,
+		LedgerCloseTime:        t.Time,
+		BaseAmount:             t.BaseAmount,
+		CounterAmount:          t.CounterAmount,
+		Price:                  hProtocol.TradePrice{N: t.PriceN, D: t.PriceD},
+		BaseIsSeller:           t.BaseIsSeller,
+		BaseAccount:            t.BaseAccount,
+		CounterAccount:         t.CounterAccount,
+		BaseLiquidityPoolID:    t.BasePool,
+		CounterLiquidityPoolID: t.CounterPool,
+	}
+}
+
+// Store is a trade database with one bucket per pair. Trades are keyed by
+// close time, then paging token, so they iterate in time order and adding a
+// trade twice keeps one copy. Safe for concurrent use.
+type Store struct {
+	db *bolt.DB
+}
+
+var (
+	tradesKey = []byte("trades")
+	metaKey   = []byte("meta")
+	// completeKey marks a pair whose history has been backfilled to the
+	// first trade Horizon has
+	completeKey = []byte("complete")
+	// syncedKey holds the paging token the next backfill continues forward
+	// from; every trade before it and after the oldest stored one is stored
+	syncedKey = []byte("synced")
+)
+
+// Open opens or creates the database at path. It fails after a second when
+// another process holds it.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("trade store %s: %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Add stores trades for pair and returns how many were new
+func (s *Store) Add(pair string, trades []Trade) (int, error) {
+	if len(trades) == 0 {
+		return 0, nil
+	}
+	added := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := pairBucket(tx, pair, tradesKey)
+		if err != nil {
+			return err
+		}
+		for _, t := range trades {
+			k := key(t.Time, t.ID)
+			if b.Get(k) != nil {
+				continue
+			}
+			v, err := json.Marshal(t)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(k, v); err != nil {
+				return err
+			}
+			added++
+		}
+		return nil
+	})
+	return added, err
+}
+
+// Count returns the number of trades stored for pair
+func (s *Store) Count(pair string) int {
+	n := 0
+	s.db.View(func(tx *bolt.Tx) error {
+		if b := readBucket(tx, pair, tradesKey); b != nil {
+			n = b.Stats().KeyN
+		}
+		return nil
+	})
+	return n
+}
+
+// Latest returns up to n trades for pair, newest first, after skipping the
+// skip newest ones
+func (s *Store) Latest(pair string, skip, n int) ([]Trade, error) {
+	var out []Trade
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := readBucket(tx, pair, tradesKey)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil && len(out) < n; k, v = c.Prev() {
+			if skip > 0 {
+				skip--
+				continue
+			}
+			var t Trade
+			if err := json.Unmarshal(v, &t); err != nil {
+				return err
+			}
+			out = append(out, t)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// Oldest and Newest return the first and last stored trade of pair
+func (s *Store) Oldest(pair string) (Trade, bool) { return s.edge(pair, false) }
+func (s *Store) Newest(pair string) (Trade, bool) { return s.edge(pair, true) }
+
+func (s *Store) edge(pair string, last bool) (Trade, bool) {
+	var t Trade
+	found := false
+	s.db.View(func(tx *bolt.Tx) error {
+		b := readBucket(tx, pair, tradesKey)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		k, v := c.First()
+		if last {
+			k, v = c.Last()
+		}
+		if k != nil && json.Unmarshal(v, &t) == nil {
+			found = true
+		}
+		return nil
+	})
+	return t, found
+}
+
+// Since calls fn for every trade of pair closed at or after since, oldest
+// first, until fn returns false
+func (s *Store) Since(pair string, since time.Time, fn func(Trade) bool) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		b := readBucket(tx, pair, tradesKey)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Seek(key(since, "")); k != nil; k, v = c.Next() {
+			var t Trade
+			if err := json.Unmarshal(v, &t); err != nil {
+				return err
+			}
+			if !fn(t) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// AccountVolume is the volume one account, or liquidity pool, traded on a
+// pair
+type AccountVolume struct {
+	Account string // account ID, or pool ID for pool trades
+	Trades  int
+	Base    *big.Rat
+	Counter *big.Rat
+}
+
+// VolumeByAccount sums the volume each party traded on pair since the given
+// time, largest base volume first. Both sides of a trade are counted, so the
+// totals add up to twice the pair's volume.
+func (s *Store) VolumeByAccount(pair string, since time.Time) ([]AccountVolume, error) {
+	byAccount := map[string]*AccountVolume{}
+	add := func(account string, base, counter *big.Rat) {
+		if account == "" {
+			return
+		}
+		v := byAccount[account]
+		if v == nil {
+			v = &AccountVolume{Account: account, Base: new(big.Rat), Counter: new(big.Rat)}
+			byAccount[account] = v
+		}
+		v.Trades++
+		v.Base.Add(v.Base, base)
+		v.Counter.Add(v.Counter, counter)
+	}
+	err := s.Since(pair, since, func(t Trade) bool {
+		base, ok1 := new(big.Rat).SetString(t.BaseAmount)
+		counter, ok2 := new(big.Rat).SetString(t.CounterAmount)
+		if !ok1 || !ok2 {
+			return true
+		}
+		add(firstNonEmpty(t.BaseAccount, t.BasePool), base, counter)
+		add(firstNonEmpty(t.CounterAccount, t.CounterPool), base, counter)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]AccountVolume, 0, len(byAccount))
+	for _, v := range byAccount {
+		out = append(out, *v)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if c := out[i].Base.Cmp(out[j].Base); c != 0 {
+			return c > 0
+		}
+		return out[i].Account < out[j].Account
+	})
+	return out, nil
+}
+
+// Complete reports whether pair's history has been backfilled to its start
+func (s *Store) Complete(pair string) bool {
+	done := false
+	s.db.View(func(tx *bolt.Tx) error {
+		if b := readBucket(tx, pair, metaKey); b != nil {
+			done = b.Get(completeKey) != nil
+		}
+		return nil
+	})
+	return done
+}
+
+func (s *Store) setComplete(pair string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := pairBucket(tx, pair, metaKey)
+		if err != nil {
+			return err
+		}
+		return b.Put(completeKey, []byte{1})
+	})
+}
+
+// synced returns the paging token the forward backfill resumes from
+func (s *Store) synced(pair string) (string, bool) {
+	var cursor string
+	s.db.View(func(tx *bolt.Tx) error {
+		if b := readBucket(tx, pair, metaKey); b != nil {
+			cursor = string(b.Get(syncedKey))
+		}
+		return nil
+	})
+	return cursor, cursor != ""
+}
+
+func (s *Store) setSynced(pair, cursor string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := pairBucket(tx, pair, metaKey)
+		if err != nil {
+			return err
+		}
+		return b.Put(syncedKey, []byte(cursor))
+	})
+}
+
+// key orders trades by close time, with the paging token breaking ties
+func key(t time.Time, id string) []byte {
+	k := make([]byte, 8, 8+len(id))
+	binary.BigEndian.PutUint64(k, uint64(t.UnixNano()))
+	return append(k, id...)
+}
+
+func pairBucket(tx *bolt.Tx, pair string, name []byte) (*bolt.Bucket, error) {
+	if pair == "" {
+		return nil, errors.New("trade store: empty pair")
+	}
+	p, err := tx.CreateBucketIfNotExists([]byte(pair))
+	if err != nil {
+		return nil, err
+	}
+	return p.CreateBucketIfNotExists(name)
+}
+
+func readBucket(tx *bolt.Tx, pair string, name []byte) *bolt.Bucket {
+	p := tx.Bucket([]byte(pair))
+	if p == nil {
+		return nil
+	}
+	return p.Bucket(name)
+}
+
+func firstNonEmpty(s ...string) string {
+	for _, v := range s {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
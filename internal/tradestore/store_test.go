@@ -0,0 +1,172 @@
+package tradestore
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+)
+
+const pair = "XLM:native/USDC:GA5ZSEJYB37JRC5AVCIA5MOP4RHTM335X2KGX3IHOJAPP5RE34K4KZVN"
+
+var t0 = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func open(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "trades.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// trade i closes i minutes after t0
+func trade(i int, base, counter string) hProtocol.Trade {
+	return hProtocol.Trade{
+		PT:              fmt.Sprintf("%d-1", 1000+i),
+		LedgerCloseTime: t0.Add(time.Duration(i) * time.Minute),
+		BaseAmount:      "10",
+		CounterAmount:   "2.5",
+		Price:           hProtocol.TradePrice{N: 1, D: 4},
+		BaseAccount:     base,
+		CounterAccount:  counter,
+	}
+}
+
+func TestAddIsIdempotentAndLatestPagesBack(t *testing.T) {
+	s := open(t)
+	var trades []Trade
+	for i := 0; i < 5; i++ {
+		trades = append(trades, FromHorizon(trade(i, "GA", "GB")))
+	}
+	if n, err := s.Add(pair, trades); n != 5 || err != nil {
+		t.Fatalf("Add: %d %v", n, err)
+	}
+	if n, _ := s.Add(pair, trades[3:]); n != 0 || s.Count(pair) != 5 {
+		t.Fatalf("re-adding stored %d, count %d", n, s.Count(pair))
+	}
+
+	page, err := s.Latest(pair, 1, 2)
+	if err != nil || len(page) != 2 || page[0].ID != "1003-1" || page[1].ID != "1002-1" {
+		t.Fatalf("Latest: %+v %v", page, err)
+	}
+	if h := page[0].Horizon(); h.PagingToken() != "1003-1" || h.Price.D != 4 || !h.LedgerCloseTime.Equal(t0.Add(3*time.Minute)) {
+		t.Errorf("Horizon(): %+v", h)
+	}
+}
+
+func TestVolumeByAccount(t *testing.T) {
+	s := open(t)
+	s.Add(pair, []Trade{
+		FromHorizon(trade(0, "GOLD", "GB")), // before the window
+		FromHorizon(trade(10, "GA", "GB")),
+		FromHorizon(trade(11, "GA", "GC")),
+	})
+	vols, err := s.VolumeByAccount(pair, t0.Add(5*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, v := range vols {
+		got[v.Account] = fmt.Sprintf("%d %s %s", v.Trades, v.Base.FloatString(1), v.Counter.FloatString(1))
+	}
+	want := map[string]string{"GA": "2 20.0 5.0", "GB": "1 10.0 2.5", "GC": "1 10.0 2.5"}
+	if fmt.Sprint(got) != fmt.Sprint(want) || vols[0].Account != "GA" {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// fakeHorizon serves trades 0..n-1 in pages like Horizon does
+type fakeHorizon struct {
+	trades []hProtocol.Trade
+	reqs   []horizonclient.TradeRequest
+}
+
+func (f *fakeHorizon) Trades(req horizonclient.TradeRequest) (hProtocol.TradesPage, error) {
+	f.reqs = append(f.reqs, req)
+	list := append([]hProtocol.Trade(nil), f.trades...)
+	if req.Order == horizonclient.OrderDesc {
+		sort.Slice(list, func(i, j int) bool { return list[i].LedgerCloseTime.After(list[j].LedgerCloseTime) })
+	}
+	start := 0
+	if req.Cursor != "" {
+		for i, t := range list {
+			if t.PT == req.Cursor {
+				start = i + 1
+			}
+		}
+	}
+	end := min(len(list), start+int(req.Limit))
+	var page hProtocol.TradesPage
+	page.Embedded.Records = list[start:end]
+	return page, nil
+}
+
+func TestBackfillFillsBothDirectionsAndMarksComplete(t *testing.T) {
+	s := open(t)
+	f := &fakeHorizon{}
+	for i := 0; i < 450; i++ {
+		f.trades = append(f.trades, trade(i, "GA", "GB"))
+	}
+	// sdexmon saw a few recent trades before being closed
+	s.Add(pair, convert(f.trades[300:310]))
+
+	n, err := s.Backfill(context.Background(), f, pair, horizonclient.TradeRequest{}, time.Time{})
+	if err != nil || n != 440 || s.Count(pair) != 450 {
+		t.Fatalf("backfilled %d (%v), stored %d", n, err, s.Count(pair))
+	}
+	if !s.Complete(pair) {
+		t.Errorf("history should be complete")
+	}
+
+	f.trades = append(f.trades, trade(450, "GA", "GB"))
+	f.reqs = nil
+	if n, _ := s.Backfill(context.Background(), f, pair, horizonclient.TradeRequest{}, time.Time{}); n != 1 || len(f.reqs) != 1 {
+		t.Errorf("complete pair should only fetch newer trades: %d added, %d requests", n, len(f.reqs))
+	}
+}
+
+func TestBackfillStopsAtTheWindow(t *testing.T) {
+	s := open(t)
+	f := &fakeHorizon{}
+	for i := 0; i < 1000; i++ {
+		f.trades = append(f.trades, trade(i, "GA", "GB"))
+	}
+	since := t0.Add(700 * time.Minute)
+	if _, err := s.Backfill(context.Background(), f, pair, horizonclient.TradeRequest{}, since); err != nil {
+		t.Fatal(err)
+	}
+	oldest, _ := s.Oldest(pair)
+	if oldest.Time.After(since) || s.Complete(pair) || s.Count(pair) != 400 {
+		t.Errorf("oldest %s, complete %v, count %d", oldest.Time, s.Complete(pair), s.Count(pair))
+	}
+}
+
+func TestBackfillFillsTheGapBeforeLiveTrades(t *testing.T) {
+	s := open(t)
+	f := &fakeHorizon{}
+	for i := 0; i < 300; i++ {
+		f.trades = append(f.trades, trade(i, "GA", "GB"))
+	}
+	if _, err := s.Backfill(context.Background(), f, pair, horizonclient.TradeRequest{}, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// sdexmon was closed while 150 trades were made, then the live feed
+	// stored the latest ones before the backfill got to the pair
+	for i := 300; i < 450; i++ {
+		f.trades = append(f.trades, trade(i, "GA", "GB"))
+	}
+	s.Add(pair, convert(f.trades[440:450]))
+
+	n, err := s.Backfill(context.Background(), f, pair, horizonclient.TradeRequest{}, time.Time{})
+	if err != nil || n != 140 || s.Count(pair) != 450 {
+		t.Fatalf("backfilled %d (%v), stored %d; want the 140 trades of the gap", n, err, s.Count(pair))
+	}
+}